@@ -0,0 +1,10 @@
+// Package api содержит описание API календаря: EventService.proto, сгенерированный
+// код (gen) и спецификацию OpenAPI, которую выпускает `make generate`.
+package api
+
+import _ "embed" // для встраивания спецификации OpenAPI
+
+// OpenAPI - спецификация REST-отображения CalendarService (OpenAPI 2.0, JSON).
+//
+//go:embed EventService.swagger.json
+var OpenAPI []byte
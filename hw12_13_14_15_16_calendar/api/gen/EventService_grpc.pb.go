@@ -0,0 +1,825 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: EventService.proto
+
+package gen
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CalendarService_CreateEvent_FullMethodName         = "/event.CalendarService/CreateEvent"
+	CalendarService_UpdateEvent_FullMethodName         = "/event.CalendarService/UpdateEvent"
+	CalendarService_DeleteEvent_FullMethodName         = "/event.CalendarService/DeleteEvent"
+	CalendarService_ListTrash_FullMethodName           = "/event.CalendarService/ListTrash"
+	CalendarService_RestoreEvent_FullMethodName        = "/event.CalendarService/RestoreEvent"
+	CalendarService_GetEventHistory_FullMethodName     = "/event.CalendarService/GetEventHistory"
+	CalendarService_GetEventByID_FullMethodName        = "/event.CalendarService/GetEventByID"
+	CalendarService_InviteAttendees_FullMethodName     = "/event.CalendarService/InviteAttendees"
+	CalendarService_RespondToInvitation_FullMethodName = "/event.CalendarService/RespondToInvitation"
+	CalendarService_ListEventsForDay_FullMethodName    = "/event.CalendarService/ListEventsForDay"
+	CalendarService_ListEventsForWeek_FullMethodName   = "/event.CalendarService/ListEventsForWeek"
+	CalendarService_ListEventsForMonth_FullMethodName  = "/event.CalendarService/ListEventsForMonth"
+	CalendarService_ListEvents_FullMethodName          = "/event.CalendarService/ListEvents"
+	CalendarService_SearchEvents_FullMethodName        = "/event.CalendarService/SearchEvents"
+	CalendarService_GetSettings_FullMethodName         = "/event.CalendarService/GetSettings"
+	CalendarService_UpdateSettings_FullMethodName      = "/event.CalendarService/UpdateSettings"
+	CalendarService_FreeBusy_FullMethodName            = "/event.CalendarService/FreeBusy"
+	CalendarService_FindSlots_FullMethodName           = "/event.CalendarService/FindSlots"
+	CalendarService_WatchEvents_FullMethodName         = "/event.CalendarService/WatchEvents"
+)
+
+// CalendarServiceClient is the client API for CalendarService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// REST-отображение (grpc-gateway) обслуживается тем же процессом под префиксом /api/v1 и является
+// каноническим REST API. Прежние маршруты /api/... с такими же путями без v1 оставлены для
+// совместимости и отвечают с заголовком Deprecation.
+// Пользователь передается заголовком X-User-ID, как и в остальном HTTP API.
+type CalendarServiceClient interface {
+	CreateEvent(ctx context.Context, in *CreateEventRequest, opts ...grpc.CallOption) (*CreateEventResponse, error)
+	UpdateEvent(ctx context.Context, in *UpdateEventRequest, opts ...grpc.CallOption) (*UpdateEventResponse, error)
+	DeleteEvent(ctx context.Context, in *DeleteEventRequest, opts ...grpc.CallOption) (*DeleteEventResponse, error)
+	ListTrash(ctx context.Context, in *ListTrashRequest, opts ...grpc.CallOption) (*ListTrashResponse, error)
+	RestoreEvent(ctx context.Context, in *RestoreEventRequest, opts ...grpc.CallOption) (*RestoreEventResponse, error)
+	GetEventHistory(ctx context.Context, in *GetEventHistoryRequest, opts ...grpc.CallOption) (*GetEventHistoryResponse, error)
+	GetEventByID(ctx context.Context, in *GetEventByIDRequest, opts ...grpc.CallOption) (*GetEventByIDResponse, error)
+	InviteAttendees(ctx context.Context, in *InviteAttendeesRequest, opts ...grpc.CallOption) (*InviteAttendeesResponse, error)
+	RespondToInvitation(ctx context.Context, in *RespondToInvitationRequest, opts ...grpc.CallOption) (*RespondToInvitationResponse, error)
+	ListEventsForDay(ctx context.Context, in *ListForDayRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
+	ListEventsForWeek(ctx context.Context, in *ListForWeekRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
+	ListEventsForMonth(ctx context.Context, in *ListForMonthRequest, opts ...grpc.CallOption) (*ListEventsResponse, error)
+	ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*ListEventsPageResponse, error)
+	SearchEvents(ctx context.Context, in *SearchEventsRequest, opts ...grpc.CallOption) (*SearchEventsResponse, error)
+	GetSettings(ctx context.Context, in *GetSettingsRequest, opts ...grpc.CallOption) (*UserSettings, error)
+	UpdateSettings(ctx context.Context, in *UpdateSettingsRequest, opts ...grpc.CallOption) (*UserSettings, error)
+	FreeBusy(ctx context.Context, in *FreeBusyRequest, opts ...grpc.CallOption) (*FreeBusyResponse, error)
+	FindSlots(ctx context.Context, in *FindSlotsRequest, opts ...grpc.CallOption) (*FindSlotsResponse, error)
+	// WatchEvents не отображается в REST: для браузеров есть SSE-поток GET /api/events/stream.
+	// Медленный подписчик отключается со статусом RESOURCE_EXHAUSTED. Событие, ушедшее из окна
+	// подписки, приходит как CHANGE_TYPE_DELETED с последним состоянием, которое видел подписчик.
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EventChange], error)
+}
+
+type calendarServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCalendarServiceClient(cc grpc.ClientConnInterface) CalendarServiceClient {
+	return &calendarServiceClient{cc}
+}
+
+func (c *calendarServiceClient) CreateEvent(ctx context.Context, in *CreateEventRequest, opts ...grpc.CallOption) (*CreateEventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateEventResponse)
+	err := c.cc.Invoke(ctx, CalendarService_CreateEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) UpdateEvent(ctx context.Context, in *UpdateEventRequest, opts ...grpc.CallOption) (*UpdateEventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateEventResponse)
+	err := c.cc.Invoke(ctx, CalendarService_UpdateEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) DeleteEvent(ctx context.Context, in *DeleteEventRequest, opts ...grpc.CallOption) (*DeleteEventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteEventResponse)
+	err := c.cc.Invoke(ctx, CalendarService_DeleteEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) ListTrash(ctx context.Context, in *ListTrashRequest, opts ...grpc.CallOption) (*ListTrashResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTrashResponse)
+	err := c.cc.Invoke(ctx, CalendarService_ListTrash_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) RestoreEvent(ctx context.Context, in *RestoreEventRequest, opts ...grpc.CallOption) (*RestoreEventResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreEventResponse)
+	err := c.cc.Invoke(ctx, CalendarService_RestoreEvent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) GetEventHistory(ctx context.Context, in *GetEventHistoryRequest, opts ...grpc.CallOption) (*GetEventHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetEventHistoryResponse)
+	err := c.cc.Invoke(ctx, CalendarService_GetEventHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) GetEventByID(ctx context.Context, in *GetEventByIDRequest, opts ...grpc.CallOption) (*GetEventByIDResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetEventByIDResponse)
+	err := c.cc.Invoke(ctx, CalendarService_GetEventByID_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) InviteAttendees(ctx context.Context, in *InviteAttendeesRequest, opts ...grpc.CallOption) (*InviteAttendeesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InviteAttendeesResponse)
+	err := c.cc.Invoke(ctx, CalendarService_InviteAttendees_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) RespondToInvitation(ctx context.Context, in *RespondToInvitationRequest, opts ...grpc.CallOption) (*RespondToInvitationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RespondToInvitationResponse)
+	err := c.cc.Invoke(ctx, CalendarService_RespondToInvitation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) ListEventsForDay(ctx context.Context, in *ListForDayRequest, opts ...grpc.CallOption) (*ListEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEventsResponse)
+	err := c.cc.Invoke(ctx, CalendarService_ListEventsForDay_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) ListEventsForWeek(ctx context.Context, in *ListForWeekRequest, opts ...grpc.CallOption) (*ListEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEventsResponse)
+	err := c.cc.Invoke(ctx, CalendarService_ListEventsForWeek_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) ListEventsForMonth(ctx context.Context, in *ListForMonthRequest, opts ...grpc.CallOption) (*ListEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEventsResponse)
+	err := c.cc.Invoke(ctx, CalendarService_ListEventsForMonth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) ListEvents(ctx context.Context, in *ListEventsRequest, opts ...grpc.CallOption) (*ListEventsPageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEventsPageResponse)
+	err := c.cc.Invoke(ctx, CalendarService_ListEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) SearchEvents(ctx context.Context, in *SearchEventsRequest, opts ...grpc.CallOption) (*SearchEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchEventsResponse)
+	err := c.cc.Invoke(ctx, CalendarService_SearchEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) GetSettings(ctx context.Context, in *GetSettingsRequest, opts ...grpc.CallOption) (*UserSettings, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserSettings)
+	err := c.cc.Invoke(ctx, CalendarService_GetSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) UpdateSettings(ctx context.Context, in *UpdateSettingsRequest, opts ...grpc.CallOption) (*UserSettings, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserSettings)
+	err := c.cc.Invoke(ctx, CalendarService_UpdateSettings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) FreeBusy(ctx context.Context, in *FreeBusyRequest, opts ...grpc.CallOption) (*FreeBusyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FreeBusyResponse)
+	err := c.cc.Invoke(ctx, CalendarService_FreeBusy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) FindSlots(ctx context.Context, in *FindSlotsRequest, opts ...grpc.CallOption) (*FindSlotsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindSlotsResponse)
+	err := c.cc.Invoke(ctx, CalendarService_FindSlots_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarServiceClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EventChange], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CalendarService_ServiceDesc.Streams[0], CalendarService_WatchEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchEventsRequest, EventChange]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CalendarService_WatchEventsClient = grpc.ServerStreamingClient[EventChange]
+
+// CalendarServiceServer is the server API for CalendarService service.
+// All implementations must embed UnimplementedCalendarServiceServer
+// for forward compatibility.
+//
+// REST-отображение (grpc-gateway) обслуживается тем же процессом под префиксом /api/v1 и является
+// каноническим REST API. Прежние маршруты /api/... с такими же путями без v1 оставлены для
+// совместимости и отвечают с заголовком Deprecation.
+// Пользователь передается заголовком X-User-ID, как и в остальном HTTP API.
+type CalendarServiceServer interface {
+	CreateEvent(context.Context, *CreateEventRequest) (*CreateEventResponse, error)
+	UpdateEvent(context.Context, *UpdateEventRequest) (*UpdateEventResponse, error)
+	DeleteEvent(context.Context, *DeleteEventRequest) (*DeleteEventResponse, error)
+	ListTrash(context.Context, *ListTrashRequest) (*ListTrashResponse, error)
+	RestoreEvent(context.Context, *RestoreEventRequest) (*RestoreEventResponse, error)
+	GetEventHistory(context.Context, *GetEventHistoryRequest) (*GetEventHistoryResponse, error)
+	GetEventByID(context.Context, *GetEventByIDRequest) (*GetEventByIDResponse, error)
+	InviteAttendees(context.Context, *InviteAttendeesRequest) (*InviteAttendeesResponse, error)
+	RespondToInvitation(context.Context, *RespondToInvitationRequest) (*RespondToInvitationResponse, error)
+	ListEventsForDay(context.Context, *ListForDayRequest) (*ListEventsResponse, error)
+	ListEventsForWeek(context.Context, *ListForWeekRequest) (*ListEventsResponse, error)
+	ListEventsForMonth(context.Context, *ListForMonthRequest) (*ListEventsResponse, error)
+	ListEvents(context.Context, *ListEventsRequest) (*ListEventsPageResponse, error)
+	SearchEvents(context.Context, *SearchEventsRequest) (*SearchEventsResponse, error)
+	GetSettings(context.Context, *GetSettingsRequest) (*UserSettings, error)
+	UpdateSettings(context.Context, *UpdateSettingsRequest) (*UserSettings, error)
+	FreeBusy(context.Context, *FreeBusyRequest) (*FreeBusyResponse, error)
+	FindSlots(context.Context, *FindSlotsRequest) (*FindSlotsResponse, error)
+	// WatchEvents не отображается в REST: для браузеров есть SSE-поток GET /api/events/stream.
+	// Медленный подписчик отключается со статусом RESOURCE_EXHAUSTED. Событие, ушедшее из окна
+	// подписки, приходит как CHANGE_TYPE_DELETED с последним состоянием, которое видел подписчик.
+	WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[EventChange]) error
+	mustEmbedUnimplementedCalendarServiceServer()
+}
+
+// UnimplementedCalendarServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCalendarServiceServer struct{}
+
+func (UnimplementedCalendarServiceServer) CreateEvent(context.Context, *CreateEventRequest) (*CreateEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateEvent not implemented")
+}
+func (UnimplementedCalendarServiceServer) UpdateEvent(context.Context, *UpdateEventRequest) (*UpdateEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateEvent not implemented")
+}
+func (UnimplementedCalendarServiceServer) DeleteEvent(context.Context, *DeleteEventRequest) (*DeleteEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteEvent not implemented")
+}
+func (UnimplementedCalendarServiceServer) ListTrash(context.Context, *ListTrashRequest) (*ListTrashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrash not implemented")
+}
+func (UnimplementedCalendarServiceServer) RestoreEvent(context.Context, *RestoreEventRequest) (*RestoreEventResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreEvent not implemented")
+}
+func (UnimplementedCalendarServiceServer) GetEventHistory(context.Context, *GetEventHistoryRequest) (*GetEventHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEventHistory not implemented")
+}
+func (UnimplementedCalendarServiceServer) GetEventByID(context.Context, *GetEventByIDRequest) (*GetEventByIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEventByID not implemented")
+}
+func (UnimplementedCalendarServiceServer) InviteAttendees(context.Context, *InviteAttendeesRequest) (*InviteAttendeesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InviteAttendees not implemented")
+}
+func (UnimplementedCalendarServiceServer) RespondToInvitation(context.Context, *RespondToInvitationRequest) (*RespondToInvitationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RespondToInvitation not implemented")
+}
+func (UnimplementedCalendarServiceServer) ListEventsForDay(context.Context, *ListForDayRequest) (*ListEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEventsForDay not implemented")
+}
+func (UnimplementedCalendarServiceServer) ListEventsForWeek(context.Context, *ListForWeekRequest) (*ListEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEventsForWeek not implemented")
+}
+func (UnimplementedCalendarServiceServer) ListEventsForMonth(context.Context, *ListForMonthRequest) (*ListEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEventsForMonth not implemented")
+}
+func (UnimplementedCalendarServiceServer) ListEvents(context.Context, *ListEventsRequest) (*ListEventsPageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEvents not implemented")
+}
+func (UnimplementedCalendarServiceServer) SearchEvents(context.Context, *SearchEventsRequest) (*SearchEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchEvents not implemented")
+}
+func (UnimplementedCalendarServiceServer) GetSettings(context.Context, *GetSettingsRequest) (*UserSettings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSettings not implemented")
+}
+func (UnimplementedCalendarServiceServer) UpdateSettings(context.Context, *UpdateSettingsRequest) (*UserSettings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSettings not implemented")
+}
+func (UnimplementedCalendarServiceServer) FreeBusy(context.Context, *FreeBusyRequest) (*FreeBusyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FreeBusy not implemented")
+}
+func (UnimplementedCalendarServiceServer) FindSlots(context.Context, *FindSlotsRequest) (*FindSlotsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindSlots not implemented")
+}
+func (UnimplementedCalendarServiceServer) WatchEvents(*WatchEventsRequest, grpc.ServerStreamingServer[EventChange]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedCalendarServiceServer) mustEmbedUnimplementedCalendarServiceServer() {}
+func (UnimplementedCalendarServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeCalendarServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CalendarServiceServer will
+// result in compilation errors.
+type UnsafeCalendarServiceServer interface {
+	mustEmbedUnimplementedCalendarServiceServer()
+}
+
+func RegisterCalendarServiceServer(s grpc.ServiceRegistrar, srv CalendarServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCalendarServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CalendarService_ServiceDesc, srv)
+}
+
+func _CalendarService_CreateEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).CreateEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_CreateEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).CreateEvent(ctx, req.(*CreateEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_UpdateEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).UpdateEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_UpdateEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).UpdateEvent(ctx, req.(*UpdateEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_DeleteEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).DeleteEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_DeleteEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).DeleteEvent(ctx, req.(*DeleteEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_ListTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTrashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).ListTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_ListTrash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).ListTrash(ctx, req.(*ListTrashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_RestoreEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreEventRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).RestoreEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_RestoreEvent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).RestoreEvent(ctx, req.(*RestoreEventRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_GetEventHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).GetEventHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_GetEventHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).GetEventHistory(ctx, req.(*GetEventHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_GetEventByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).GetEventByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_GetEventByID_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).GetEventByID(ctx, req.(*GetEventByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_InviteAttendees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InviteAttendeesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).InviteAttendees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_InviteAttendees_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).InviteAttendees(ctx, req.(*InviteAttendeesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_RespondToInvitation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RespondToInvitationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).RespondToInvitation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_RespondToInvitation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).RespondToInvitation(ctx, req.(*RespondToInvitationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_ListEventsForDay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListForDayRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).ListEventsForDay(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_ListEventsForDay_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).ListEventsForDay(ctx, req.(*ListForDayRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_ListEventsForWeek_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListForWeekRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).ListEventsForWeek(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_ListEventsForWeek_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).ListEventsForWeek(ctx, req.(*ListForWeekRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_ListEventsForMonth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListForMonthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).ListEventsForMonth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_ListEventsForMonth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).ListEventsForMonth(ctx, req.(*ListForMonthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_ListEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).ListEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_ListEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).ListEvents(ctx, req.(*ListEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_SearchEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).SearchEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_SearchEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).SearchEvents(ctx, req.(*SearchEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_GetSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).GetSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_GetSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).GetSettings(ctx, req.(*GetSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_UpdateSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).UpdateSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_UpdateSettings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).UpdateSettings(ctx, req.(*UpdateSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_FreeBusy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FreeBusyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).FreeBusy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_FreeBusy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).FreeBusy(ctx, req.(*FreeBusyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_FindSlots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindSlotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServiceServer).FindSlots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CalendarService_FindSlots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServiceServer).FindSlots(ctx, req.(*FindSlotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CalendarService_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CalendarServiceServer).WatchEvents(m, &grpc.GenericServerStream[WatchEventsRequest, EventChange]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CalendarService_WatchEventsServer = grpc.ServerStreamingServer[EventChange]
+
+// CalendarService_ServiceDesc is the grpc.ServiceDesc for CalendarService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CalendarService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "event.CalendarService",
+	HandlerType: (*CalendarServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateEvent",
+			Handler:    _CalendarService_CreateEvent_Handler,
+		},
+		{
+			MethodName: "UpdateEvent",
+			Handler:    _CalendarService_UpdateEvent_Handler,
+		},
+		{
+			MethodName: "DeleteEvent",
+			Handler:    _CalendarService_DeleteEvent_Handler,
+		},
+		{
+			MethodName: "ListTrash",
+			Handler:    _CalendarService_ListTrash_Handler,
+		},
+		{
+			MethodName: "RestoreEvent",
+			Handler:    _CalendarService_RestoreEvent_Handler,
+		},
+		{
+			MethodName: "GetEventHistory",
+			Handler:    _CalendarService_GetEventHistory_Handler,
+		},
+		{
+			MethodName: "GetEventByID",
+			Handler:    _CalendarService_GetEventByID_Handler,
+		},
+		{
+			MethodName: "InviteAttendees",
+			Handler:    _CalendarService_InviteAttendees_Handler,
+		},
+		{
+			MethodName: "RespondToInvitation",
+			Handler:    _CalendarService_RespondToInvitation_Handler,
+		},
+		{
+			MethodName: "ListEventsForDay",
+			Handler:    _CalendarService_ListEventsForDay_Handler,
+		},
+		{
+			MethodName: "ListEventsForWeek",
+			Handler:    _CalendarService_ListEventsForWeek_Handler,
+		},
+		{
+			MethodName: "ListEventsForMonth",
+			Handler:    _CalendarService_ListEventsForMonth_Handler,
+		},
+		{
+			MethodName: "ListEvents",
+			Handler:    _CalendarService_ListEvents_Handler,
+		},
+		{
+			MethodName: "SearchEvents",
+			Handler:    _CalendarService_SearchEvents_Handler,
+		},
+		{
+			MethodName: "GetSettings",
+			Handler:    _CalendarService_GetSettings_Handler,
+		},
+		{
+			MethodName: "UpdateSettings",
+			Handler:    _CalendarService_UpdateSettings_Handler,
+		},
+		{
+			MethodName: "FreeBusy",
+			Handler:    _CalendarService_FreeBusy_Handler,
+		},
+		{
+			MethodName: "FindSlots",
+			Handler:    _CalendarService_FindSlots_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _CalendarService_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "EventService.proto",
+}
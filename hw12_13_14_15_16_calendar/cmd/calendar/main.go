@@ -8,10 +8,12 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // база часовых поясов на случай образа без системной
 
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/app"
 	cfg "github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/config"
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/logger"
+	grpcserver "github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/server/grpc"
 	internalhttp "github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/server/http"
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
 	memorystorage "github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage/memory"
@@ -28,6 +30,23 @@ func init() {
 	flag.BoolVar(&version, "version", false, "Show version")
 }
 
+// appOptions разбирает политики пересечений событий и доступ к занятости из конфигурации.
+func appOptions(conf cfg.EventsConf) (app.Options, error) {
+	var opts app.Options
+	var err error
+	if opts.Overlap, err = storage.ParseOverlapPolicy(conf.OverlapPolicy); err != nil {
+		return app.Options{}, err
+	}
+	opts.UserOverlap = make(map[string]storage.OverlapPolicy, len(conf.UserOverlapPolicies))
+	for userID, v := range conf.UserOverlapPolicies {
+		if opts.UserOverlap[userID], err = storage.ParseOverlapPolicy(v); err != nil {
+			return app.Options{}, fmt.Errorf("user %s: %w", userID, err)
+		}
+	}
+	opts.FreeBusyShares = conf.FreeBusyShares
+	return opts, nil
+}
+
 func main() {
 	flag.Parse()
 
@@ -66,15 +85,44 @@ func main() {
 		os.Exit(1)
 	}
 
-	calendar := app.New(logg, stor)
+	opts, err := appOptions(conf.Events)
+	if err != nil {
+		logg.Error(fmt.Sprintf("Invalid events config: %v", err))
+		os.Exit(1)
+	}
+	calendar := app.New(logg, stor, opts)
 
 	server := internalhttp.NewServer(logg, calendar, conf.Server.Host, conf.Server.Port)
 
+	grpcServer, err := grpcserver.New(logg, calendar, conf.Server.GRPCHost, conf.Server.GRPCPort)
+	if err != nil {
+		logg.Error("failed to create grpc server: " + err.Error())
+		os.Exit(1)
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(),
 		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	// do not defer cancel here to avoid gocritic exitAfterDefer warning when using os.Exit
 
+	gateway, err := grpcServer.Gateway(ctx)
+	if err != nil {
+		logg.Error("failed to create grpc gateway: " + err.Error())
+		cancel()
+		os.Exit(1)
+	}
+	server.Mount("/api/v1/", gateway)
+
+	// Ошибка любого из серверов останавливает оба.
 	go func() {
+		if err := grpcServer.Start(); err != nil {
+			logg.Error("failed to start grpc server: " + err.Error())
+			cancel()
+		}
+	}()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
 		<-ctx.Done()
 
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
@@ -83,6 +131,9 @@ func main() {
 		if err := server.Stop(ctx); err != nil {
 			logg.Error("failed to stop http server: " + err.Error())
 		}
+		if err := grpcServer.Stop(ctx); err != nil {
+			logg.Error("failed to stop grpc server: " + err.Error())
+		}
 
 		if sqlStor, ok := stor.(*sqlstorage.Storage); ok {
 			if err := sqlStor.Close(ctx); err != nil {
@@ -96,6 +147,8 @@ func main() {
 	if err := server.Start(ctx); err != nil {
 		logg.Error("failed to start http server: " + err.Error())
 		cancel()
+		<-stopped
 		os.Exit(1)
 	}
+	<-stopped
 }
@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // база часовых поясов на случай образа без системной
 
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/config"
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/logger"
@@ -16,6 +17,9 @@ import (
 	sqlstorage "github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage/sql"
 )
 
+// defaultRelayInterval используется, если relayInterval не задан в конфиге.
+const defaultRelayInterval = 5 * time.Second
+
 var (
 	configFile string
 	version    bool
@@ -54,14 +58,27 @@ func main() {
 	defer stor.Close(context.Background())
 
 	// Подключение к RabbitMQ
-	rmq, err := rabbitmq.NewClient(conf.RabbitMQ.URL, conf.RabbitMQ.Queue)
+	rmq, err := rabbitmq.NewClient(conf.RabbitMQ.URL, rabbitmq.Queues{
+		Name:        conf.RabbitMQ.Queue,
+		DeadLetter:  conf.RabbitMQ.DeadLetterQueue,
+		RetryDelay:  conf.RabbitMQ.RetryDelay,
+		MaxAttempts: conf.RabbitMQ.MaxAttempts,
+	})
 	if err != nil {
 		logg.Error(fmt.Sprintf("Failed to connect to RabbitMQ: %v", err))
 		return
 	}
 	defer rmq.Close()
 
-	sched := scheduler.New(stor, rmq, logg)
+	opts := scheduler.Options{
+		InstanceID:     conf.Schedule.InstanceID,
+		ClaimLease:     conf.Schedule.ClaimLease,
+		TrashRetention: conf.Schedule.TrashRetention,
+	}
+	if conf.Schedule.LeaderElection {
+		opts.Leader = stor
+	}
+	sched := scheduler.New(stor, rmq, logg, opts)
 
 	logg.Info("scheduler is running...")
 
@@ -71,6 +88,13 @@ func main() {
 	tickerCleanup := time.NewTicker(conf.Schedule.CleanupInterval)
 	defer tickerCleanup.Stop()
 
+	relayInterval := conf.Schedule.RelayInterval
+	if relayInterval <= 0 {
+		relayInterval = defaultRelayInterval
+	}
+	tickerRelay := time.NewTicker(relayInterval)
+	defer tickerRelay.Stop()
+
 	stopCh := make(chan os.Signal, 1)
 	signal.Notify(stopCh, syscall.SIGINT, syscall.SIGTERM)
 
@@ -78,6 +102,8 @@ func main() {
 		select {
 		case <-tickerScan.C:
 			sched.ProcessNotifications(context.Background())
+		case <-tickerRelay.C:
+			sched.RelayOutbox(context.Background())
 		case <-tickerCleanup.C:
 			sched.ProcessCleanup(context.Background())
 		case <-stopCh:
@@ -2,16 +2,19 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+	_ "time/tzdata" // база часовых поясов на случай образа без системной
 
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/config"
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/logger"
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/sender"
 )
 
 var (
@@ -40,40 +43,107 @@ func main() {
 
 	logg := logger.New(conf.Logger.Level)
 
-	rmq, err := rabbitmq.NewClient(conf.RabbitMQ.URL, conf.RabbitMQ.Queue)
+	rmq, err := rabbitmq.NewClient(conf.RabbitMQ.URL, rabbitmq.Queues{
+		Name:        conf.RabbitMQ.Queue,
+		DeadLetter:  conf.RabbitMQ.DeadLetterQueue,
+		RetryDelay:  conf.RabbitMQ.RetryDelay,
+		MaxAttempts: conf.RabbitMQ.MaxAttempts,
+	})
 	if err != nil {
 		logg.Error(fmt.Sprintf("Failed to connect to RabbitMQ: %v", err))
 		return
 	}
 	defer rmq.Close()
 
-	msgs, err := rmq.Consume()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	notifier, closeNotifier, err := newNotifier(conf.Sender, logg)
 	if err != nil {
-		logg.Error(fmt.Sprintf("Failed to start consuming: %v", err))
+		logg.Error(fmt.Sprintf("Failed to configure notification channels: %v", err))
 		return
 	}
+	defer closeNotifier()
 
-	logg.Info("sender is running...")
+	snd := sender.New(rmq, notifier, logg)
 
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
+	logg.Info("sender is running...")
+	if err := snd.Run(ctx); err != nil {
+		logg.Error(err.Error())
+		return
+	}
+	logg.Info("sender is stopping...")
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			logg.Info("sender is stopping...")
-			return
-		case msg, ok := <-msgs:
-			if !ok {
-				logg.Info("message channel closed")
-				return
-			}
-			var n rabbitmq.Notification
-			if err := json.Unmarshal(msg.Body, &n); err != nil {
-				logg.Error(fmt.Sprintf("failed to unmarshal notification: %v", err))
-				continue
-			}
-			logg.Infof("Notification: EventID=%s, Title=%s, UserID=%s, StartTime=%v", n.EventID, n.Title, n.UserID, n.StartTime)
+// newNotifier собирает каналы доставки из конфигурации. Канал email включается,
+// если задан SMTP-сервер; остальные каналы доступны всегда.
+func newNotifier(conf config.SenderConf, logg *logger.Logger) (*sender.Router, func(), error) {
+	recipients := make(sender.Recipients, len(conf.Users))
+	for id, u := range conf.Users {
+		location, err := loadLocation(u.TimeZone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("user %s: %w", id, err)
 		}
+		recipients[id] = sender.Recipient{
+			Channel:    u.Channel,
+			Email:      u.Email,
+			WebhookURL: u.WebhookURL,
+			Locale:     u.Locale,
+			Location:   location,
+		}
+	}
+
+	var templates fs.FS
+	if conf.TemplatesDir != "" {
+		templates = os.DirFS(conf.TemplatesDir)
+	}
+	location, err := loadLocation(conf.TimeZone)
+	if err != nil {
+		return nil, nil, err
+	}
+	renderer, err := sender.NewRenderer(templates, conf.Locale, location)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	defaultChannel := conf.DefaultChannel
+	if defaultChannel == "" {
+		defaultChannel = sender.ChannelLog
+	}
+	router := sender.NewRouter(defaultChannel, recipients)
+	router.Handle(sender.ChannelLog, sender.NewLogNotifier(logg, recipients, renderer))
+	router.Handle(sender.ChannelWebhook, sender.NewWebhookNotifier(sender.WebhookConfig{
+		URL:     conf.Webhook.URL,
+		Secret:  conf.Webhook.Secret,
+		Timeout: conf.Webhook.Timeout,
+	}, recipients, renderer))
+	if conf.SMTP.Host != "" {
+		router.Handle(sender.ChannelEmail, sender.NewEmailNotifier(sender.SMTPConfig{
+			Host:     conf.SMTP.Host,
+			Port:     conf.SMTP.Port,
+			Username: conf.SMTP.Username,
+			Password: conf.SMTP.Password,
+			From:     conf.SMTP.From,
+		}, recipients, renderer))
+	}
+
+	file, err := sender.NewFileNotifier(conf.File.Path, recipients, renderer)
+	if err != nil {
+		return nil, nil, err
+	}
+	router.Handle(sender.ChannelFile, file)
+
+	return router, func() { _ = file.Close() }, nil
+}
+
+// loadLocation загружает часовой пояс IANA; пустое имя означает пояс по умолчанию (nil).
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
 	}
+	return location, nil
 }
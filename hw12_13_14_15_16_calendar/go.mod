@@ -3,8 +3,10 @@ module github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar
 go 1.23
 
 require (
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.20.0
 	github.com/jmoiron/sqlx v1.4.0
 	github.com/lib/pq v1.10.9
+	google.golang.org/genproto/googleapis/api v0.0.0-20240528184218-531527333157
 	google.golang.org/grpc v1.65.0
 	google.golang.org/protobuf v1.35.1
 	gopkg.in/yaml.v3 v3.0.1
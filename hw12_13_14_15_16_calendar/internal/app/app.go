@@ -2,6 +2,10 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
@@ -10,6 +14,18 @@ import (
 type App struct {
 	logger  Logger
 	storage Storage
+	feed    *changeFeed
+	opts    Options
+}
+
+// Options - настройки правил работы с событиями.
+type Options struct {
+	// Overlap - политика пересечений событий пользователя; пустая - storage.OverlapReject.
+	Overlap storage.OverlapPolicy
+	// UserOverlap - политики пересечений по ID пользователя, переопределяющие Overlap.
+	UserOverlap map[string]storage.OverlapPolicy
+	// FreeBusyShares - кому пользователь открыл свою занятость: ID владельца -> ID пользователей.
+	FreeBusyShares map[string][]string
 }
 
 type Logger interface {
@@ -24,53 +40,504 @@ type Logger interface {
 }
 
 type Storage interface {
-	CreateEvent(ctx context.Context, event storage.Event) error
-	UpdateEvent(ctx context.Context, id string, event storage.Event) error
-	DeleteEvent(ctx context.Context, id string) error
-	GetEventByID(ctx context.Context, id string) (*storage.Event, error)
-	ListEventsForDay(ctx context.Context, date time.Time) ([]storage.Event, error)
-	ListEventsForWeek(ctx context.Context, startDate time.Time) ([]storage.Event, error)
-	ListEventsForMonth(ctx context.Context, startDate time.Time) ([]storage.Event, error)
+	CreateEvent(ctx context.Context, event storage.Event) (storage.Event, error)
+	UpdateEvent(ctx context.Context, userID, id string, event storage.Event) error
+	DeleteEvent(ctx context.Context, userID, id string, version int64) error
+	ListTrash(ctx context.Context, userID string) ([]storage.Event, error)
+	RestoreEvent(ctx context.Context, userID, id string) error
+	GetEventByID(ctx context.Context, userID, id string) (*storage.Event, error)
+	RespondToInvitation(ctx context.Context, userID, id string, status storage.RSVPStatus) error
+	SharesEvent(ctx context.Context, userID, otherID string) (bool, error)
+	ListEventsForDay(ctx context.Context, userID string, date time.Time) ([]storage.Event, error)
+	ListEventsForWeek(ctx context.Context, userID string, startDate time.Time) ([]storage.Event, error)
+	ListEventsForMonth(ctx context.Context, userID string, startDate time.Time) ([]storage.Event, error)
+	ListEventsBetween(ctx context.Context, userID string, start, end time.Time) ([]storage.Event, error)
+	ListEvents(ctx context.Context, filter storage.EventFilter) (storage.EventPage, error)
+	SearchEvents(ctx context.Context, query storage.SearchQuery) (storage.SearchPage, error)
+	AddAuditEntry(ctx context.Context, entry storage.AuditEntry) error
+	ListAuditEntries(ctx context.Context, id string) ([]storage.AuditEntry, error)
+	GetUserSettings(ctx context.Context, userID string) (storage.UserSettings, error)
+	SaveUserSettings(ctx context.Context, settings storage.UserSettings) error
+	Atomic(ctx context.Context, fn func(tx storage.Storage) error) error
 }
 
-func New(logger Logger, storage Storage) *App {
+func New(logger Logger, storage Storage, opts Options) *App {
 	return &App{
 		logger:  logger,
 		storage: storage,
+		feed:    newChangeFeed(DefaultWatchBuffer),
+		opts:    opts,
+	}
+}
+
+// CreateEvent создает событие от имени пользователя userID.
+// Создать событие для другого пользователя нельзя. Возвращает созданное событие и,
+// при политике storage.OverlapWarn, ID событий, с которыми оно пересекается.
+func (a *App) CreateEvent(
+	ctx context.Context, userID string, event storage.Event,
+) (storage.Event, []string, error) {
+	a.logger.Debugf("Creating event %s for user %s", event.ID, userID)
+	if event.UserID != "" && event.UserID != userID {
+		return storage.Event{}, nil, storage.ErrForbidden
+	}
+	event.UserID = userID
+	var created storage.Event
+	var conflicts []string
+	err := a.storage.Atomic(ctx, func(tx storage.Storage) error {
+		var err error
+		if conflicts, err = a.checkOverlap(ctx, tx, event); err != nil {
+			return err
+		}
+		if created, err = tx.CreateEvent(ctx, event); err != nil {
+			return err
+		}
+		return a.audit(ctx, tx, userID, storage.AuditCreated, nil, &created)
+	})
+	if err != nil {
+		return storage.Event{}, nil, err
+	}
+	a.feed.publish(Change{Type: ChangeCreated, Event: created, At: time.Now()})
+	return created, conflicts, nil
+}
+
+// UpdateEvent заменяет событие id; изменять можно только свои события. При политике
+// storage.OverlapWarn возвращает ID событий, с которыми пересекается новая версия.
+func (a *App) UpdateEvent(ctx context.Context, userID, id string, event storage.Event) ([]string, error) {
+	a.logger.Debugf("Updating event %s for user %s", id, userID)
+	if event.UserID != "" && event.UserID != userID {
+		return nil, storage.ErrForbidden
+	}
+	event.ID, event.UserID = id, userID
+	var previous, updated *storage.Event
+	var conflicts []string
+	err := a.storage.Atomic(ctx, func(tx storage.Storage) error {
+		var err error
+		if previous, err = a.lockedEvent(ctx, tx, userID, id); err != nil {
+			return err
+		}
+		if previous.UserID != userID {
+			return storage.ErrForbidden
+		}
+		if conflicts, err = a.checkOverlap(ctx, tx, event); err != nil {
+			return err
+		}
+		if err := tx.UpdateEvent(ctx, userID, id, event); err != nil {
+			return err
+		}
+		if updated, err = tx.GetEventByID(ctx, userID, id); err != nil {
+			return err
+		}
+		return a.audit(ctx, tx, userID, storage.AuditUpdated, previous, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.feed.publish(Change{Type: ChangeUpdated, Event: *updated, At: time.Now(), previous: previous})
+	return conflicts, nil
+}
+
+// overlapPolicy возвращает политику пересечений событий пользователя userID.
+func (a *App) overlapPolicy(userID string) storage.OverlapPolicy {
+	if policy, ok := a.opts.UserOverlap[userID]; ok && policy != "" {
+		return policy
+	}
+	if a.opts.Overlap == "" {
+		return storage.OverlapReject
+	}
+	return a.opts.Overlap
+}
+
+// checkOverlap применяет политику пересечений владельца события: при storage.OverlapReject
+// пересечение - ошибка storage.ErrDateBusy, при storage.OverlapWarn возвращаются ID
+// пересекающихся событий, при storage.OverlapAllow пересечения не ищутся.
+// Вызывается внутри Atomic перед записью события: события владельца блокируются,
+// поэтому параллельная запись не может занять проверенное время.
+func (a *App) checkOverlap(ctx context.Context, tx storage.Storage, event storage.Event) ([]string, error) {
+	policy := a.overlapPolicy(event.UserID)
+	if policy == storage.OverlapAllow {
+		return nil, nil
+	}
+	if err := tx.LockEvents(ctx, event.UserID); err != nil {
+		return nil, err
+	}
+	conflicts, err := tx.FindConflicts(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 && policy == storage.OverlapReject {
+		return nil, fmt.Errorf("%w: overlaps %s", storage.ErrDateBusy, strings.Join(conflicts, ", "))
+	}
+	return conflicts, nil
+}
+
+// DeleteEvent переносит событие в корзину, если его текущая версия равна version (0 - без проверки).
+func (a *App) DeleteEvent(ctx context.Context, userID, id string, version int64) error {
+	a.logger.Debugf("Deleting event %s version %d for user %s", id, version, userID)
+	var deleted *storage.Event
+	err := a.storage.Atomic(ctx, func(tx storage.Storage) error {
+		var err error
+		if deleted, err = a.lockedEvent(ctx, tx, userID, id); err != nil {
+			return err
+		}
+		if err := tx.DeleteEvent(ctx, userID, id, version); err != nil {
+			return err
+		}
+		return a.audit(ctx, tx, userID, storage.AuditDeleted, deleted, nil)
+	})
+	if err != nil {
+		return err
+	}
+	a.feed.publish(Change{Type: ChangeDeleted, Event: *deleted, At: time.Now()})
+	return nil
+}
+
+// lockedEvent блокирует события владельца события id (см. storage.Storage.LockEvents) и читает
+// событие через tx заново: журнал и лента получают то состояние, которое заменяет изменение.
+func (a *App) lockedEvent(ctx context.Context, tx storage.Storage, userID, id string) (*storage.Event, error) {
+	event, err := tx.GetEventByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.LockEvents(ctx, event.UserID); err != nil {
+		return nil, err
+	}
+	return tx.GetEventByID(ctx, userID, id)
+}
+
+// trashed возвращает событие id из корзины пользователя userID или storage.ErrEventNotFound.
+func (a *App) trashed(ctx context.Context, st Storage, userID, id string) (storage.Event, error) {
+	trash, err := st.ListTrash(ctx, userID)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	for _, e := range trash {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return storage.Event{}, storage.ErrEventNotFound
+}
+
+// audit записывает изменение события пользователем actor в журнал; before и after - как
+// в storage.DiffEvents. Вызывается внутри Atomic вместе с изменением: если запись журнала
+// не удалась, изменение отменяется.
+func (a *App) audit(
+	ctx context.Context, tx storage.Storage, actor string, action storage.AuditAction, before, after *storage.Event,
+) error {
+	entry := storage.AuditEntry{
+		Actor:   actor,
+		Action:  action,
+		At:      time.Now(),
+		Changes: storage.DiffEvents(before, after),
+	}
+	if after != nil {
+		entry.EventID = after.ID
+	} else {
+		entry.EventID = before.ID
+	}
+	if err := tx.AddAuditEntry(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record %s of event %s in audit log: %w", action, entry.EventID, err)
+	}
+	return nil
+}
+
+// EventHistory возвращает журнал изменений события id, начиная с самых ранних записей.
+// Журнал доступен тем, кто видит событие, а удаленного события - его владельцу.
+func (a *App) EventHistory(ctx context.Context, userID, id string) ([]storage.AuditEntry, error) {
+	a.logger.Debugf("Getting history of event %s for user %s", id, userID)
+	_, err := a.storage.GetEventByID(ctx, userID, id)
+	if errors.Is(err, storage.ErrEventNotFound) {
+		_, err = a.trashed(ctx, a.storage, userID, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.storage.ListAuditEntries(ctx, id)
+}
+
+// ListTrash возвращает удаленные события пользователя userID, недавно удаленные - первыми.
+func (a *App) ListTrash(ctx context.Context, userID string) ([]storage.Event, error) {
+	a.logger.Debugf("Listing trash for user %s", userID)
+	return a.storage.ListTrash(ctx, userID)
+}
+
+// RestoreEvent возвращает событие id из корзины пользователя userID. Восстановленное событие
+// проходит ту же проверку пересечений, что и новое: при storage.OverlapReject событие,
+// время которого уже занято, остается в корзине. Возвращает событие и, при политике
+// storage.OverlapWarn, ID событий, с которыми оно пересекается.
+func (a *App) RestoreEvent(ctx context.Context, userID, id string) (storage.Event, []string, error) {
+	a.logger.Debugf("Restoring event %s for user %s", id, userID)
+	var restored *storage.Event
+	var conflicts []string
+	err := a.storage.Atomic(ctx, func(tx storage.Storage) error {
+		if err := tx.LockEvents(ctx, userID); err != nil {
+			return err
+		}
+		trashed, err := a.trashed(ctx, tx, userID, id)
+		if err != nil {
+			return err
+		}
+		if conflicts, err = a.checkOverlap(ctx, tx, trashed); err != nil {
+			return err
+		}
+		if err := tx.RestoreEvent(ctx, userID, id); err != nil {
+			return err
+		}
+		if restored, err = tx.GetEventByID(ctx, userID, id); err != nil {
+			return err
+		}
+		return a.audit(ctx, tx, userID, storage.AuditRestored, nil, restored)
+	})
+	if err != nil {
+		return storage.Event{}, nil, err
+	}
+	a.feed.publish(Change{Type: ChangeCreated, Event: *restored, At: time.Now()})
+	return *restored, conflicts, nil
+}
+
+// InviteAttendees приглашает на событие id новых участников; уже приглашенным меняется только роль.
+// Приглашать может только владелец события. Время события не меняется, поэтому пересечения
+// не проверяются: пригласить можно и на событие, которое уже пересекается с другими.
+// Возвращает событие в сохраненном виде.
+func (a *App) InviteAttendees(
+	ctx context.Context, userID, id string, attendees []storage.Attendee,
+) (storage.Event, error) {
+	a.logger.Debugf("Inviting %d attendees to event %s by user %s", len(attendees), id, userID)
+	var previous, updated *storage.Event
+	err := a.storage.Atomic(ctx, func(tx storage.Storage) error {
+		var err error
+		if previous, err = a.lockedEvent(ctx, tx, userID, id); err != nil {
+			return err
+		}
+		if previous.UserID != userID {
+			return storage.ErrForbidden
+		}
+		event := *previous
+		event.Attendees = append([]storage.Attendee(nil), previous.Attendees...)
+		for _, invited := range attendees {
+			found := false
+			for i := range event.Attendees {
+				if event.Attendees[i].UserID == invited.UserID {
+					event.Attendees[i].Role, found = invited.Role, true
+				}
+			}
+			if !found {
+				event.Attendees = append(event.Attendees, storage.Attendee{UserID: invited.UserID, Role: invited.Role})
+			}
+		}
+		if err := tx.UpdateEvent(ctx, userID, id, event); err != nil {
+			return err
+		}
+		if updated, err = tx.GetEventByID(ctx, userID, id); err != nil {
+			return err
+		}
+		return a.audit(ctx, tx, userID, storage.AuditUpdated, previous, updated)
+	})
+	if err != nil {
+		return storage.Event{}, err
+	}
+	a.feed.publish(Change{Type: ChangeUpdated, Event: *updated, At: time.Now(), previous: previous})
+	return *updated, nil
+}
+
+// RespondToInvitation сохраняет ответ участника userID на приглашение на событие id
+// и возвращает событие с обновленным ответом.
+func (a *App) RespondToInvitation(
+	ctx context.Context, userID, id string, status storage.RSVPStatus,
+) (storage.Event, error) {
+	a.logger.Debugf("User %s responded %q to event %s", userID, status, id)
+	var previous, updated *storage.Event
+	err := a.storage.Atomic(ctx, func(tx storage.Storage) error {
+		var err error
+		if previous, err = a.lockedEvent(ctx, tx, userID, id); err != nil {
+			return err
+		}
+		if err := tx.RespondToInvitation(ctx, userID, id, status); err != nil {
+			return err
+		}
+		if updated, err = tx.GetEventByID(ctx, userID, id); err != nil {
+			return err
+		}
+		return a.audit(ctx, tx, userID, storage.AuditUpdated, previous, updated)
+	})
+	if err != nil {
+		return storage.Event{}, err
+	}
+	a.feed.publish(Change{Type: ChangeUpdated, Event: *updated, At: time.Now(), previous: previous})
+	return *updated, nil
+}
+
+// WatchEvents подписывает пользователя userID на изменения его событий.
+// Подписку нужно закрыть вызовом Close, когда она больше не нужна.
+func (a *App) WatchEvents(_ context.Context, userID string, filter WatchFilter) (*Subscription, error) {
+	a.logger.Debugf("User %s subscribed to event changes", userID)
+	if filter.UserID != "" && filter.UserID != userID {
+		return nil, storage.ErrForbidden
+	}
+	filter.UserID = userID
+	return a.feed.subscribe(filter), nil
+}
+
+func (a *App) GetEventByID(ctx context.Context, userID, id string) (*storage.Event, error) {
+	a.logger.Debugf("Getting event %s for user %s", id, userID)
+	return a.storage.GetEventByID(ctx, userID, id)
+}
+
+func (a *App) ListEventsForDay(ctx context.Context, userID string, date time.Time) ([]storage.Event, error) {
+	a.logger.Debugf("Listing events of user %s for day: %s", userID, date.Format("2006-01-02"))
+	return a.storage.ListEventsForDay(ctx, userID, date)
+}
+
+func (a *App) ListEventsForWeek(ctx context.Context, userID string, startDate time.Time) ([]storage.Event, error) {
+	a.logger.Debugf("Listing events of user %s for week starting: %s", userID, startDate.Format("2006-01-02"))
+	return a.storage.ListEventsForWeek(ctx, userID, startDate)
+}
+
+func (a *App) ListEventsForMonth(ctx context.Context, userID string, startDate time.Time) ([]storage.Event, error) {
+	a.logger.Debugf("Listing events of user %s for month starting: %s", userID, startDate.Format("2006-01-02"))
+	return a.storage.ListEventsForMonth(ctx, userID, startDate)
+}
+
+func (a *App) ListEventsBetween(ctx context.Context, userID string, start, end time.Time) ([]storage.Event, error) {
+	a.logger.Debugf("Listing events of user %s between %s and %s",
+		userID, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	return a.storage.ListEventsBetween(ctx, userID, start, end)
+}
+
+// ListEvents возвращает страницу событий пользователя userID по фильтру.
+// Просматривать события другого пользователя нельзя.
+func (a *App) ListEvents(ctx context.Context, userID string, filter storage.EventFilter) (storage.EventPage, error) {
+	a.logger.Debugf("Listing events of user %s: query %q, limit %d", userID, filter.Query, filter.Limit)
+	if filter.UserID != "" && filter.UserID != userID {
+		return storage.EventPage{}, storage.ErrForbidden
 	}
+	filter.UserID = userID
+	return a.storage.ListEvents(ctx, filter)
 }
 
-func (a *App) CreateEvent(ctx context.Context, event storage.Event) error {
-	a.logger.Debugf("Creating event: %s", event.ID)
-	return a.storage.CreateEvent(ctx, event)
+// SearchEvents выполняет полнотекстовый поиск по событиям пользователя userID.
+func (a *App) SearchEvents(ctx context.Context, userID string, query storage.SearchQuery) (storage.SearchPage, error) {
+	a.logger.Debugf("Searching events of user %s: %q", userID, query.Query)
+	query.UserID = userID
+	return a.storage.SearchEvents(ctx, query)
 }
 
-func (a *App) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
-	a.logger.Debugf("Updating event: %s", id)
-	return a.storage.UpdateEvent(ctx, id, event)
+// MaxFreeBusyUsers ограничивает число пользователей в одном запросе занятости.
+const MaxFreeBusyUsers = 50
+
+// FreeBusy возвращает занятость пользователей userIDs в интервале [from, to): объединенные интервалы
+// их событий и принятых приглашений без названий и описаний. Пустой userIDs - сам пользователь userID.
+// Чужую занятость видят только участники общих событий и те, кому ее открыли (Options.FreeBusyShares),
+// остальным возвращается storage.ErrForbidden.
+func (a *App) FreeBusy(
+	ctx context.Context, userID string, userIDs []string, from, to time.Time,
+) (map[string][]storage.Interval, error) {
+	a.logger.Debugf("User %s requested free/busy of %d users between %s and %s",
+		userID, len(userIDs), from.Format(time.RFC3339), to.Format(time.RFC3339))
+	if err := storage.ValidateRange(from, to); err != nil {
+		return nil, err
+	}
+	if len(userIDs) == 0 {
+		userIDs = []string{userID}
+	}
+	if len(userIDs) > MaxFreeBusyUsers {
+		return nil, fmt.Errorf("%w: at most %d users", storage.ErrInvalidRange, MaxFreeBusyUsers)
+	}
+	busy := make(map[string][]storage.Interval, len(userIDs))
+	for _, id := range userIDs {
+		if id == "" {
+			return nil, fmt.Errorf("%w: empty user id", storage.ErrInvalidRange)
+		}
+		if _, ok := busy[id]; ok {
+			continue
+		}
+		if err := a.checkFreeBusyAccess(ctx, userID, id); err != nil {
+			return nil, err
+		}
+		events, err := a.storage.ListEventsBetween(ctx, id, from, to)
+		if err != nil {
+			return nil, err
+		}
+		busy[id] = storage.BusyIntervals(events, from, to)
+	}
+	return busy, nil
 }
 
-func (a *App) DeleteEvent(ctx context.Context, id string) error {
-	a.logger.Debugf("Deleting event: %s", id)
-	return a.storage.DeleteEvent(ctx, id)
+// checkFreeBusyAccess проверяет, может ли пользователь userID видеть занятость пользователя id.
+func (a *App) checkFreeBusyAccess(ctx context.Context, userID, id string) error {
+	if id == userID || slices.Contains(a.opts.FreeBusyShares[id], userID) {
+		return nil
+	}
+	shares, err := a.storage.SharesEvent(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !shares {
+		return fmt.Errorf("%w: free/busy of %s", storage.ErrForbidden, id)
+	}
+	return nil
+}
+
+// FindSlots предлагает до query.Count самых ранних слотов, в которые свободны все пользователи
+// userIDs (пустой - сам userID), в рабочие часы query в часовом поясе query.Location.
+// Доступ к занятости пользователей проверяется так же, как в FreeBusy.
+func (a *App) FindSlots(
+	ctx context.Context, userID string, userIDs []string, query storage.SlotQuery,
+) ([]storage.Interval, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	busy, err := a.FreeBusy(ctx, userID, userIDs, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	var all []storage.Interval
+	for _, intervals := range busy {
+		all = append(all, intervals...)
+	}
+	return storage.FreeSlots(storage.MergeIntervals(all), query), nil
 }
 
-func (a *App) GetEventByID(ctx context.Context, id string) (*storage.Event, error) {
-	a.logger.Debugf("Getting event: %s", id)
-	return a.storage.GetEventByID(ctx, id)
+// Location возвращает часовой пояс, в котором считаются дни, недели и месяцы для пользователя userID:
+// явно переданный tz, иначе пояс из настроек пользователя, иначе UTC.
+func (a *App) Location(ctx context.Context, userID, tz string) (*time.Location, error) {
+	if tz == "" {
+		settings, err := a.storage.GetUserSettings(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		tz = settings.TimeZone
+	}
+	return storage.LoadLocation(tz)
 }
 
-func (a *App) ListEventsForDay(ctx context.Context, date time.Time) ([]storage.Event, error) {
-	a.logger.Debugf("Listing events for day: %s", date.Format("2006-01-02"))
-	return a.storage.ListEventsForDay(ctx, date)
+// WeekStart возвращает первый день недели для пользователя userID: явно переданный weekStart,
+// иначе из настроек пользователя (см. storage.UserSettings.FirstDayOfWeek).
+func (a *App) WeekStart(ctx context.Context, userID, weekStart string) (time.Weekday, error) {
+	if weekStart != "" {
+		return storage.ParseWeekday(weekStart)
+	}
+	settings, err := a.storage.GetUserSettings(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return settings.FirstDayOfWeek(), nil
 }
 
-func (a *App) ListEventsForWeek(ctx context.Context, startDate time.Time) ([]storage.Event, error) {
-	a.logger.Debugf("Listing events for week starting: %s", startDate.Format("2006-01-02"))
-	return a.storage.ListEventsForWeek(ctx, startDate)
+func (a *App) GetSettings(ctx context.Context, userID string) (storage.UserSettings, error) {
+	a.logger.Debugf("Getting settings of user %s", userID)
+	return a.storage.GetUserSettings(ctx, userID)
 }
 
-func (a *App) ListEventsForMonth(ctx context.Context, startDate time.Time) ([]storage.Event, error) {
-	a.logger.Debugf("Listing events for month starting: %s", startDate.Format("2006-01-02"))
-	return a.storage.ListEventsForMonth(ctx, startDate)
+// UpdateSettings сохраняет настройки пользователя userID и возвращает их в сохраненном виде.
+func (a *App) UpdateSettings(
+	ctx context.Context, userID string, settings storage.UserSettings,
+) (storage.UserSettings, error) {
+	a.logger.Debugf("Updating settings of user %s: time zone %q, locale %q, week start %q",
+		userID, settings.TimeZone, settings.Locale, settings.WeekStart)
+	settings.UserID = userID
+	if err := a.storage.SaveUserSettings(ctx, settings); err != nil {
+		return storage.UserSettings{}, err
+	}
+	return settings, nil
 }
@@ -2,26 +2,49 @@ package app
 
 import (
 	"context"
+	"errors"
+	"maps"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage/memory"
 )
 
+// mockStorage реализует методы хранилища, которые вызывает приложение;
+// остальные методы storage.Storage в тестах не используются.
 type mockStorage struct {
-	events map[string]storage.Event
-	err    error
+	storage.Storage
+	events   map[string]storage.Event
+	trash    map[string]storage.Event
+	settings map[string]storage.UserSettings
+	audit    []storage.AuditEntry
+	err      error
+	// auditErr - ошибка записи журнала.
+	auditErr error
 }
 
-func (m *mockStorage) CreateEvent(_ context.Context, event storage.Event) error {
+// Atomic отменяет изменения событий, если fn вернула ошибку, как транзакция.
+func (m *mockStorage) Atomic(_ context.Context, fn func(tx storage.Storage) error) error {
+	events, trash := maps.Clone(m.events), maps.Clone(m.trash)
+	if err := fn(m); err != nil {
+		m.events, m.trash = events, trash
+		return err
+	}
+	return nil
+}
+
+func (m *mockStorage) CreateEvent(_ context.Context, event storage.Event) (storage.Event, error) {
 	if m.err != nil {
-		return m.err
+		return storage.Event{}, m.err
 	}
 	m.events[event.ID] = event
-	return nil
+	return event, nil
 }
 
-func (m *mockStorage) UpdateEvent(_ context.Context, id string, event storage.Event) error {
+func (m *mockStorage) UpdateEvent(_ context.Context, _, id string, event storage.Event) error {
 	if m.err != nil {
 		return m.err
 	}
@@ -29,15 +52,57 @@ func (m *mockStorage) UpdateEvent(_ context.Context, id string, event storage.Ev
 	return nil
 }
 
-func (m *mockStorage) DeleteEvent(_ context.Context, id string) error {
+func (m *mockStorage) DeleteEvent(_ context.Context, _, id string, _ int64) error {
 	if m.err != nil {
 		return m.err
 	}
+	if m.trash == nil {
+		m.trash = make(map[string]storage.Event)
+	}
+	deletedAt := time.Now()
+	ev := m.events[id]
+	ev.DeletedAt = &deletedAt
+	m.trash[id] = ev
 	delete(m.events, id)
 	return nil
 }
 
-func (m *mockStorage) GetEventByID(_ context.Context, id string) (*storage.Event, error) {
+func (m *mockStorage) ListTrash(_ context.Context, userID string) ([]storage.Event, error) {
+	var list []storage.Event
+	for _, e := range m.trash {
+		if e.UserID == userID {
+			list = append(list, e)
+		}
+	}
+	return list, m.err
+}
+
+func (m *mockStorage) RestoreEvent(_ context.Context, _, id string) error {
+	ev, ok := m.trash[id]
+	if !ok {
+		return storage.ErrEventNotFound
+	}
+	ev.DeletedAt = nil
+	m.events[id] = ev
+	delete(m.trash, id)
+	return m.err
+}
+
+func (m *mockStorage) LockEvents(_ context.Context, _ string) error {
+	return m.err
+}
+
+func (m *mockStorage) FindConflicts(_ context.Context, event storage.Event) ([]string, error) {
+	var conflicts []string
+	for id, e := range m.events {
+		if id != event.ID && e.UserID == event.UserID && storage.Overlaps(event, e) {
+			conflicts = append(conflicts, id)
+		}
+	}
+	return conflicts, m.err
+}
+
+func (m *mockStorage) GetEventByID(_ context.Context, _, id string) (*storage.Event, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -48,23 +113,111 @@ func (m *mockStorage) GetEventByID(_ context.Context, id string) (*storage.Event
 	return &ev, nil
 }
 
-func (m *mockStorage) ListEventsForDay(_ context.Context, _ time.Time) ([]storage.Event, error) {
+func (m *mockStorage) RespondToInvitation(_ context.Context, userID, id string, status storage.RSVPStatus) error {
+	if m.err != nil {
+		return m.err
+	}
+	ev := m.events[id]
+	ev.Attendees = append([]storage.Attendee(nil), ev.Attendees...)
+	for i, a := range ev.Attendees {
+		if a.UserID == userID {
+			ev.Attendees[i].Status = status
+			m.events[id] = ev
+			return nil
+		}
+	}
+	return storage.ErrForbidden
+}
+
+func (m *mockStorage) SharesEvent(_ context.Context, userID, otherID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	for _, ev := range m.events {
+		if ev.VisibleTo(userID) && ev.VisibleTo(otherID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *mockStorage) ListEventsForDay(_ context.Context, _ string, _ time.Time) ([]storage.Event, error) {
 	return nil, m.err
 }
 
-func (m *mockStorage) ListEventsForWeek(_ context.Context, _ time.Time) ([]storage.Event, error) {
+func (m *mockStorage) ListEventsForWeek(_ context.Context, _ string, _ time.Time) ([]storage.Event, error) {
 	return nil, m.err
 }
 
-func (m *mockStorage) ListEventsForMonth(_ context.Context, _ time.Time) ([]storage.Event, error) {
+func (m *mockStorage) ListEventsForMonth(_ context.Context, _ string, _ time.Time) ([]storage.Event, error) {
 	return nil, m.err
 }
 
-func (m *mockStorage) GetEventsToNotify(_ context.Context) ([]storage.Event, error) {
+func (m *mockStorage) ListEventsBetween(_ context.Context, userID string, start, end time.Time) ([]storage.Event, error) {
+	var list []storage.Event
+	for _, e := range m.events {
+		if e.UserID == userID && e.StartTime.Before(end) && e.EndTime.After(start) {
+			list = append(list, e)
+		}
+	}
+	return list, m.err
+}
+
+func (m *mockStorage) ListEvents(_ context.Context, filter storage.EventFilter) (storage.EventPage, error) {
+	page := storage.EventPage{Events: []storage.Event{}}
+	for _, e := range m.events {
+		if e.UserID == filter.UserID {
+			page.Events = append(page.Events, e)
+		}
+	}
+	return page, m.err
+}
+
+func (m *mockStorage) SearchEvents(_ context.Context, _ storage.SearchQuery) (storage.SearchPage, error) {
+	return storage.SearchPage{}, m.err
+}
+
+func (m *mockStorage) AddAuditEntry(_ context.Context, entry storage.AuditEntry) error {
+	if m.auditErr != nil {
+		return m.auditErr
+	}
+	m.audit = append(m.audit, entry)
+	return m.err
+}
+
+func (m *mockStorage) ListAuditEntries(_ context.Context, id string) ([]storage.AuditEntry, error) {
+	var entries []storage.AuditEntry
+	for _, e := range m.audit {
+		if e.EventID == id {
+			entries = append(entries, e)
+		}
+	}
+	return entries, m.err
+}
+
+func (m *mockStorage) GetUserSettings(_ context.Context, userID string) (storage.UserSettings, error) {
+	if settings, ok := m.settings[userID]; ok {
+		return settings, m.err
+	}
+	return storage.UserSettings{UserID: userID}, m.err
+}
+
+func (m *mockStorage) SaveUserSettings(_ context.Context, settings storage.UserSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	if m.settings == nil {
+		m.settings = make(map[string]storage.UserSettings)
+	}
+	m.settings[settings.UserID] = settings
+	return m.err
+}
+
+func (m *mockStorage) GetEventsToNotify(_ context.Context) ([]storage.DueReminder, error) {
 	return nil, m.err
 }
 
-func (m *mockStorage) MarkEventNotified(_ context.Context, _ string) error {
+func (m *mockStorage) MarkEventNotified(_ context.Context, _, _ string, _ time.Time, _ ...storage.OutboxMessage) error {
 	return m.err
 }
 
@@ -86,13 +239,13 @@ func (m *mockLogger) Errorf(_ string, _ ...interface{}) {}
 func TestApp(t *testing.T) {
 	ms := &mockStorage{events: make(map[string]storage.Event)}
 	ml := &mockLogger{}
-	a := New(ml, ms)
+	a := New(ml, ms, Options{})
 
 	ctx := context.Background()
 	event := storage.Event{ID: "1", Title: "Test"}
 
 	t.Run("CreateEvent", func(t *testing.T) {
-		err := a.CreateEvent(ctx, event)
+		_, _, err := a.CreateEvent(ctx, "user1", event)
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
@@ -102,7 +255,7 @@ func TestApp(t *testing.T) {
 	})
 
 	t.Run("GetEventByID", func(t *testing.T) {
-		ev, err := a.GetEventByID(ctx, "1")
+		ev, err := a.GetEventByID(ctx, "user1", "1")
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
@@ -113,7 +266,7 @@ func TestApp(t *testing.T) {
 
 	t.Run("UpdateEvent", func(t *testing.T) {
 		event.Title = "Updated"
-		err := a.UpdateEvent(ctx, "1", event)
+		_, err := a.UpdateEvent(ctx, "user1", "1", event)
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
@@ -123,7 +276,7 @@ func TestApp(t *testing.T) {
 	})
 
 	t.Run("DeleteEvent", func(t *testing.T) {
-		err := a.DeleteEvent(ctx, "1")
+		err := a.DeleteEvent(ctx, "user1", "1", 0)
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
@@ -131,4 +284,556 @@ func TestApp(t *testing.T) {
 			t.Errorf("expected 0 events, got %d", len(ms.events))
 		}
 	})
+
+	t.Run("CreateEventForAnotherUser", func(t *testing.T) {
+		foreign := storage.Event{ID: "2", Title: "Foreign", UserID: "user2"}
+		_, _, err := a.CreateEvent(ctx, "user1", foreign)
+		if !errors.Is(err, storage.ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+	})
+
+	t.Run("ListEventsForAnotherUser", func(t *testing.T) {
+		_, err := a.ListEvents(ctx, "user1", storage.EventFilter{UserID: "user2"})
+		if !errors.Is(err, storage.ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+	})
+}
+
+func TestApp_Location(t *testing.T) {
+	ctx := context.Background()
+	a := New(&mockLogger{}, &mockStorage{events: make(map[string]storage.Event)}, Options{})
+
+	loc, err := a.Location(ctx, "user1", "")
+	if err != nil || loc != time.UTC {
+		t.Fatalf("expected UTC without settings, got %v, %v", loc, err)
+	}
+
+	if _, err := a.UpdateSettings(ctx, "user1", storage.UserSettings{TimeZone: "Mars/Olympus"}); !errors.Is(
+		err, storage.ErrInvalidTimeZone) {
+		t.Fatalf("expected ErrInvalidTimeZone, got %v", err)
+	}
+	settings, err := a.UpdateSettings(ctx, "user1", storage.UserSettings{UserID: "user2", TimeZone: "Europe/Moscow"})
+	if err != nil || settings.UserID != "user1" {
+		t.Fatalf("expected settings of user1 to be saved, got %+v, %v", settings, err)
+	}
+
+	if loc, _ := a.Location(ctx, "user1", ""); loc.String() != "Europe/Moscow" {
+		t.Errorf("expected time zone from settings, got %v", loc)
+	}
+	if loc, _ := a.Location(ctx, "user1", "America/New_York"); loc.String() != "America/New_York" {
+		t.Errorf("expected explicit time zone to win, got %v", loc)
+	}
+	if loc, _ := a.Location(ctx, "user2", ""); loc != time.UTC {
+		t.Errorf("expected UTC for another user, got %v", loc)
+	}
+	if _, err := a.Location(ctx, "user1", "Mars/Olympus"); !errors.Is(err, storage.ErrInvalidTimeZone) {
+		t.Errorf("expected ErrInvalidTimeZone, got %v", err)
+	}
+}
+
+func TestApp_WeekStart(t *testing.T) {
+	ctx := context.Background()
+	a := New(&mockLogger{}, &mockStorage{events: make(map[string]storage.Event)}, Options{})
+
+	if d, err := a.WeekStart(ctx, "user1", ""); err != nil || d != time.Monday {
+		t.Errorf("expected Monday by default, got %v, %v", d, err)
+	}
+	if _, err := a.UpdateSettings(ctx, "user1", storage.UserSettings{Locale: "en-US"}); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := a.WeekStart(ctx, "user1", ""); d != time.Sunday {
+		t.Errorf("expected Sunday for en-US, got %v", d)
+	}
+	if _, err := a.UpdateSettings(ctx, "user1", storage.UserSettings{Locale: "en-US", WeekStart: "monday"}); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := a.WeekStart(ctx, "user1", ""); d != time.Monday {
+		t.Errorf("expected explicit week start to override locale, got %v", d)
+	}
+	if d, _ := a.WeekStart(ctx, "user1", "sat"); d != time.Saturday {
+		t.Errorf("expected week start from the request, got %v", d)
+	}
+	if _, err := a.WeekStart(ctx, "user1", "someday"); !errors.Is(err, storage.ErrInvalidWeekStart) {
+		t.Errorf("expected ErrInvalidWeekStart, got %v", err)
+	}
+	if _, err := a.UpdateSettings(ctx, "user1", storage.UserSettings{WeekStart: "x"}); !errors.Is(
+		err, storage.ErrInvalidWeekStart) {
+		t.Errorf("expected ErrInvalidWeekStart, got %v", err)
+	}
+}
+
+func TestApp_WatchEvents(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+
+	t.Run("DeliversMatchingChanges", func(t *testing.T) {
+		a := New(&mockLogger{}, &mockStorage{events: make(map[string]storage.Event)}, Options{})
+		sub, err := a.WatchEvents(ctx, "user1", WatchFilter{From: day, To: day.Add(24 * time.Hour)})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		defer sub.Close()
+
+		inside := storage.Event{ID: "1", Title: "Inside", StartTime: day, EndTime: day.Add(time.Hour)}
+		outside := storage.Event{ID: "2", Title: "Outside", StartTime: day.AddDate(0, 0, 2), EndTime: day.AddDate(0, 0, 2)}
+		if _, _, err := a.CreateEvent(ctx, "user1", outside); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := a.CreateEvent(ctx, "user1", inside); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.DeleteEvent(ctx, "user1", "1", 0); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, want := range []ChangeType{ChangeCreated, ChangeDeleted} {
+			change := <-sub.C
+			if change.Type != want || change.Event.ID != "1" {
+				t.Errorf("expected %s of event 1, got %s of event %s", want, change.Type, change.Event.ID)
+			}
+		}
+	})
+
+	t.Run("UpdateOutOfWindow", func(t *testing.T) {
+		ms := &mockStorage{events: map[string]storage.Event{
+			"1": {ID: "1", UserID: "user1", StartTime: day, EndTime: day.Add(time.Hour)},
+		}}
+		a := New(&mockLogger{}, ms, Options{})
+		sub, _ := a.WatchEvents(ctx, "user1", WatchFilter{From: day, To: day.Add(24 * time.Hour)})
+		defer sub.Close()
+
+		moved := storage.Event{ID: "1", StartTime: day.AddDate(0, 1, 0), EndTime: day.AddDate(0, 1, 0)}
+		if _, err := a.UpdateEvent(ctx, "user1", "1", moved); err != nil {
+			t.Fatal(err)
+		}
+		change := <-sub.C
+		if change.Type != ChangeDeleted || !change.Event.StartTime.Equal(day) {
+			t.Errorf("expected deletion with the last visible start time, got %+v", change)
+		}
+	})
+
+	t.Run("AttendeeRemoved", func(t *testing.T) {
+		ms := &mockStorage{events: map[string]storage.Event{
+			"1": {
+				ID: "1", UserID: "owner", Title: "Sync", StartTime: day, EndTime: day.Add(time.Hour),
+				Attendees: []storage.Attendee{{UserID: "alice", Status: storage.StatusAccepted}},
+			},
+		}}
+		a := New(&mockLogger{}, ms, Options{})
+		sub, _ := a.WatchEvents(ctx, "alice", WatchFilter{})
+		defer sub.Close()
+
+		update := ms.events["1"]
+		update.Title = "Layoffs"
+		update.Attendees = nil
+		if _, err := a.UpdateEvent(ctx, "owner", "1", update); err != nil {
+			t.Fatal(err)
+		}
+		change := <-sub.C
+		if change.Type != ChangeDeleted || change.Event.Title != "Sync" {
+			t.Errorf("expected removed attendee to get deletion with the state they saw, got %+v", change)
+		}
+	})
+
+	t.Run("SlowConsumerIsDropped", func(t *testing.T) {
+		a := New(&mockLogger{}, &mockStorage{events: make(map[string]storage.Event)}, Options{})
+		sub, _ := a.WatchEvents(ctx, "user1", WatchFilter{})
+		for i := 0; i <= DefaultWatchBuffer; i++ {
+			if _, _, err := a.CreateEvent(ctx, "user1", storage.Event{ID: storage.NewID()}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		n := 0
+		for range sub.C {
+			n++
+		}
+		if n != DefaultWatchBuffer {
+			t.Errorf("expected %d buffered changes, got %d", DefaultWatchBuffer, n)
+		}
+		if !errors.Is(sub.Err(), ErrSlowConsumer) {
+			t.Errorf("expected ErrSlowConsumer, got %v", sub.Err())
+		}
+	})
+
+	t.Run("AnotherUser", func(t *testing.T) {
+		a := New(&mockLogger{}, &mockStorage{events: make(map[string]storage.Event)}, Options{})
+		if _, err := a.WatchEvents(ctx, "user1", WatchFilter{UserID: "user2"}); !errors.Is(err, storage.ErrForbidden) {
+			t.Errorf("expected ErrForbidden, got %v", err)
+		}
+	})
+}
+
+func TestApp_Attendees(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	ms := &mockStorage{events: map[string]storage.Event{
+		"1": {ID: "1", UserID: "owner", Title: "Sync", StartTime: day, EndTime: day.Add(time.Hour)},
+		// Событие уже пересекается с другим: это не мешает приглашать участников даже при reject.
+		"2": {ID: "2", UserID: "owner", Title: "Call", StartTime: day.Add(30 * time.Minute), EndTime: day.Add(2 * time.Hour)},
+	}}
+	a := New(&mockLogger{}, ms, Options{Overlap: storage.OverlapReject})
+
+	if _, err := a.InviteAttendees(ctx, "alice", "1", nil); !errors.Is(err, storage.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for a non-owner, got %v", err)
+	}
+	invite := []storage.Attendee{{UserID: "alice", Role: storage.RoleOptional}, {UserID: "bob"}}
+	event, err := a.InviteAttendees(ctx, "owner", "1", invite)
+	if err != nil {
+		t.Fatalf("InviteAttendees failed: %v", err)
+	}
+	if len(event.Attendees) != 2 || event.Attendees[0].Role != storage.RoleOptional {
+		t.Errorf("expected alice and bob to be invited, got %+v", event.Attendees)
+	}
+	if event, _ = a.InviteAttendees(ctx, "owner", "1", invite[:1]); len(event.Attendees) != 2 {
+		t.Errorf("repeated invitation must not duplicate attendees, got %+v", event.Attendees)
+	}
+
+	sub, _ := a.WatchEvents(ctx, "alice", WatchFilter{})
+	defer sub.Close()
+	event, err = a.RespondToInvitation(ctx, "alice", "1", storage.StatusAccepted)
+	if err != nil {
+		t.Fatalf("RespondToInvitation failed: %v", err)
+	}
+	if got, _ := event.Attendee("alice"); got.Status != storage.StatusAccepted {
+		t.Errorf("expected alice to accept, got %+v", got)
+	}
+	if change := <-sub.C; change.Type != ChangeUpdated || change.Event.ID != "1" {
+		t.Errorf("expected accepted event in alice's feed, got %+v", change)
+	}
+	if _, err := a.RespondToInvitation(ctx, "carol", "1", storage.StatusAccepted); !errors.Is(err, storage.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for a user without invitation, got %v", err)
+	}
+}
+
+func TestApp_FreeBusy(t *testing.T) {
+	ctx := context.Background()
+	// 2026-10-19 - понедельник.
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	ms := &mockStorage{events: map[string]storage.Event{
+		"1": {ID: "1", UserID: "alice", StartTime: day.Add(9 * time.Hour), EndTime: day.Add(10 * time.Hour)},
+		"2": {
+			ID: "2", UserID: "bob", StartTime: day.Add(10 * time.Hour), EndTime: day.Add(12 * time.Hour),
+			Attendees: []storage.Attendee{{UserID: "alice"}},
+		},
+		"3": {
+			ID: "3", UserID: "bob", StartTime: day, EndTime: day.AddDate(0, 0, 1), AllDay: true,
+			Transparency: storage.TransparencyFree,
+		},
+	}}
+	// Занятость bob видна alice как участнице его встречи, занятость carol - по ее разрешению.
+	a := New(&mockLogger{}, ms, Options{FreeBusyShares: map[string][]string{"carol": {"alice"}}})
+
+	busy, err := a.FreeBusy(ctx, "alice", []string{"alice", "bob", "carol"}, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("FreeBusy failed: %v", err)
+	}
+	if len(busy) != 3 || len(busy["alice"]) != 1 || len(busy["bob"]) != 1 || len(busy["carol"]) != 0 {
+		t.Errorf("unexpected free/busy: %+v", busy)
+	}
+	if busy, _ := a.FreeBusy(ctx, "alice", nil, day, day.AddDate(0, 0, 1)); len(busy) != 1 || busy["alice"] == nil {
+		t.Errorf("expected the caller's free/busy by default, got %+v", busy)
+	}
+	if _, err := a.FreeBusy(ctx, "alice", nil, day, day.AddDate(2, 0, 0)); !errors.Is(err, storage.ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange for a too long range, got %v", err)
+	}
+	if _, err := a.FreeBusy(ctx, "alice", []string{"dave"}, day, day.AddDate(0, 0, 1)); !errors.Is(err, storage.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for a stranger's free/busy, got %v", err)
+	}
+	if _, err := a.FreeBusy(ctx, "bob", []string{"carol"}, day, day.AddDate(0, 0, 1)); !errors.Is(err, storage.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for free/busy not shared with the caller, got %v", err)
+	}
+
+	slots, err := a.FindSlots(ctx, "alice", []string{"alice", "bob"}, storage.SlotQuery{
+		From: day, To: day.AddDate(0, 0, 1), Duration: time.Hour, Count: 3,
+		WorkStart: storage.DefaultWorkStart, WorkEnd: storage.DefaultWorkEnd,
+	})
+	if err != nil {
+		t.Fatalf("FindSlots failed: %v", err)
+	}
+	if len(slots) != 3 || !slots[0].Start.Equal(day.Add(12*time.Hour)) || !slots[2].End.Equal(day.Add(15*time.Hour)) {
+		t.Errorf("expected slots from 12:00 after both meetings, got %+v", slots)
+	}
+}
+
+// slowConflicts замедляет поиск пересечений, чтобы параллельные записи успели
+// пересечься между проверкой и сохранением, если они не атомарны.
+type slowConflicts struct {
+	storage.Storage
+}
+
+func (s slowConflicts) FindConflicts(ctx context.Context, event storage.Event) ([]string, error) {
+	conflicts, err := s.Storage.FindConflicts(ctx, event)
+	time.Sleep(time.Millisecond)
+	return conflicts, err
+}
+
+func (s slowConflicts) Atomic(ctx context.Context, fn func(tx storage.Storage) error) error {
+	return s.Storage.Atomic(ctx, func(tx storage.Storage) error { return fn(slowConflicts{tx}) })
+}
+
+func TestApp_ConcurrentOverlapReject(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	a := New(&mockLogger{}, slowConflicts{memorystorage.New()}, Options{Overlap: storage.OverlapReject})
+
+	// Все события занимают одно время: при политике reject сохраниться может только одно.
+	const writers = 20
+	var created atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			event := storage.Event{Title: "Meeting", StartTime: start, EndTime: start.Add(time.Hour)}
+			_, _, err := a.CreateEvent(ctx, "user1", event)
+			switch {
+			case err == nil:
+				created.Add(1)
+			case !errors.Is(err, storage.ErrDateBusy):
+				t.Errorf("expected ErrDateBusy, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := created.Load(); n != 1 {
+		t.Errorf("expected exactly one event to be created, got %d", n)
+	}
+}
+
+func TestApp_OverlapPolicy(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	meeting := storage.Event{ID: "1", Title: "Meeting", StartTime: start, EndTime: start.Add(time.Hour)}
+	overlapping := storage.Event{
+		ID: "2", Title: "Overlap", StartTime: start.Add(30 * time.Minute), EndTime: start.Add(2 * time.Hour),
+	}
+
+	newApp := func(opts Options) (*App, *mockStorage) {
+		ms := &mockStorage{events: make(map[string]storage.Event)}
+		a := New(&mockLogger{}, ms, opts)
+		if _, _, err := a.CreateEvent(ctx, "user1", meeting); err != nil {
+			t.Fatalf("CreateEvent failed: %v", err)
+		}
+		return a, ms
+	}
+
+	t.Run("Reject", func(t *testing.T) {
+		a, ms := newApp(Options{})
+		if _, _, err := a.CreateEvent(ctx, "user1", overlapping); !errors.Is(err, storage.ErrDateBusy) {
+			t.Errorf("expected ErrDateBusy, got %v", err)
+		}
+		if len(ms.events) != 1 {
+			t.Errorf("rejected event must not be saved, got %d events", len(ms.events))
+		}
+
+		free := overlapping
+		free.Transparency = storage.TransparencyFree
+		if _, _, err := a.CreateEvent(ctx, "user1", free); err != nil {
+			t.Errorf("free event must not be rejected, got %v", err)
+		}
+	})
+
+	t.Run("Warn", func(t *testing.T) {
+		a, _ := newApp(Options{Overlap: storage.OverlapAllow, UserOverlap: map[string]storage.OverlapPolicy{
+			"user1": storage.OverlapWarn,
+		}})
+		_, conflicts, err := a.CreateEvent(ctx, "user1", overlapping)
+		if err != nil || len(conflicts) != 1 || conflicts[0] != "1" {
+			t.Fatalf("expected event 2 to be saved with conflict 1, got %v, %v", conflicts, err)
+		}
+		moved := overlapping
+		moved.StartTime, moved.EndTime = start.Add(3*time.Hour), start.Add(4*time.Hour)
+		if conflicts, err := a.UpdateEvent(ctx, "user1", "2", moved); err != nil || len(conflicts) != 0 {
+			t.Errorf("expected no conflicts after moving, got %v, %v", conflicts, err)
+		}
+	})
+
+	t.Run("Allow", func(t *testing.T) {
+		a, _ := newApp(Options{Overlap: storage.OverlapAllow})
+		_, conflicts, err := a.CreateEvent(ctx, "user1", overlapping)
+		if err != nil || conflicts != nil {
+			t.Errorf("expected event to be saved without checks, got %v, %v", conflicts, err)
+		}
+	})
+}
+
+func TestApp_Trash(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	ms := &mockStorage{events: make(map[string]storage.Event)}
+	a := New(&mockLogger{}, ms, Options{})
+
+	meeting := storage.Event{ID: "1", Title: "Meeting", StartTime: start, EndTime: start.Add(time.Hour)}
+	if _, _, err := a.CreateEvent(ctx, "user1", meeting); err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	if err := a.DeleteEvent(ctx, "user1", "1", 0); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+	trash, err := a.ListTrash(ctx, "user1")
+	if err != nil || len(trash) != 1 || trash[0].DeletedAt == nil {
+		t.Fatalf("expected the deleted event in trash, got %+v, %v", trash, err)
+	}
+	if _, _, err := a.RestoreEvent(ctx, "user2", "1"); !errors.Is(err, storage.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound for another user's trash, got %v", err)
+	}
+
+	// Пока событие в корзине, его время свободно; восстановить его поверх новой встречи нельзя.
+	replacement := meeting
+	replacement.ID = "2"
+	if _, _, err := a.CreateEvent(ctx, "user1", replacement); err != nil {
+		t.Fatalf("expected the slot of a deleted event to be free, got %v", err)
+	}
+	if _, _, err := a.RestoreEvent(ctx, "user1", "1"); !errors.Is(err, storage.ErrDateBusy) {
+		t.Errorf("expected ErrDateBusy on restore, got %v", err)
+	}
+
+	if err := a.DeleteEvent(ctx, "user1", "2", 0); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+	restored, _, err := a.RestoreEvent(ctx, "user1", "1")
+	if err != nil || restored.ID != "1" || restored.DeletedAt != nil {
+		t.Errorf("expected event 1 restored, got %+v, %v", restored, err)
+	}
+	if trash, _ := a.ListTrash(ctx, "user1"); len(trash) != 1 || trash[0].ID != "2" {
+		t.Errorf("expected only event 2 left in trash, got %+v", trash)
+	}
+}
+
+func TestApp_EventHistory(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	ms := &mockStorage{events: make(map[string]storage.Event)}
+	a := New(&mockLogger{}, ms, Options{})
+
+	meeting := storage.Event{
+		ID: "1", Title: "Meeting", StartTime: start, EndTime: start.Add(time.Hour),
+		Attendees: []storage.Attendee{{UserID: "user2", Role: storage.RoleRequired, Status: storage.StatusNeedsAction}},
+	}
+	if _, _, err := a.CreateEvent(ctx, "user1", meeting); err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	renamed := meeting
+	renamed.Title = "Planning"
+	if _, err := a.UpdateEvent(ctx, "user1", "1", renamed); err != nil {
+		t.Fatalf("UpdateEvent failed: %v", err)
+	}
+	if _, err := a.RespondToInvitation(ctx, "user2", "1", storage.StatusAccepted); err != nil {
+		t.Fatalf("RespondToInvitation failed: %v", err)
+	}
+	if err := a.DeleteEvent(ctx, "user1", "1", 0); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+
+	history, err := a.EventHistory(ctx, "user1", "1")
+	if err != nil {
+		t.Fatalf("EventHistory failed: %v", err)
+	}
+	want := []struct {
+		actor  string
+		action storage.AuditAction
+	}{
+		{"user1", storage.AuditCreated},
+		{"user1", storage.AuditUpdated},
+		{"user2", storage.AuditUpdated},
+		{"user1", storage.AuditDeleted},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), history)
+	}
+	for i, w := range want {
+		if history[i].Actor != w.actor || history[i].Action != w.action || history[i].At.IsZero() {
+			t.Errorf("entry %d: expected %s by %s, got %+v", i, w.action, w.actor, history[i])
+		}
+	}
+	if c := history[1].Changes; len(c) != 1 || c[0] != (storage.FieldChange{Field: "title", Old: "Meeting", New: "Planning"}) {
+		t.Errorf("expected only the title change, got %+v", c)
+	}
+	if c := history[2].Changes; len(c) != 1 || c[0].Field != "attendees" {
+		t.Errorf("expected the attendee response as a change, got %+v", c)
+	}
+
+	if _, err := a.EventHistory(ctx, "user2", "1"); !errors.Is(err, storage.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound for a trashed event of another user, got %v", err)
+	}
+}
+
+// interleavedWrite перед каждым Atomic выполняет write, как параллельная запись,
+// успевшая между чтением события без блокировки и началом транзакции.
+type interleavedWrite struct {
+	*mockStorage
+	write func()
+}
+
+func (s interleavedWrite) Atomic(ctx context.Context, fn func(tx storage.Storage) error) error {
+	s.write()
+	return s.mockStorage.Atomic(ctx, fn)
+}
+
+func TestApp_AuditReadsStateInsideAtomic(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	ms := &mockStorage{events: map[string]storage.Event{
+		"1": {ID: "1", UserID: "user1", Title: "Meeting", StartTime: start, EndTime: start.Add(time.Hour)},
+	}}
+	a := New(&mockLogger{}, interleavedWrite{ms, func() {
+		e := ms.events["1"]
+		e.Title = "Planning"
+		ms.events["1"] = e
+	}}, Options{Overlap: storage.OverlapAllow})
+	sub, _ := a.WatchEvents(ctx, "user1", WatchFilter{})
+	defer sub.Close()
+
+	update := ms.events["1"]
+	update.Title = "Retro"
+	if _, err := a.UpdateEvent(ctx, "user1", "1", update); err != nil {
+		t.Fatalf("UpdateEvent failed: %v", err)
+	}
+	want := storage.FieldChange{Field: "title", Old: "Planning", New: "Retro"}
+	if len(ms.audit) != 1 || len(ms.audit[0].Changes) != 1 || ms.audit[0].Changes[0] != want {
+		t.Errorf("expected audit diff from the replaced state %v, got %+v", want, ms.audit)
+	}
+	if change := <-sub.C; change.previous == nil || change.previous.Title != "Planning" {
+		t.Errorf("expected the replaced state as previous, got %+v", change.previous)
+	}
+
+	if err := a.DeleteEvent(ctx, "user1", "1", 0); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+	if change := <-sub.C; change.Type != ChangeDeleted || change.Event.Title != "Planning" {
+		t.Errorf("expected the deleted state in the feed, got %+v", change)
+	}
+}
+
+func TestApp_AuditFailureRollsBack(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	auditErr := errors.New("audit log is unavailable")
+	ms := &mockStorage{events: make(map[string]storage.Event), auditErr: auditErr}
+	a := New(&mockLogger{}, ms, Options{})
+
+	meeting := storage.Event{ID: "1", Title: "Meeting", StartTime: start, EndTime: start.Add(time.Hour)}
+	if _, _, err := a.CreateEvent(ctx, "user1", meeting); !errors.Is(err, auditErr) {
+		t.Fatalf("expected audit error, got %v", err)
+	}
+	if len(ms.events) != 0 {
+		t.Errorf("expected event creation to be rolled back, got %+v", ms.events)
+	}
+
+	ms.auditErr = nil
+	if _, _, err := a.CreateEvent(ctx, "user1", meeting); err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	ms.auditErr = auditErr
+	renamed := meeting
+	renamed.Title = "Planning"
+	if _, err := a.UpdateEvent(ctx, "user1", "1", renamed); !errors.Is(err, auditErr) {
+		t.Fatalf("expected audit error, got %v", err)
+	}
+	if ms.events["1"].Title != "Meeting" {
+		t.Errorf("expected update to be rolled back, got %+v", ms.events["1"])
+	}
 }
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// DefaultWatchBuffer - сколько изменений может накопить подписчик, прежде чем будет отключен.
+const DefaultWatchBuffer = 64
+
+// ErrSlowConsumer - подписчик не успевал забирать изменения и был отключен.
+var ErrSlowConsumer = errors.New("subscriber is too slow")
+
+// ChangeType - вид изменения события.
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// Change - запись ленты изменений. Для удаленного события Event содержит его последнее состояние.
+// Если событие ушло из окна подписки (его перенесли, участника убрали из приглашенных),
+// подписчик получает ChangeDeleted с последним состоянием, которое он видел, а не новое.
+type Change struct {
+	Type  ChangeType
+	Event storage.Event
+	At    time.Time
+
+	// previous - состояние до изменения: подписчик узнает и о событии, ушедшем из его окна.
+	previous *storage.Event
+}
+
+// WatchFilter - условия подписки. Нулевые From/To означают отсутствие соответствующей границы.
+type WatchFilter struct {
+	UserID string
+	From   time.Time
+	To     time.Time
+}
+
+// Matches сообщает, попадает ли событие (хотя бы одно его вхождение) в окно фильтра.
+// Как и в списках, кроме своих событий учитываются принятые приглашения.
+func (f WatchFilter) Matches(e storage.Event) bool {
+	if !e.ListedFor(f.UserID) {
+		return false
+	}
+	if f.From.IsZero() && f.To.IsZero() {
+		return true
+	}
+	if !e.IsRecurring() {
+		return (f.From.IsZero() || e.EndTime.After(f.From)) && (f.To.IsZero() || e.StartTime.Before(f.To))
+	}
+	from, to := f.From, f.To
+	if from.IsZero() {
+		from = e.StartTime
+	}
+	if to.IsZero() {
+		to = from.Add(storage.ConflictHorizon)
+	}
+	return len(e.OccurrencesOverlapping(from, to)) > 0
+}
+
+// Subscription - подписка на ленту изменений.
+// Канал C закрывается при Close или при отключении медленного подписчика (см. Err).
+type Subscription struct {
+	C <-chan Change
+
+	ch     chan Change
+	filter WatchFilter
+	feed   *changeFeed
+	err    error
+}
+
+// Err возвращает ErrSlowConsumer, если подписка была закрыта из-за переполнения буфера.
+// Значение определено после закрытия канала C.
+func (s *Subscription) Err() error {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	return s.err
+}
+
+// Close отменяет подписку. Повторный вызов безопасен.
+func (s *Subscription) Close() {
+	s.feed.remove(s, nil)
+}
+
+// changeFeed рассылает изменения подписчикам без блокировки публикующей стороны.
+type changeFeed struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	buffer int
+}
+
+func newChangeFeed(buffer int) *changeFeed {
+	return &changeFeed{subs: make(map[*Subscription]struct{}), buffer: buffer}
+}
+
+func (f *changeFeed) subscribe(filter WatchFilter) *Subscription {
+	ch := make(chan Change, f.buffer)
+	sub := &Subscription{C: ch, ch: ch, filter: filter, feed: f}
+
+	f.mu.Lock()
+	f.subs[sub] = struct{}{}
+	f.mu.Unlock()
+	return sub
+}
+
+// publish отправляет изменение подходящим подписчикам; переполненные подписки закрываются.
+func (f *changeFeed) publish(change Change) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for sub := range f.subs {
+		delivered := change
+		if !sub.filter.Matches(change.Event) {
+			if change.previous == nil || !sub.filter.Matches(*change.previous) {
+				continue
+			}
+			delivered = Change{Type: ChangeDeleted, Event: *change.previous, At: change.At}
+		}
+		select {
+		case sub.ch <- delivered:
+		default:
+			f.removeLocked(sub, ErrSlowConsumer)
+		}
+	}
+}
+
+func (f *changeFeed) remove(sub *Subscription, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.removeLocked(sub, err)
+}
+
+func (f *changeFeed) removeLocked(sub *Subscription, err error) {
+	if _, ok := f.subs[sub]; !ok {
+		return
+	}
+	delete(f.subs, sub)
+	sub.err = err
+	close(sub.ch)
+}
@@ -15,6 +15,8 @@ type Config struct {
 	Database DatabaseConf `yaml:"database"`
 	RabbitMQ RabbitMQConf `yaml:"rabbitmq"`
 	Schedule ScheduleConf `yaml:"schedule"`
+	Sender   SenderConf   `yaml:"sender"`
+	Events   EventsConf   `yaml:"events"`
 }
 
 type LoggerConf struct {
@@ -32,6 +34,18 @@ type StorageConf struct {
 	Type string `yaml:"type"` // "memory" or "sql"
 }
 
+// EventsConf - правила работы с событиями.
+type EventsConf struct {
+	// OverlapPolicy - что делать с событием, пересекающимся с занятым временем владельца:
+	// reject (отклонить, по умолчанию), warn (сохранить и вернуть ID пересечений) или allow.
+	OverlapPolicy string `yaml:"overlapPolicy"`
+	// UserOverlapPolicies - политики пересечений по ID пользователя, переопределяющие OverlapPolicy.
+	UserOverlapPolicies map[string]string `yaml:"userOverlapPolicies"`
+	// FreeBusyShares - кому пользователь открыл свою занятость: ID владельца -> ID пользователей.
+	// Участники общих событий видят занятость друг друга и без этого.
+	FreeBusyShares map[string][]string `yaml:"freeBusyShares"`
+}
+
 type DatabaseConf struct {
 	DSN string `yaml:"dsn"`
 }
@@ -39,11 +53,75 @@ type DatabaseConf struct {
 type RabbitMQConf struct {
 	URL   string `yaml:"url"`
 	Queue string `yaml:"queue"`
+	// DeadLetterQueue - очередь уведомлений, которые не удалось доставить; пустая - queue + ".dead".
+	DeadLetterQueue string `yaml:"deadLetterQueue"`
+	// RetryDelay - пауза перед повторной доставкой уведомления.
+	RetryDelay time.Duration `yaml:"retryDelay"`
+	// MaxAttempts - число попыток доставки, после которого уведомление уходит в DeadLetterQueue.
+	MaxAttempts int `yaml:"maxAttempts"`
 }
 
 type ScheduleConf struct {
 	ScanInterval    time.Duration `yaml:"scanInterval"`
 	CleanupInterval time.Duration `yaml:"cleanupInterval"`
+	// RelayInterval - период отправки накопленных в outbox уведомлений в RabbitMQ.
+	RelayInterval time.Duration `yaml:"relayInterval"`
+	// InstanceID различает реплики планировщика при захвате напоминаний и outbox; пустой - hostname-pid.
+	InstanceID string `yaml:"instanceID"`
+	// ClaimLease - на сколько реплика захватывает напоминания и сообщения outbox, которые отправляет.
+	ClaimLease time.Duration `yaml:"claimLease"`
+	// LeaderElection включает выбор одной реплики для очистки через advisory-блокировку.
+	LeaderElection bool `yaml:"leaderElection"`
+	// TrashRetention - сколько удаленные события хранятся в корзине; пустой - 30 дней.
+	TrashRetention time.Duration `yaml:"trashRetention"`
+}
+
+// SenderConf - каналы доставки уведомлений calendar_sender.
+type SenderConf struct {
+	// DefaultChannel - канал для напоминаний без канала и пользователей без своего канала:
+	// log, email, webhook или file. Пустой - log.
+	DefaultChannel string `yaml:"defaultChannel"`
+	// TemplatesDir - каталог шаблонов <locale>/<channel>.<subject|txt|html>.tmpl;
+	// пустой - встроенные шаблоны.
+	TemplatesDir string `yaml:"templatesDir"`
+	// Locale и TimeZone (IANA, например Europe/Moscow) - язык сообщений и пояс времени
+	// события для пользователей без своих настроек.
+	Locale   string      `yaml:"locale"`
+	TimeZone string      `yaml:"timeZone"`
+	SMTP     SMTPConf    `yaml:"smtp"`
+	Webhook  WebhookConf `yaml:"webhook"`
+	File     FileConf    `yaml:"file"`
+	// Users - настройки доставки по ID пользователя.
+	Users map[string]RecipientConf `yaml:"users"`
+}
+
+// SMTPConf - SMTP-сервер канала email; канал включен, если задан host.
+type SMTPConf struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// WebhookConf - исходящие вебхуки. URL используется для пользователей без своего webhookURL.
+type WebhookConf struct {
+	URL     string        `yaml:"url"`
+	Secret  string        `yaml:"secret"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// FileConf - файл канала file; пустой путь или "-" - stdout.
+type FileConf struct {
+	Path string `yaml:"path"`
+}
+
+type RecipientConf struct {
+	Channel    string `yaml:"channel"`
+	Email      string `yaml:"email"`
+	WebhookURL string `yaml:"webhookURL"`
+	Locale     string `yaml:"locale"`
+	TimeZone   string `yaml:"timeZone"`
 }
 
 func NewConfig(configPath string) (*Config, error) {
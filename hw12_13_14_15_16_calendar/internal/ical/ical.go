@@ -0,0 +1,492 @@
+// Package ical реализует сериализацию событий календаря в формат iCalendar (RFC 5545)
+// в объеме VCALENDAR/VEVENT/VALARM, достаточном для обмена с Google Calendar и Outlook.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// ContentType - значение заголовка Content-Type для .ics.
+const ContentType = "text/calendar; charset=utf-8"
+
+const (
+	prodID        = "-//otus//calendar//RU"
+	utcLayout     = "20060102T150405Z"
+	localLayout   = "20060102T150405"
+	dateLayout    = "20060102"
+	maxLineOctets = 75
+)
+
+var ErrMalformed = errors.New("malformed iCalendar data")
+
+// Item - результат разбора одного VEVENT. Err заполняется, если VEVENT не удалось
+// преобразовать в событие; остальные VEVENT при этом продолжают разбираться.
+type Item struct {
+	UID   string
+	Event storage.Event
+	Err   error
+}
+
+// Encode записывает события в w как один VCALENDAR.
+func Encode(w io.Writer, events []storage.Event, now time.Time) error {
+	lw := &lineWriter{w: w}
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:" + prodID)
+	lw.line("CALSCALE:GREGORIAN")
+	for _, e := range events {
+		lw.line("BEGIN:VEVENT")
+		uid := e.UID
+		if uid == "" {
+			uid = e.ID
+		}
+		lw.line("UID:" + escapeText(uid))
+		lw.line("DTSTAMP:" + now.UTC().Format(utcLayout))
+		lw.line("DTSTART" + formatDateTime(e.StartTime, e))
+		lw.line("DTEND" + formatDateTime(e.EndTime, e))
+		lw.line("SUMMARY:" + escapeText(e.Title))
+		if e.Description != "" {
+			lw.line("DESCRIPTION:" + escapeText(e.Description))
+		}
+		if e.Transparency == storage.TransparencyFree {
+			lw.line("TRANSP:TRANSPARENT")
+		}
+		if e.RRule != nil {
+			lw.line("RRULE:" + e.RRule.String())
+		}
+		if len(e.ExDates) > 0 {
+			lw.line("EXDATE:" + storage.FormatExDates(e.ExDates))
+		}
+		for _, r := range e.Reminders {
+			lw.line("BEGIN:VALARM")
+			lw.line("ACTION:DISPLAY")
+			lw.line("DESCRIPTION:" + escapeText(e.Title))
+			lw.line("TRIGGER:" + formatTrigger(r.Offset))
+			lw.line("END:VALARM")
+		}
+		lw.line("END:VEVENT")
+	}
+	lw.line("END:VCALENDAR")
+	return lw.err
+}
+
+// Decode разбирает VCALENDAR и возвращает по одному Item на каждый VEVENT.
+// Ошибка возвращается только при нарушении структуры потока.
+func Decode(r io.Reader) ([]Item, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		items   []Item
+		current *veventBuilder
+		inAlarm bool
+		depth   int
+	)
+	for _, raw := range lines {
+		if raw == "" {
+			continue
+		}
+		p, err := parseProperty(raw)
+		if err != nil {
+			if current != nil && current.err == nil {
+				current.err = err
+			}
+			continue
+		}
+
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			current = &veventBuilder{}
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			if current == nil {
+				return nil, fmt.Errorf("%w: unexpected END:VEVENT", ErrMalformed)
+			}
+			items = append(items, current.build())
+			current = nil
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VALARM"):
+			inAlarm = true
+			if current != nil {
+				current.hasTrigger = false
+			}
+		case p.name == "END" && strings.EqualFold(p.value, "VALARM"):
+			inAlarm = false
+		case p.name == "BEGIN":
+			depth++
+		case p.name == "END":
+			depth--
+		case current != nil && inAlarm:
+			current.alarm(p)
+		case current != nil:
+			current.property(p)
+		}
+	}
+	if current != nil || depth != 0 {
+		return nil, fmt.Errorf("%w: unterminated component", ErrMalformed)
+	}
+	return items, nil
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func parseProperty(line string) (property, error) {
+	// Двоеточие внутри кавычек параметров не является разделителем.
+	inQuotes := false
+	sep := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		}
+		if c == ':' && !inQuotes {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 {
+		return property{}, fmt.Errorf("%w: %q", ErrMalformed, line)
+	}
+
+	head := strings.Split(line[:sep], ";")
+	p := property{
+		name:   strings.ToUpper(head[0]),
+		params: make(map[string]string, len(head)-1),
+		value:  line[sep+1:],
+	}
+	for _, param := range head[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, nil
+}
+
+type veventBuilder struct {
+	uid          string
+	summary      string
+	description  string
+	start, end   time.Time
+	timeZone     string // TZID из DTSTART
+	allDay       bool
+	transparent  bool // TRANSP:TRANSPARENT - событие не занимает время
+	hasStart     bool
+	hasEnd       bool
+	rrule        string
+	exDates      []time.Time
+	triggers     []time.Duration
+	triggersAbs  []time.Time
+	hasTrigger   bool // в текущем VALARM уже встретился TRIGGER
+	durationProp *time.Duration
+	err          error
+}
+
+func (b *veventBuilder) property(p property) {
+	if b.err != nil {
+		return
+	}
+	var err error
+	switch p.name {
+	case "UID":
+		b.uid = unescapeText(p.value)
+	case "SUMMARY":
+		b.summary = unescapeText(p.value)
+	case "DESCRIPTION":
+		b.description = unescapeText(p.value)
+	case "DTSTART":
+		b.start, b.allDay, err = parseDateTime(p)
+		b.hasStart = err == nil
+		b.timeZone = p.params["TZID"]
+	case "DTEND":
+		b.end, _, err = parseDateTime(p)
+		b.hasEnd = err == nil
+	case "DURATION":
+		var d time.Duration
+		d, err = parseDuration(p.value)
+		b.durationProp = &d
+	case "TRANSP":
+		b.transparent = strings.EqualFold(p.value, "TRANSPARENT")
+	case "RRULE":
+		b.rrule = p.value
+	case "EXDATE":
+		for _, v := range strings.Split(p.value, ",") {
+			var d time.Time
+			d, _, err = parseDateTime(property{name: p.name, params: p.params, value: v})
+			if err != nil {
+				break
+			}
+			b.exDates = append(b.exDates, d)
+		}
+	}
+	if err != nil {
+		b.err = fmt.Errorf("%s: %w", p.name, err)
+	}
+}
+
+func (b *veventBuilder) alarm(p property) {
+	if p.name != "TRIGGER" || b.err != nil || b.hasTrigger {
+		return
+	}
+	b.hasTrigger = true
+	if strings.EqualFold(p.params["VALUE"], "DATE-TIME") {
+		t, _, err := parseDateTime(p)
+		if err != nil {
+			b.err = fmt.Errorf("TRIGGER: %w", err)
+			return
+		}
+		b.triggersAbs = append(b.triggersAbs, t)
+		return
+	}
+	if strings.EqualFold(p.params["RELATED"], "END") {
+		// Напоминания относительно окончания события не поддерживаются.
+		return
+	}
+	d, err := parseDuration(p.value)
+	if err != nil {
+		b.err = fmt.Errorf("TRIGGER: %w", err)
+		return
+	}
+	b.triggers = append(b.triggers, d)
+}
+
+func (b *veventBuilder) build() Item {
+	item := Item{UID: b.uid}
+	if b.err != nil {
+		item.Err = fmt.Errorf("%w: %w", storage.ErrInvalidEvent, b.err)
+		return item
+	}
+	if b.uid == "" || !b.hasStart {
+		item.Err = fmt.Errorf("%w: UID and DTSTART are required", storage.ErrInvalidEvent)
+		return item
+	}
+
+	end := b.end
+	switch {
+	case b.hasEnd:
+	case b.durationProp != nil:
+		end = b.start.Add(*b.durationProp)
+	case b.allDay:
+		end = b.start.AddDate(0, 0, 1)
+	default:
+		end = b.start
+	}
+
+	e := storage.Event{
+		UID:         b.uid,
+		Title:       b.summary,
+		StartTime:   b.start,
+		EndTime:     end,
+		Description: b.description,
+		TimeZone:    b.timeZone,
+		AllDay:      b.allDay,
+		ExDates:     b.exDates,
+	}
+	if b.transparent {
+		e.Transparency = storage.TransparencyFree
+	}
+	if b.rrule != "" {
+		rule, err := storage.ParseRRule(b.rrule)
+		if err != nil {
+			item.Err = fmt.Errorf("%w: %w", storage.ErrInvalidEvent, err)
+			return item
+		}
+		e.RRule = rule
+	}
+	offsets := make([]time.Duration, 0, len(b.triggers)+len(b.triggersAbs))
+	for _, d := range b.triggers {
+		offsets = append(offsets, -d)
+	}
+	for _, t := range b.triggersAbs {
+		offsets = append(offsets, b.start.Sub(t))
+	}
+	for _, offset := range offsets {
+		// Напоминания после начала события не поддерживаются.
+		if offset >= 0 {
+			e.Reminders = append(e.Reminders, storage.Reminder{Offset: offset})
+		}
+	}
+	item.Event = e
+	return item
+}
+
+// formatDateTime возвращает параметры и значение DATE-TIME: для события на весь день - дату
+// без времени, для события с часовым поясом - местное время с TZID, иначе UTC.
+func formatDateTime(t time.Time, e storage.Event) string {
+	if e.AllDay {
+		return ";VALUE=DATE:" + t.In(e.Location()).Format(dateLayout)
+	}
+	if e.TimeZone == "" {
+		return ":" + t.UTC().Format(utcLayout)
+	}
+	return ";TZID=" + e.TimeZone + ":" + t.In(e.Location()).Format(localLayout)
+}
+
+func parseDateTime(p property) (time.Time, bool, error) {
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(p.value) == len(dateLayout) {
+		t, err := time.Parse(dateLayout, p.value)
+		return t, true, err
+	}
+	if strings.HasSuffix(p.value, "Z") {
+		t, err := time.Parse(utcLayout, p.value)
+		return t, false, err
+	}
+	loc := time.UTC
+	if tzid := p.params["TZID"]; tzid != "" {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		loc = l
+	}
+	t, err := time.ParseInLocation(localLayout, p.value, loc)
+	return t, false, err
+}
+
+// parseDuration разбирает значение DURATION/TRIGGER вида -P1DT2H30M или P1W.
+func parseDuration(s string) (time.Duration, error) {
+	orig := s
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign = -1
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("bad duration %q", orig)
+	}
+	s = s[1:]
+
+	var total time.Duration
+	inTime := false
+	num := 0
+	hasNum := false
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			num = num*10 + int(c-'0')
+			hasNum = true
+			continue
+		case c == 'T':
+			inTime = true
+			continue
+		}
+		if !hasNum {
+			return 0, fmt.Errorf("bad duration %q", orig)
+		}
+		n := time.Duration(num)
+		switch {
+		case c == 'W' && !inTime:
+			total += n * 7 * 24 * time.Hour
+		case c == 'D' && !inTime:
+			total += n * 24 * time.Hour
+		case c == 'H' && inTime:
+			total += n * time.Hour
+		case c == 'M' && inTime:
+			total += n * time.Minute
+		case c == 'S' && inTime:
+			total += n * time.Second
+		default:
+			return 0, fmt.Errorf("bad duration %q", orig)
+		}
+		num, hasNum = 0, false
+	}
+	if hasNum {
+		return 0, fmt.Errorf("bad duration %q", orig)
+	}
+	return sign * total, nil
+}
+
+// formatTrigger формирует относительный TRIGGER для напоминания за lead до начала.
+func formatTrigger(lead time.Duration) string {
+	prefix := "-P"
+	if lead < 0 {
+		prefix = "P"
+		lead = -lead
+	}
+	days := lead / (24 * time.Hour)
+	lead -= days * 24 * time.Hour
+	var b strings.Builder
+	b.WriteString(prefix)
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if lead > 0 || days == 0 {
+		b.WriteString("T")
+		h := lead / time.Hour
+		m := (lead % time.Hour) / time.Minute
+		sec := (lead % time.Minute) / time.Second
+		if h > 0 {
+			fmt.Fprintf(&b, "%dH", h)
+		}
+		if m > 0 {
+			fmt.Fprintf(&b, "%dM", m)
+		}
+		if sec > 0 || (h == 0 && m == 0) {
+			fmt.Fprintf(&b, "%dS", sec)
+		}
+	}
+	return b.String()
+}
+
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+func unescapeText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+// unfold читает строки содержимого, склеивая перенесенные (RFC 5545, 3.1).
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lines []string
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read iCalendar data: %w", err)
+	}
+	return lines, nil
+}
+
+// lineWriter пишет строки с CRLF и переносом длинных строк по 75 октетов.
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (lw *lineWriter) line(s string) {
+	if lw.err != nil {
+		return
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > maxLineOctets {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+	_, lw.err = io.WriteString(lw.w, b.String())
+}
@@ -0,0 +1,178 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	rule, _ := storage.ParseRRule("FREQ=WEEKLY;BYDAY=MO,WE")
+	events := []storage.Event{
+		{
+			ID:          "evt-1",
+			Title:       "Sync; budget, Q3",
+			StartTime:   start,
+			EndTime:     start.Add(time.Hour),
+			Description: "line1\nline2 " + strings.Repeat("long ", 30),
+			Reminders:   []storage.Reminder{{Offset: 24 * time.Hour}, {Offset: 15 * time.Minute}},
+			RRule:       rule,
+			ExDates:     []time.Time{start.AddDate(0, 0, 7)},
+		},
+		{
+			ID: "hold", Title: "Hold", StartTime: start, EndTime: start.Add(time.Hour),
+			Transparency: storage.TransparencyFree,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, events, start); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line is not folded: %q", line)
+		}
+	}
+
+	items, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	if items[0].Event.Transparency != "" || items[1].Event.Transparency != storage.TransparencyFree {
+		t.Errorf("expected only the hold to be transparent, got %+v", items)
+	}
+	got := items[0].Event
+	if items[0].Err != nil {
+		t.Fatalf("unexpected item error: %v", items[0].Err)
+	}
+	if got.UID != "evt-1" || got.Title != events[0].Title || got.Description != events[0].Description {
+		t.Errorf("text fields mismatch: %+v", got)
+	}
+	if !got.StartTime.Equal(start) || !got.EndTime.Equal(start.Add(time.Hour)) {
+		t.Errorf("time mismatch: %v - %v", got.StartTime, got.EndTime)
+	}
+	if len(got.Reminders) != 2 || got.Reminders[0].Offset != 24*time.Hour || got.Reminders[1].Offset != 15*time.Minute {
+		t.Errorf("expected reminders 24h and 15m before, got %+v", got.Reminders)
+	}
+	if got.RRule == nil || got.RRule.String() != rule.String() {
+		t.Errorf("expected rrule %s, got %v", rule, got.RRule)
+	}
+	if len(got.ExDates) != 1 || !got.ExDates[0].Equal(start.AddDate(0, 0, 7)) {
+		t.Errorf("unexpected exdates: %v", got.ExDates)
+	}
+}
+
+func TestEncodeDecode_TimeZone(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, loc)
+	events := []storage.Event{{
+		ID: "evt-ny", Title: "Standup", StartTime: start, EndTime: start.Add(15 * time.Minute),
+		TimeZone: "America/New_York",
+	}}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, events, start); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "DTSTART;TZID=America/New_York:20260310T090000\r\n") {
+		t.Errorf("expected local DTSTART with TZID, got:\n%s", buf.String())
+	}
+
+	items, err := Decode(&buf)
+	if err != nil || len(items) != 1 || items[0].Err != nil {
+		t.Fatalf("Decode failed: %v, %+v", err, items)
+	}
+	got := items[0].Event
+	if got.TimeZone != "America/New_York" || !got.StartTime.Equal(start) || !got.EndTime.Equal(events[0].EndTime) {
+		t.Errorf("expected start %v in America/New_York, got %v in %q", start, got.StartTime, got.TimeZone)
+	}
+}
+
+func TestEncode_AllDay(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Moscow")
+	start := time.Date(2026, 11, 4, 0, 0, 0, 0, loc)
+	events := []storage.Event{{
+		ID: "holiday", Title: "Holiday", StartTime: start, EndTime: start.AddDate(0, 0, 2),
+		TimeZone: "Europe/Moscow", AllDay: true,
+	}}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, events, start); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	for _, want := range []string{"DTSTART;VALUE=DATE:20261104\r\n", "DTEND;VALUE=DATE:20261106\r\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	data := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:all-day",
+		"SUMMARY:Holiday",
+		"DTSTART;VALUE=DATE:20261104",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:moscow",
+		"SUMMARY:Local",
+		"DTSTART;TZID=Europe/Moscow:20261005T100000",
+		"DURATION:PT1H30M",
+		"BEGIN:VALARM",
+		"TRIGGER:-P1D",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:broken",
+		"SUMMARY:No start",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	items, err := Decode(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(items))
+	}
+
+	allDay := items[0].Event
+	if !allDay.AllDay {
+		t.Error("expected all-day event")
+	}
+	if allDay.EndTime.Sub(allDay.StartTime) != 24*time.Hour {
+		t.Errorf("all-day event must last one day, got %v", allDay.EndTime.Sub(allDay.StartTime))
+	}
+
+	local := items[1].Event
+	if got := local.StartTime.UTC().Hour(); got != 7 {
+		t.Errorf("expected 07:00 UTC, got %d", got)
+	}
+	if local.EndTime.Sub(local.StartTime) != 90*time.Minute {
+		t.Errorf("unexpected duration %v", local.EndTime.Sub(local.StartTime))
+	}
+	if len(local.Reminders) != 1 || local.Reminders[0].Offset != 24*time.Hour {
+		t.Errorf("expected reminder one day before, got %+v", local.Reminders)
+	}
+
+	if !errors.Is(items[2].Err, storage.ErrInvalidEvent) {
+		t.Errorf("expected ErrInvalidEvent, got %v", items[2].Err)
+	}
+
+	if _, err := Decode(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\n")); !errors.Is(err, ErrMalformed) {
+		t.Errorf("expected ErrMalformed, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package rabbitmq
+
+import "github.com/streadway/amqp"
+
+// brokerConn - часть *amqp.Connection, нужная клиенту. В тестах подменяется брокером в памяти.
+type brokerConn interface {
+	Channel() (brokerChannel, error)
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	Close() error
+}
+
+// brokerChannel - часть *amqp.Channel, нужная клиенту.
+type brokerChannel interface {
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	Confirm(noWait bool) error
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Consume(
+		queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table,
+	) (<-chan amqp.Delivery, error)
+	Close() error
+}
+
+// dialFunc открывает соединение с брокером по url.
+type dialFunc func(url string) (brokerConn, error)
+
+// amqpConn приводит *amqp.Connection к brokerConn.
+type amqpConn struct {
+	*amqp.Connection
+}
+
+func (c amqpConn) Channel() (brokerChannel, error) {
+	return c.Connection.Channel()
+}
+
+func dialAMQP(url string) (brokerConn, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+	return amqpConn{conn}, nil
+}
@@ -0,0 +1,379 @@
+package rabbitmq
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// fakeBroker - брокер в памяти для тестов клиента. Поддерживает обменник по умолчанию,
+// одного получателя на очередь и возврат сообщений по x-message-ttl в x-dead-letter-routing-key.
+type fakeBroker struct {
+	mu        sync.Mutex
+	down      bool
+	noConfirm bool
+	dials     int
+	declares  int
+	conns     []*fakeConn
+	queues    map[string]*fakeQueue
+	published []fakeMessage
+	tag       uint64
+	unacked   map[uint64]fakeMessage
+	acked     int
+	nacked    int
+}
+
+type fakeQueue struct {
+	args     amqp.Table
+	messages []amqp.Publishing
+	consumer chan amqp.Delivery
+	ch       *fakeChannel
+}
+
+// fakeMessage - сообщение и очередь, в которую оно опубликовано.
+type fakeMessage struct {
+	queue string
+	msg   amqp.Publishing
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{queues: make(map[string]*fakeQueue), unacked: make(map[uint64]fakeMessage)}
+}
+
+func (b *fakeBroker) dial(string) (brokerConn, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.dials++
+	if b.down {
+		return nil, errors.New("connection refused")
+	}
+	conn := &fakeConn{broker: b}
+	b.conns = append(b.conns, conn)
+	return conn, nil
+}
+
+// setDown включает или выключает прием новых соединений.
+func (b *fakeBroker) setDown(down bool) {
+	b.mu.Lock()
+	b.down = down
+	b.mu.Unlock()
+}
+
+// drop разрывает все соединения, как при перезапуске брокера.
+func (b *fakeBroker) drop() {
+	b.mu.Lock()
+	conns := b.conns
+	b.conns = nil
+	b.mu.Unlock()
+
+	for _, c := range conns {
+		c.shutdown(&amqp.Error{Code: amqp.ConnectionForced, Reason: "CONNECTION_FORCED"})
+	}
+}
+
+func (b *fakeBroker) stats() (dials, declares, published int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dials, b.declares, len(b.published)
+}
+
+// messages возвращает сообщения, опубликованные в очередь queue.
+func (b *fakeBroker) messages(queue string) []amqp.Publishing {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var result []amqp.Publishing
+	for _, m := range b.published {
+		if m.queue == queue {
+			result = append(result, m.msg)
+		}
+	}
+	return result
+}
+
+func (b *fakeBroker) declare(name string, args amqp.Table) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.declares++
+	if q, ok := b.queues[name]; ok {
+		q.args = args
+		return
+	}
+	b.queues[name] = &fakeQueue{args: args}
+}
+
+func (b *fakeBroker) publish(queue string, msg amqp.Publishing) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.published = append(b.published, fakeMessage{queue: queue, msg: msg})
+	b.enqueueLocked(queue, msg)
+}
+
+func (b *fakeBroker) enqueueLocked(queue string, msg amqp.Publishing) {
+	q, ok := b.queues[queue]
+	if !ok {
+		return
+	}
+	if ttl, ok := q.args["x-message-ttl"].(int64); ok {
+		target, _ := q.args["x-dead-letter-routing-key"].(string)
+		time.AfterFunc(time.Duration(ttl)*time.Millisecond, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.enqueueLocked(target, msg)
+		})
+		return
+	}
+	if q.consumer != nil {
+		b.deliverLocked(queue, q, msg)
+		return
+	}
+	q.messages = append(q.messages, msg)
+}
+
+func (b *fakeBroker) deliverLocked(queue string, q *fakeQueue, msg amqp.Publishing) {
+	b.tag++
+	b.unacked[b.tag] = fakeMessage{queue: queue, msg: msg}
+	q.consumer <- amqp.Delivery{
+		Acknowledger: q.ch,
+		DeliveryTag:  b.tag,
+		ContentType:  msg.ContentType,
+		MessageId:    msg.MessageId,
+		Headers:      msg.Headers,
+		Body:         msg.Body,
+	}
+}
+
+func (b *fakeBroker) subscribe(queue string, ch *fakeChannel, deliveries chan amqp.Delivery) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q, ok := b.queues[queue]
+	if !ok {
+		q = &fakeQueue{}
+		b.queues[queue] = q
+	}
+	q.consumer, q.ch = deliveries, ch
+	for _, msg := range q.messages {
+		b.deliverLocked(queue, q, msg)
+	}
+	q.messages = nil
+}
+
+// unsubscribe снимает получателя и возвращает неподтвержденные доставки в очереди без получателей.
+func (b *fakeBroker) unsubscribe(deliveries chan amqp.Delivery) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, q := range b.queues {
+		if q.consumer == deliveries {
+			q.consumer, q.ch = nil, nil
+		}
+	}
+	close(deliveries)
+	for tag, m := range b.unacked {
+		if q := b.queues[m.queue]; q != nil && q.ch == nil {
+			delete(b.unacked, tag)
+			q.messages = append(q.messages, m.msg)
+		}
+	}
+}
+
+func (b *fakeBroker) settle(tag uint64, ack, requeue bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m, ok := b.unacked[tag]
+	if !ok {
+		return errors.New("unknown delivery tag")
+	}
+	delete(b.unacked, tag)
+	if ack {
+		b.acked++
+		return nil
+	}
+	b.nacked++
+	if requeue {
+		b.enqueueLocked(m.queue, m.msg)
+	}
+	return nil
+}
+
+func (b *fakeBroker) settled() (acked, nacked int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.acked, b.nacked
+}
+
+type fakeConn struct {
+	broker   *fakeBroker
+	mu       sync.Mutex
+	closed   bool
+	notify   []chan *amqp.Error
+	channels []*fakeChannel
+}
+
+func (c *fakeConn) Channel() (brokerChannel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, amqp.ErrClosed
+	}
+	ch := &fakeChannel{broker: c.broker}
+	c.channels = append(c.channels, ch)
+	return ch, nil
+}
+
+func (c *fakeConn) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notify = append(c.notify, receiver)
+	return receiver
+}
+
+func (c *fakeConn) Close() error {
+	c.shutdown(nil)
+	return nil
+}
+
+func (c *fakeConn) shutdown(reason *amqp.Error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.closed = true
+	for _, ch := range c.channels {
+		ch.shutdown(reason)
+	}
+	for _, n := range c.notify {
+		if reason != nil {
+			n <- reason
+		}
+		close(n)
+	}
+}
+
+type fakeChannel struct {
+	broker     *fakeBroker
+	mu         sync.Mutex
+	closed     bool
+	confirming bool
+	tag        uint64
+	confirms   []chan amqp.Confirmation
+	notify     []chan *amqp.Error
+	deliveries []chan amqp.Delivery
+}
+
+func (ch *fakeChannel) QueueDeclare(name string, _, _, _, _ bool, args amqp.Table) (amqp.Queue, error) {
+	ch.broker.declare(name, args)
+	return amqp.Queue{Name: name}, nil
+}
+
+func (ch *fakeChannel) Qos(int, int, bool) error {
+	return nil
+}
+
+func (ch *fakeChannel) Confirm(bool) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	ch.confirming = true
+	return nil
+}
+
+func (ch *fakeChannel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	ch.confirms = append(ch.confirms, confirm)
+	return confirm
+}
+
+func (ch *fakeChannel) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	ch.notify = append(ch.notify, receiver)
+	return receiver
+}
+
+func (ch *fakeChannel) Publish(_, key string, _, _ bool, msg amqp.Publishing) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if ch.closed {
+		return amqp.ErrClosed
+	}
+	ch.broker.publish(key, msg)
+	ch.tag++
+
+	ch.broker.mu.Lock()
+	noConfirm := ch.broker.noConfirm
+	ch.broker.mu.Unlock()
+	if ch.confirming && !noConfirm {
+		for _, c := range ch.confirms {
+			c <- amqp.Confirmation{DeliveryTag: ch.tag, Ack: true}
+		}
+	}
+	return nil
+}
+
+func (ch *fakeChannel) Consume(queue, _ string, _, _, _, _ bool, _ amqp.Table) (<-chan amqp.Delivery, error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if ch.closed {
+		return nil, amqp.ErrClosed
+	}
+	deliveries := make(chan amqp.Delivery, 100)
+	ch.deliveries = append(ch.deliveries, deliveries)
+	ch.broker.subscribe(queue, ch, deliveries)
+	return deliveries, nil
+}
+
+func (ch *fakeChannel) Ack(tag uint64, _ bool) error {
+	return ch.broker.settle(tag, true, false)
+}
+
+func (ch *fakeChannel) Nack(tag uint64, _, requeue bool) error {
+	return ch.broker.settle(tag, false, requeue)
+}
+
+func (ch *fakeChannel) Reject(tag uint64, requeue bool) error {
+	return ch.broker.settle(tag, false, requeue)
+}
+
+func (ch *fakeChannel) Close() error {
+	ch.shutdown(nil)
+	return nil
+}
+
+func (ch *fakeChannel) shutdown(reason *amqp.Error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if ch.closed {
+		return
+	}
+	ch.closed = true
+	for _, d := range ch.deliveries {
+		ch.broker.unsubscribe(d)
+	}
+	for _, c := range ch.confirms {
+		close(c)
+	}
+	for _, n := range ch.notify {
+		if reason != nil {
+			n <- reason
+		}
+		close(n)
+	}
+}
@@ -2,7 +2,9 @@ package rabbitmq
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/streadway/amqp"
@@ -13,16 +15,141 @@ type Notification struct {
 	Title     string    `json:"title"`
 	StartTime time.Time `json:"startTime"`
 	UserID    string    `json:"userId"`
+	// TimeZone - часовой пояс IANA события; пустой - UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+	// Channel - канал доставки из напоминания; пустой - канал по умолчанию.
+	Channel string `json:"channel,omitempty"`
+	// IdempotencyKey одинаков у повторных доставок одного напоминания: по нему получатель
+	// отбрасывает дубликаты. Дублируется в свойстве message-id сообщения.
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
 }
 
+const (
+	// confirmTimeout - сколько Publish ждет подтверждения брокера.
+	confirmTimeout = 5 * time.Second
+	// prefetch - сколько неподтвержденных доставок брокер держит на получателе.
+	prefetch = 16
+	// defaultRetryDelay и defaultMaxAttempts используются, если они не заданы в Queues.
+	defaultRetryDelay  = 30 * time.Second
+	defaultMaxAttempts = 5
+	// attemptsHeader - число неудачных попыток обработки сообщения,
+	// errorHeader - причина последней неудачи.
+	attemptsHeader = "x-attempts"
+	errorHeader    = "x-last-error"
+	// minReconnectDelay и maxReconnectDelay ограничивают экспоненциальную паузу между
+	// попытками переподключения.
+	minReconnectDelay = 500 * time.Millisecond
+	maxReconnectDelay = 30 * time.Second
+)
+
+var (
+	// ErrNotConfirmed - брокер отверг сообщение или не подтвердил его за confirmTimeout.
+	ErrNotConfirmed = errors.New("message is not confirmed by broker")
+	// ErrNotConnected - соединение с брокером потеряно и еще не восстановлено.
+	ErrNotConnected = errors.New("not connected to broker")
+)
+
+// Queues описывает очередь уведомлений и вспомогательные очереди, которые клиент объявляет
+// при каждом подключении.
+type Queues struct {
+	// Name - основная очередь уведомлений.
+	Name string
+	// DeadLetter - очередь сообщений, которые не удалось обработать; по умолчанию Name + ".dead".
+	DeadLetter string
+	// RetryDelay - через сколько сообщение из очереди повторов вернется в основную очередь.
+	RetryDelay time.Duration
+	// MaxAttempts - после стольких неудачных попыток сообщение уходит в DeadLetter.
+	MaxAttempts int
+}
+
+// withDefaults заполняет незаданные поля значениями по умолчанию.
+func (q Queues) withDefaults() Queues {
+	if q.DeadLetter == "" {
+		q.DeadLetter = q.Name + ".dead"
+	}
+	if q.RetryDelay <= 0 {
+		q.RetryDelay = defaultRetryDelay
+	}
+	if q.MaxAttempts <= 0 {
+		q.MaxAttempts = defaultMaxAttempts
+	}
+	return q
+}
+
+// retry - очередь отложенных повторов: сообщения лежат в ней RetryDelay, после чего брокер
+// перекладывает их обратно в основную очередь.
+func (q Queues) retry() string {
+	return q.Name + ".retry"
+}
+
+// session - соединение с брокером и настроенный на нем канал.
+type session struct {
+	conn       brokerConn
+	channel    brokerChannel
+	confirms   chan amqp.Confirmation
+	connClosed chan *amqp.Error
+	chanClosed chan *amqp.Error
+}
+
+func (s *session) close() {
+	s.channel.Close()
+	s.conn.Close()
+}
+
+// Client публикует и получает уведомления через очередь queues.Name. При разрыве соединения
+// клиент переподключается с экспоненциальной паузой, заново объявляет очереди и включает
+// подтверждения; подписка, полученная через Consume, переживает переподключение.
 type Client struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	queue   string
+	url            string
+	queues         Queues
+	dial           dialFunc
+	confirmTimeout time.Duration
+	minDelay       time.Duration
+	maxDelay       time.Duration
+
+	// mu защищает текущую сессию и упорядочивает публикации:
+	// подтверждения приходят по номерам сообщений в канале.
+	mu      sync.Mutex
+	session *session
+	tag     uint64
+	// reconnected закрывается и пересоздается при каждом переподключении.
+	reconnected chan struct{}
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func NewClient(url string, queues Queues) (*Client, error) {
+	return newClient(url, queues, dialAMQP)
+}
+
+func newClient(url string, queues Queues, dial dialFunc) (*Client, error) {
+	c := &Client{
+		url:            url,
+		queues:         queues.withDefaults(),
+		dial:           dial,
+		confirmTimeout: confirmTimeout,
+		minDelay:       minReconnectDelay,
+		maxDelay:       maxReconnectDelay,
+		reconnected:    make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+
+	s, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	c.session = s
+
+	c.wg.Add(1)
+	go c.watch(s)
+	return c, nil
 }
 
-func NewClient(url, queueName string) (*Client, error) {
-	conn, err := amqp.Dial(url)
+// connect открывает соединение и канал, объявляет очереди и включает подтверждения.
+func (c *Client) connect() (*session, error) {
+	conn, err := c.dial(c.url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
@@ -33,72 +160,332 @@ func NewClient(url, queueName string) (*Client, error) {
 		return nil, fmt.Errorf("failed to open a channel: %w", err)
 	}
 
-	_, err = ch.QueueDeclare(
-		queueName, // name
-		true,      // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
-	)
-	if err != nil {
+	if err := c.declare(ch); err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, err
+	}
+
+	if err := ch.Qos(prefetch, 0, false); err != nil {
 		ch.Close()
 		conn.Close()
-		return nil, fmt.Errorf("failed to declare a queue: %w", err)
+		return nil, fmt.Errorf("failed to set prefetch: %w", err)
 	}
 
-	return &Client{
-		conn:    conn,
-		channel: ch,
-		queue:   queueName,
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	return &session{
+		conn:       conn,
+		channel:    ch,
+		confirms:   ch.NotifyPublish(make(chan amqp.Confirmation, 16)),
+		connClosed: conn.NotifyClose(make(chan *amqp.Error, 1)),
+		chanClosed: ch.NotifyClose(make(chan *amqp.Error, 1)),
 	}, nil
 }
 
-func (c *Client) Close() error {
-	if c.channel != nil {
-		c.channel.Close()
+// declare объявляет основную очередь, очередь повторов и очередь недоставленных сообщений.
+// Повторы публикуются в очередь без получателей с TTL: истекшие сообщения брокер
+// возвращает в основную очередь через обменник по умолчанию.
+func (c *Client) declare(ch brokerChannel) error {
+	queues := []struct {
+		name string
+		args amqp.Table
+	}{
+		{name: c.queues.Name},
+		{name: c.queues.retry(), args: amqp.Table{
+			"x-message-ttl":             c.queues.RetryDelay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": c.queues.Name,
+		}},
+		{name: c.queues.DeadLetter},
 	}
-	if c.conn != nil {
-		return c.conn.Close()
+	for _, q := range queues {
+		_, err := ch.QueueDeclare(
+			q.name, // name
+			true,   // durable
+			false,  // delete when unused
+			false,  // exclusive
+			false,  // no-wait
+			q.args, // arguments
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare queue %s: %w", q.name, err)
+		}
 	}
 	return nil
 }
 
+// watch ждет разрыва текущей сессии и восстанавливает ее, пока клиент не закрыт.
+func (c *Client) watch(s *session) {
+	defer c.wg.Done()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-s.connClosed:
+		case <-s.chanClosed:
+		}
+
+		c.mu.Lock()
+		c.session = nil
+		c.mu.Unlock()
+		// Канал мог закрыться при живом соединении: закрываем сессию целиком.
+		s.close()
+
+		if s = c.reconnect(); s == nil {
+			return
+		}
+	}
+}
+
+// reconnect повторяет connect с растущей паузой и устанавливает новую сессию.
+// Возвращает nil, если клиент закрыли раньше.
+func (c *Client) reconnect() *session {
+	delay := c.minDelay
+	for {
+		select {
+		case <-c.done:
+			return nil
+		case <-time.After(delay):
+		}
+
+		s, err := c.connect()
+		if err != nil {
+			delay *= 2
+			if delay > c.maxDelay {
+				delay = c.maxDelay
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		select {
+		case <-c.done:
+			c.mu.Unlock()
+			s.close()
+			return nil
+		default:
+		}
+		c.session, c.tag = s, 0
+		close(c.reconnected)
+		c.reconnected = make(chan struct{})
+		c.mu.Unlock()
+		return s
+	}
+}
+
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+
+		c.mu.Lock()
+		if c.session != nil {
+			c.session.channel.Close()
+			err = c.session.conn.Close()
+			c.session = nil
+		}
+		c.mu.Unlock()
+
+		c.wg.Wait()
+	})
+	return err
+}
+
+// Publish отправляет уведомление и ждет подтверждения брокера. Пока соединение
+// не восстановлено, возвращает ErrNotConnected.
 func (c *Client) Publish(n Notification) error {
 	body, err := json.Marshal(n)
 	if err != nil {
 		return fmt.Errorf("failed to marshal notification: %w", err)
 	}
 
-	err = c.channel.Publish(
-		"",      // exchange
-		c.queue, // routing key
-		false,   // mandatory
-		false,   // immediate
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		})
+	return c.publish(c.queues.Name, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    n.IdempotencyKey,
+		Body:         body,
+	})
+}
+
+// publish отправляет сообщение в очередь queue через обменник по умолчанию и ждет подтверждения.
+func (c *Client) publish(queue string, msg amqp.Publishing) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil {
+		return ErrNotConnected
+	}
+	err := c.session.channel.Publish(
+		"",    // exchange
+		queue, // routing key
+		false, // mandatory
+		false, // immediate
+		msg)
 	if err != nil {
 		return fmt.Errorf("failed to publish a message: %w", err)
 	}
+	c.tag++
 
-	return nil
+	return waitConfirm(c.session.confirms, c.tag, c.confirmTimeout)
+}
+
+// waitConfirm ждет подтверждения сообщения tag, пропуская запоздавшие подтверждения
+// предыдущих сообщений, не дождавшихся своего.
+func waitConfirm(confirms <-chan amqp.Confirmation, tag uint64, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case conf, ok := <-confirms:
+			if !ok {
+				return fmt.Errorf("%w: channel is closed", ErrNotConfirmed)
+			}
+			if conf.DeliveryTag < tag {
+				continue
+			}
+			if !conf.Ack {
+				return fmt.Errorf("%w: nack", ErrNotConfirmed)
+			}
+			return nil
+		case <-timer.C:
+			return fmt.Errorf("%w: timeout", ErrNotConfirmed)
+		}
+	}
 }
 
+// Consume подписывается на очередь. Возвращенный канал переживает переподключения:
+// после разрыва подписка восстанавливается на новом соединении. Канал закрывается
+// только вместе с клиентом. Каждую доставку нужно подтвердить через d.Ack или передать
+// в Retry или DeadLetter; неподтвержденные при разрыве доставки брокер отдаст повторно.
 func (c *Client) Consume() (<-chan amqp.Delivery, error) {
-	msgs, err := c.channel.Consume(
-		c.queue, // queue
-		"",      // consumer
-		true,    // auto-ack
-		false,   // exclusive
-		false,   // no-local
-		false,   // no-wait
-		nil,     // args
+	msgs, next, err := c.subscribe()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan amqp.Delivery)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer close(out)
+
+		for {
+			for d := range msgs {
+				select {
+				case out <- d:
+				case <-c.done:
+					return
+				}
+			}
+
+			// Доставки закончились: соединение потеряно. Ждем переподключения и подписываемся
+			// заново; если подписка не удалась, ждем следующего.
+			for {
+				select {
+				case <-c.done:
+					return
+				case <-next:
+				}
+				if msgs, next, err = c.subscribe(); err == nil {
+					break
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// subscribe подписывается на очередь в текущей сессии и возвращает канал,
+// который закроется при следующем переподключении.
+func (c *Client) subscribe() (<-chan amqp.Delivery, <-chan struct{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	next := c.reconnected
+	if c.session == nil {
+		return nil, next, ErrNotConnected
+	}
+	msgs, err := c.session.channel.Consume(
+		c.queues.Name, // queue
+		"",            // consumer
+		false,         // auto-ack
+		false,         // exclusive
+		false,         // no-local
+		false,         // no-wait
+		nil,           // args
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to register a consumer: %w", err)
+		return nil, next, fmt.Errorf("failed to register a consumer: %w", err)
+	}
+
+	return msgs, next, nil
+}
+
+// Attempts возвращает число неудачных попыток обработки доставки.
+func Attempts(d amqp.Delivery) int {
+	switch v := d.Headers[attemptsHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+// Retry откладывает доставку, которую не удалось обработать из-за cause: сообщение уходит
+// в очередь повторов, а после MaxAttempts неудач - в очередь недоставленных. Возвращает true,
+// если сообщение отправлено в очередь недоставленных. Если переложить сообщение не удалось,
+// доставка возвращается в основную очередь.
+func (c *Client) Retry(d amqp.Delivery, cause error) (bool, error) {
+	attempts := Attempts(d) + 1
+	queue, dead := c.queues.retry(), attempts >= c.queues.MaxAttempts
+	if dead {
+		queue = c.queues.DeadLetter
 	}
+	return dead, c.move(d, queue, attempts, cause)
+}
+
+// DeadLetter отправляет доставку, которую бессмысленно повторять, в очередь недоставленных.
+func (c *Client) DeadLetter(d amqp.Delivery, cause error) error {
+	return c.move(d, c.queues.DeadLetter, Attempts(d)+1, cause)
+}
 
-	return msgs, nil
+// move публикует копию доставки в queue и подтверждает оригинал. Сбой между публикацией
+// и подтверждением дает дубликат, который получатель отбросит по ключу идемпотентности.
+func (c *Client) move(d amqp.Delivery, queue string, attempts int, cause error) error {
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[attemptsHeader] = int64(attempts)
+	if cause != nil {
+		headers[errorHeader] = cause.Error()
+	}
+
+	err := c.publish(queue, amqp.Publishing{
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    d.MessageId,
+		Headers:      headers,
+		Body:         d.Body,
+	})
+	if err != nil {
+		_ = d.Nack(false, true)
+		return fmt.Errorf("failed to move message to %s: %w", queue, err)
+	}
+	if err := d.Ack(false); err != nil {
+		return fmt.Errorf("failed to ack message: %w", err)
+	}
+	return nil
 }
@@ -2,8 +2,11 @@ package rabbitmq
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/streadway/amqp"
 )
 
 func TestNotificationSerialization(t *testing.T) {
@@ -38,3 +41,215 @@ func TestNotificationSerialization(t *testing.T) {
 		t.Errorf("expected UserID %s, got %s", n.UserID, n2.UserID)
 	}
 }
+
+func TestWaitConfirm(t *testing.T) {
+	confirms := make(chan amqp.Confirmation, 3)
+	confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+	confirms <- amqp.Confirmation{DeliveryTag: 2, Ack: true}
+	if err := waitConfirm(confirms, 2, time.Second); err != nil {
+		t.Errorf("expected confirmation, got %v", err)
+	}
+
+	confirms <- amqp.Confirmation{DeliveryTag: 3, Ack: false}
+	if err := waitConfirm(confirms, 3, time.Second); !errors.Is(err, ErrNotConfirmed) {
+		t.Errorf("expected ErrNotConfirmed on nack, got %v", err)
+	}
+
+	if err := waitConfirm(confirms, 4, 10*time.Millisecond); !errors.Is(err, ErrNotConfirmed) {
+		t.Errorf("expected ErrNotConfirmed on timeout, got %v", err)
+	}
+}
+
+// newTestClient подключает клиента к брокеру в памяти с короткими паузами и таймаутами.
+func newTestClient(t *testing.T, b *fakeBroker) *Client {
+	t.Helper()
+
+	c, err := newClient("amqp://fake", Queues{Name: "notifications", RetryDelay: 20 * time.Millisecond, MaxAttempts: 3}, b.dial)
+	if err != nil {
+		t.Fatalf("newClient failed: %v", err)
+	}
+	c.confirmTimeout = 50 * time.Millisecond
+	c.minDelay, c.maxDelay = time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+// eventually повторяет cond, пока оно не выполнится или не выйдет секунда.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition is not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClient_PublishConfirmed(t *testing.T) {
+	b := newFakeBroker()
+	c := newTestClient(t, b)
+
+	if err := c.Publish(Notification{EventID: "1", IdempotencyKey: "key-1"}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	msg := b.messages("notifications")[0]
+	if msg.MessageId != "key-1" || msg.DeliveryMode != amqp.Persistent {
+		t.Errorf("expected persistent message with id key-1, got %+v", msg)
+	}
+
+	b.mu.Lock()
+	b.noConfirm = true
+	b.mu.Unlock()
+	if err := c.Publish(Notification{EventID: "2"}); !errors.Is(err, ErrNotConfirmed) {
+		t.Errorf("expected ErrNotConfirmed without broker confirmation, got %v", err)
+	}
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	b := newFakeBroker()
+	c := newTestClient(t, b)
+
+	b.setDown(true)
+	b.drop()
+	eventually(t, func() bool { return errors.Is(c.Publish(Notification{EventID: "1"}), ErrNotConnected) })
+	// Пока брокер недоступен, клиент продолжает попытки с растущей паузой.
+	eventually(t, func() bool { dials, _, _ := b.stats(); return dials >= 3 })
+
+	b.setDown(false)
+	eventually(t, func() bool { return c.Publish(Notification{EventID: "2"}) == nil })
+
+	// Основная очередь, очередь повторов и очередь недоставленных при каждом подключении.
+	if _, declares, _ := b.stats(); declares != 6 {
+		t.Errorf("expected queues to be declared again after reconnect, got %d declarations", declares)
+	}
+}
+
+func TestClient_ConsumeResubscribes(t *testing.T) {
+	b := newFakeBroker()
+	c := newTestClient(t, b)
+
+	msgs, err := c.Consume()
+	if err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	receive := func() Notification {
+		t.Helper()
+		d := receiveDelivery(t, msgs)
+		var n Notification
+		if err := json.Unmarshal(d.Body, &n); err != nil {
+			t.Fatalf("failed to unmarshal delivery: %v", err)
+		}
+		if err := d.Ack(false); err != nil {
+			t.Fatalf("Ack failed: %v", err)
+		}
+		return n
+	}
+
+	if err := c.Publish(Notification{EventID: "1"}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if n := receive(); n.EventID != "1" {
+		t.Errorf("expected event 1, got %s", n.EventID)
+	}
+
+	b.drop()
+	eventually(t, func() bool { return c.Publish(Notification{EventID: "2"}) == nil })
+	if n := receive(); n.EventID != "2" {
+		t.Errorf("expected event 2 after reconnect, got %s", n.EventID)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	select {
+	case _, ok := <-msgs:
+		if ok {
+			t.Error("expected no deliveries after Close")
+		}
+	case <-time.After(time.Second):
+		t.Error("expected deliveries channel to be closed with the client")
+	}
+}
+
+func receiveDelivery(t *testing.T, msgs <-chan amqp.Delivery) amqp.Delivery {
+	t.Helper()
+
+	select {
+	case d := <-msgs:
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("no delivery in time")
+		return amqp.Delivery{}
+	}
+}
+
+func TestClient_UnackedRedelivered(t *testing.T) {
+	b := newFakeBroker()
+	c := newTestClient(t, b)
+
+	msgs, err := c.Consume()
+	if err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if err := c.Publish(Notification{EventID: "1", IdempotencyKey: "key-1"}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	_ = receiveDelivery(t, msgs)
+
+	// Получатель упал, не подтвердив доставку: после переподключения она приходит снова.
+	b.drop()
+	if d := receiveDelivery(t, msgs); d.MessageId != "key-1" {
+		t.Errorf("expected unacked message to be redelivered, got %q", d.MessageId)
+	}
+}
+
+func TestClient_RetryAndDeadLetter(t *testing.T) {
+	b := newFakeBroker()
+	c := newTestClient(t, b)
+
+	msgs, err := c.Consume()
+	if err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if err := c.Publish(Notification{EventID: "1", IdempotencyKey: "key-1"}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	cause := errors.New("smtp is down")
+	for attempt := 1; attempt <= 3; attempt++ {
+		d := receiveDelivery(t, msgs)
+		if got := Attempts(d); got != attempt-1 {
+			t.Fatalf("expected %d previous attempts, got %d", attempt-1, got)
+		}
+		dead, err := c.Retry(d, cause)
+		if err != nil {
+			t.Fatalf("Retry failed: %v", err)
+		}
+		if dead != (attempt == 3) {
+			t.Fatalf("attempt %d: expected dead-lettered %v, got %v", attempt, attempt == 3, dead)
+		}
+	}
+
+	if retries := b.messages("notifications.retry"); len(retries) != 2 {
+		t.Errorf("expected 2 delayed retries, got %d", len(retries))
+	}
+	dead := b.messages("notifications.dead")
+	if len(dead) != 1 || dead[0].MessageId != "key-1" || dead[0].Headers[errorHeader] != cause.Error() {
+		t.Fatalf("expected message key-1 in dead-letter queue with the last error, got %+v", dead)
+	}
+	if acked, nacked := b.settled(); acked != 3 || nacked != 0 {
+		t.Errorf("expected every delivery to be acked once, got %d acked, %d nacked", acked, nacked)
+	}
+
+	if err := c.Publish(Notification{EventID: "2"}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if err := c.DeadLetter(receiveDelivery(t, msgs), errors.New("malformed")); err != nil {
+		t.Fatalf("DeadLetter failed: %v", err)
+	}
+	if dead := b.messages("notifications.dead"); len(dead) != 2 {
+		t.Errorf("expected poison message to go straight to dead-letter queue, got %d", len(dead))
+	}
+}
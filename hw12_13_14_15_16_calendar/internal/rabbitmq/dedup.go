@@ -0,0 +1,64 @@
+package rabbitmq
+
+import (
+	"sync"
+	"time"
+)
+
+// Deduplicator запоминает ключи идемпотентности обработанных уведомлений на время ttl.
+// Состояние хранится в памяти процесса и теряется при перезапуске.
+type Deduplicator struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	seen  map[string]time.Time
+	order []dedupEntry
+}
+
+type dedupEntry struct {
+	key     string
+	expires time.Time
+}
+
+func NewDeduplicator(ttl time.Duration) *Deduplicator {
+	return &Deduplicator{ttl: ttl, seen: make(map[string]time.Time)}
+}
+
+// Seen сообщает, встречался ли ключ в течение ttl, и запоминает его.
+// Пустой ключ никогда не считается повтором.
+func (d *Deduplicator) Seen(key string, now time.Time) bool {
+	if key == "" {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.evictLocked(now)
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+	expires := now.Add(d.ttl)
+	d.seen[key] = expires
+	d.order = append(d.order, dedupEntry{key: key, expires: expires})
+	return false
+}
+
+// Forget забывает ключ, например если уведомление с ним так и не удалось доставить.
+func (d *Deduplicator) Forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.seen, key)
+}
+
+// evictLocked забывает ключи с истекшим сроком; order упорядочен по сроку.
+// Ключ, запомненный заново после Forget, удаляется только по своей, более поздней записи.
+func (d *Deduplicator) evictLocked(now time.Time) {
+	i := 0
+	for ; i < len(d.order) && !d.order[i].expires.After(now); i++ {
+		if e := d.order[i]; d.seen[e.key].Equal(e.expires) {
+			delete(d.seen, e.key)
+		}
+	}
+	d.order = d.order[i:]
+}
@@ -0,0 +1,39 @@
+package rabbitmq
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeduplicator(t *testing.T) {
+	d := NewDeduplicator(time.Hour)
+	now := time.Now()
+
+	if d.Seen("key", now) {
+		t.Error("first delivery must not be a duplicate")
+	}
+	if !d.Seen("key", now.Add(time.Minute)) {
+		t.Error("repeated delivery must be a duplicate")
+	}
+	if d.Seen("", now) || d.Seen("", now) {
+		t.Error("empty key must never be a duplicate")
+	}
+	if d.Seen("key", now.Add(2*time.Hour)) {
+		t.Error("key must be forgotten after ttl")
+	}
+}
+
+func TestDeduplicator_Forget(t *testing.T) {
+	d := NewDeduplicator(time.Hour)
+	now := time.Now()
+
+	_ = d.Seen("key", now)
+	d.Forget("key")
+	if d.Seen("key", now.Add(30*time.Minute)) {
+		t.Error("forgotten key must not be a duplicate")
+	}
+	// Истечение первой записи не должно стирать ключ, запомненный заново.
+	if !d.Seen("key", now.Add(time.Hour+time.Minute)) {
+		t.Error("key remembered again must outlive its first entry")
+	}
+}
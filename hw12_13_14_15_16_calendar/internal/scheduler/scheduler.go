@@ -2,7 +2,9 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/logger"
@@ -11,9 +13,42 @@ import (
 )
 
 type Storage interface {
-	GetEventsToNotify(ctx context.Context) ([]storage.Event, error)
-	MarkEventNotified(ctx context.Context, id string) error
+	ClaimEventsToNotify(ctx context.Context, owner string, lease time.Duration) ([]storage.DueReminder, error)
+	MarkEventNotified(
+		ctx context.Context, id, reminderID string, occurrence time.Time, msgs ...storage.OutboxMessage,
+	) error
+	ClaimOutbox(ctx context.Context, owner string, lease time.Duration, limit int) ([]storage.OutboxMessage, error)
+	DeleteOutbox(ctx context.Context, id string) error
 	DeleteOldEvents(ctx context.Context, olderThan time.Time) error
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) error
+}
+
+// Leader разрешает выполнять задачу только одному экземпляру планировщика одновременно.
+type Leader interface {
+	RunExclusive(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error)
+}
+
+const (
+	// relayBatch - сколько сообщений outbox публикуется за один проход RelayOutbox.
+	relayBatch = 100
+	// defaultClaimLease - на сколько захватываются напоминания, если ClaimLease не задан.
+	defaultClaimLease = time.Minute
+	// DefaultTrashRetention - сколько удаленные события хранятся в корзине, если TrashRetention не задан.
+	DefaultTrashRetention = 30 * 24 * time.Hour
+	// cleanupTask - имя задачи очистки для выбора лидера.
+	cleanupTask = "calendar_scheduler.cleanup"
+)
+
+// Options - настройки работы нескольких экземпляров планировщика с одним хранилищем.
+type Options struct {
+	// InstanceID отличает экземпляр при захвате напоминаний; по умолчанию hostname-pid.
+	InstanceID string
+	// ClaimLease - сколько захваченные напоминания и сообщения outbox недоступны другим экземплярам.
+	ClaimLease time.Duration
+	// Leader, если задан, ограничивает очистку одним экземпляром.
+	Leader Leader
+	// TrashRetention - через сколько после удаления событие окончательно удаляется из корзины.
+	TrashRetention time.Duration
 }
 
 type Publisher interface {
@@ -24,49 +59,151 @@ type Scheduler struct {
 	storage   Storage
 	publisher Publisher
 	logger    *logger.Logger
+	opts      Options
 }
 
-func New(s Storage, p Publisher, l *logger.Logger) *Scheduler {
+func New(s Storage, p Publisher, l *logger.Logger, opts Options) *Scheduler {
+	if opts.InstanceID == "" {
+		host, _ := os.Hostname()
+		opts.InstanceID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if opts.ClaimLease <= 0 {
+		opts.ClaimLease = defaultClaimLease
+	}
+	if opts.TrashRetention <= 0 {
+		opts.TrashRetention = DefaultTrashRetention
+	}
 	return &Scheduler{
 		storage:   s,
 		publisher: p,
 		logger:    l,
+		opts:      opts,
 	}
 }
 
+// ProcessNotifications ставит подошедшие напоминания в outbox; публикует их RelayOutbox.
+// Уведомление о напоминании получает каждый получатель события (см. storage.Event.Recipients).
+// Напоминания захватываются за экземпляром, поэтому параллельные планировщики их не делят.
+// Отметка напоминания и запись в outbox выполняются одной транзакцией, поэтому сбой
+// между ними не приводит ни к потере, ни к повторной постановке уведомления.
 func (s *Scheduler) ProcessNotifications(ctx context.Context) {
-	events, err := s.storage.GetEventsToNotify(ctx)
+	due, err := s.storage.ClaimEventsToNotify(ctx, s.opts.InstanceID, s.opts.ClaimLease)
 	if err != nil {
 		s.logger.Error(fmt.Sprintf("failed to get events to notify: %v", err))
 		return
 	}
 
-	for _, e := range events {
+	// Для повторяющихся событий хранилище возвращает конкретное вхождение серии.
+	for _, d := range due {
+		e := d.Event
+		msgs, err := outboxMessages(d)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("failed to marshal notification for event %s: %v", e.ID, err))
+			continue
+		}
+
+		if err := s.storage.MarkEventNotified(ctx, e.ID, d.Reminder.ID, e.StartTime, msgs...); err != nil {
+			s.logger.Error(fmt.Sprintf("failed to enqueue reminder %s of event %s: %v", d.Reminder.ID, e.ID, err))
+		} else {
+			s.logger.Info(fmt.Sprintf("notification queued for event %s (reminder %s)", e.ID, d.Reminder.ID))
+		}
+	}
+}
+
+// outboxMessages готовит по сообщению outbox на каждого получателя напоминания.
+// Ключ владельца совпадает с storage.NotificationKey, ключи участников дополнены их ID.
+func outboxMessages(d storage.DueReminder) ([]storage.OutboxMessage, error) {
+	e := d.Event
+	key := storage.NotificationKey(e.ID, d.Reminder.ID, e.StartTime)
+	recipients := e.Recipients()
+	msgs := make([]storage.OutboxMessage, 0, len(recipients))
+	for _, userID := range recipients {
 		notif := rabbitmq.Notification{
-			EventID:   e.ID,
-			Title:     e.Title,
-			StartTime: e.StartTime,
-			UserID:    e.UserID,
+			EventID:        e.ID,
+			Title:          e.Title,
+			StartTime:      e.StartTime,
+			UserID:         userID,
+			TimeZone:       e.TimeZone,
+			Channel:        d.Reminder.Channel,
+			IdempotencyKey: key,
+		}
+		if userID != e.UserID {
+			notif.IdempotencyKey = key + "/" + userID
 		}
+		payload, err := json.Marshal(notif)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, storage.OutboxMessage{ID: notif.IdempotencyKey, Payload: payload})
+	}
+	return msgs, nil
+}
 
-		if err := s.publisher.Publish(notif); err != nil {
-			s.logger.Error(fmt.Sprintf("failed to publish notification for event %s: %v", e.ID, err))
+// RelayOutbox публикует сообщения outbox по порядку и удаляет подтвержденные брокером.
+// Сообщения захватываются за экземпляром, поэтому параллельные планировщики их не делят.
+// На первой ошибке публикации проход прерывается: сообщение останется в outbox до следующего.
+// Если сбой случится между публикацией и удалением, сообщение уйдет повторно
+// с тем же ключом идемпотентности, и получатель его отбросит.
+func (s *Scheduler) RelayOutbox(ctx context.Context) {
+	messages, err := s.storage.ClaimOutbox(ctx, s.opts.InstanceID, s.opts.ClaimLease, relayBatch)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("failed to get outbox messages: %v", err))
+		return
+	}
+
+	for _, m := range messages {
+		var notif rabbitmq.Notification
+		if err := json.Unmarshal(m.Payload, &notif); err != nil {
+			// Повторная попытка не поможет: сообщение отбрасывается, чтобы не блокировать очередь.
+			s.logger.Error(fmt.Sprintf("dropping malformed outbox message %s: %v", m.ID, err))
+			s.deleteOutbox(ctx, m.ID)
 			continue
 		}
 
-		if err := s.storage.MarkEventNotified(ctx, e.ID); err != nil {
-			s.logger.Error(fmt.Sprintf("failed to mark event %s as notified: %v", e.ID, err))
-		} else {
-			s.logger.Info(fmt.Sprintf("notification sent for event %s", e.ID))
+		if err := s.publisher.Publish(notif); err != nil {
+			s.logger.Error(fmt.Sprintf("failed to publish notification %s: %v", m.ID, err))
+			return
 		}
+		s.deleteOutbox(ctx, m.ID)
+		s.logger.Info(fmt.Sprintf("notification sent for event %s (%s)", notif.EventID, m.ID))
 	}
 }
 
+func (s *Scheduler) deleteOutbox(ctx context.Context, id string) {
+	if err := s.storage.DeleteOutbox(ctx, id); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to delete outbox message %s: %v", id, err))
+	}
+}
+
+// ProcessCleanup переносит в корзину события старше года и окончательно удаляет события,
+// пролежавшие в корзине дольше TrashRetention. С выбором лидера очистку выполняет только
+// экземпляр, получивший блокировку; остальные пропускают проход.
 func (s *Scheduler) ProcessCleanup(ctx context.Context) {
-	olderThan := time.Now().AddDate(-1, 0, 0)
-	if err := s.storage.DeleteOldEvents(ctx, olderThan); err != nil {
+	if s.opts.Leader == nil {
+		s.cleanup(ctx)
+		return
+	}
+	ran, err := s.opts.Leader.RunExclusive(ctx, cleanupTask, func(ctx context.Context) error {
+		s.cleanup(ctx)
+		return nil
+	})
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("failed to elect cleanup leader: %v", err))
+	} else if !ran {
+		s.logger.Debug("cleanup is running on another instance, skipping")
+	}
+}
+
+func (s *Scheduler) cleanup(ctx context.Context) {
+	now := time.Now()
+	if err := s.storage.DeleteOldEvents(ctx, now.AddDate(-1, 0, 0)); err != nil {
 		s.logger.Error(fmt.Sprintf("failed to cleanup old events: %v", err))
 	} else {
-		s.logger.Info("old events cleaned up")
+		s.logger.Info("old events moved to trash")
+	}
+	if err := s.storage.PurgeTrash(ctx, now.Add(-s.opts.TrashRetention)); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to purge trash: %v", err))
+	} else {
+		s.logger.Info("trash purged")
 	}
 }
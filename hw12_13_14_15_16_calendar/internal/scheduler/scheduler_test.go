@@ -2,26 +2,55 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/logger"
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage/memory"
 )
 
 type MockStorage struct {
-	eventsToNotify  []storage.Event
+	dueReminders    []storage.DueReminder
 	notifiedIDs     []string
+	outbox          []storage.OutboxMessage
 	deleteOldCalled bool
+	purgedBefore    time.Time
 }
 
-func (m *MockStorage) GetEventsToNotify(_ context.Context) ([]storage.Event, error) {
-	return m.eventsToNotify, nil
+func (m *MockStorage) ClaimEventsToNotify(_ context.Context, _ string, _ time.Duration) ([]storage.DueReminder, error) {
+	return m.dueReminders, nil
 }
 
-func (m *MockStorage) MarkEventNotified(_ context.Context, id string) error {
-	m.notifiedIDs = append(m.notifiedIDs, id)
+func (m *MockStorage) MarkEventNotified(
+	_ context.Context, id, reminderID string, _ time.Time, msgs ...storage.OutboxMessage,
+) error {
+	m.notifiedIDs = append(m.notifiedIDs, id+"/"+reminderID)
+	m.outbox = append(m.outbox, msgs...)
+	return nil
+}
+
+func (m *MockStorage) ClaimOutbox(
+	_ context.Context, _ string, _ time.Duration, limit int,
+) ([]storage.OutboxMessage, error) {
+	if len(m.outbox) > limit {
+		return m.outbox[:limit], nil
+	}
+	return m.outbox, nil
+}
+
+func (m *MockStorage) DeleteOutbox(_ context.Context, id string) error {
+	for i, msg := range m.outbox {
+		if msg.ID == id {
+			m.outbox = append(m.outbox[:i:i], m.outbox[i+1:]...)
+			return nil
+		}
+	}
 	return nil
 }
 
@@ -30,35 +59,124 @@ func (m *MockStorage) DeleteOldEvents(_ context.Context, _ time.Time) error {
 	return nil
 }
 
+func (m *MockStorage) PurgeTrash(_ context.Context, deletedBefore time.Time) error {
+	m.purgedBefore = deletedBefore
+	return nil
+}
+
 type MockPublisher struct {
 	published []rabbitmq.Notification
+	// failAfter - после скольких успешных публикаций Publish начинает возвращать ошибку; 0 - никогда.
+	failAfter int
 }
 
 func (m *MockPublisher) Publish(n rabbitmq.Notification) error {
+	if m.failAfter > 0 && len(m.published) >= m.failAfter {
+		return errors.New("broker is unavailable")
+	}
 	m.published = append(m.published, n)
 	return nil
 }
 
 func TestScheduler_ProcessNotifications(t *testing.T) {
 	ms := &MockStorage{
-		eventsToNotify: []storage.Event{
-			{ID: "1", Title: "Event 1", UserID: "user1", StartTime: time.Now()},
+		dueReminders: []storage.DueReminder{
+			{
+				Event:    storage.Event{ID: "1", Title: "Event 1", UserID: "user1", StartTime: time.Now()},
+				Reminder: storage.Reminder{ID: "r1", Offset: time.Hour, Channel: "email"},
+			},
 		},
 	}
 	mp := &MockPublisher{}
 	log := logger.New("ERROR")
-	s := New(ms, mp, log)
+	s := New(ms, mp, log, Options{})
+
+	s.ProcessNotifications(context.Background())
+
+	if len(mp.published) != 0 {
+		t.Errorf("expected notifications to go through outbox, got %d published", len(mp.published))
+	}
+	if len(ms.notifiedIDs) != 1 || ms.notifiedIDs[0] != "1/r1" {
+		t.Errorf("expected reminder r1 of event 1 to be marked as notified, got %v", ms.notifiedIDs)
+	}
+	if len(ms.outbox) != 1 {
+		t.Fatalf("expected 1 outbox message, got %d", len(ms.outbox))
+	}
+	var n rabbitmq.Notification
+	if err := json.Unmarshal(ms.outbox[0].Payload, &n); err != nil {
+		t.Fatalf("failed to unmarshal outbox payload: %v", err)
+	}
+	if n.EventID != "1" || n.Channel != "email" || n.IdempotencyKey != ms.outbox[0].ID {
+		t.Errorf("expected EventID 1 via email keyed by %s, got %+v", ms.outbox[0].ID, n)
+	}
+}
+
+func TestScheduler_ProcessNotificationsAttendees(t *testing.T) {
+	ms := &MockStorage{
+		dueReminders: []storage.DueReminder{{
+			Event: storage.Event{ID: "1", Title: "Meeting", UserID: "owner", StartTime: time.Now(), Attendees: []storage.Attendee{
+				{UserID: "alice", Status: storage.StatusAccepted},
+				{UserID: "bob", Status: storage.StatusDeclined},
+				{UserID: "carol", Status: storage.StatusNeedsAction},
+			}},
+			Reminder: storage.Reminder{ID: "r1", Offset: time.Hour},
+		}},
+	}
+	s := New(ms, &MockPublisher{}, logger.New("ERROR"), Options{})
 
 	s.ProcessNotifications(context.Background())
 
-	if len(mp.published) != 1 {
-		t.Errorf("expected 1 published notification, got %d", len(mp.published))
+	if len(ms.notifiedIDs) != 1 {
+		t.Errorf("expected the reminder to be marked once, got %v", ms.notifiedIDs)
+	}
+	var users []string
+	keys := make(map[string]bool)
+	for _, msg := range ms.outbox {
+		var n rabbitmq.Notification
+		if err := json.Unmarshal(msg.Payload, &n); err != nil {
+			t.Fatalf("failed to unmarshal outbox payload: %v", err)
+		}
+		users = append(users, n.UserID)
+		keys[msg.ID] = true
+	}
+	if fmt.Sprint(users) != "[owner alice carol]" || len(keys) != 3 {
+		t.Errorf("expected distinct notifications for owner, alice and carol, got %v (%d keys)", users, len(keys))
+	}
+	if !keys[storage.NotificationKey("1", "r1", ms.dueReminders[0].Event.StartTime)] {
+		t.Errorf("owner notification must keep its key, got %v", keys)
+	}
+}
+
+func TestScheduler_RelayOutbox(t *testing.T) {
+	payload := func(id string) []byte {
+		b, _ := json.Marshal(rabbitmq.Notification{EventID: id, IdempotencyKey: id})
+		return b
+	}
+	ms := &MockStorage{
+		outbox: []storage.OutboxMessage{
+			{ID: "1", Payload: payload("1")},
+			{ID: "bad", Payload: []byte("not json")},
+			{ID: "2", Payload: payload("2")},
+			{ID: "3", Payload: payload("3")},
+		},
+	}
+	mp := &MockPublisher{failAfter: 2}
+	s := New(ms, mp, logger.New("ERROR"), Options{})
+
+	s.RelayOutbox(context.Background())
+
+	if len(mp.published) != 2 || mp.published[0].EventID != "1" || mp.published[1].EventID != "2" {
+		t.Fatalf("expected events 1 and 2 to be published in order, got %+v", mp.published)
 	}
-	if mp.published[0].EventID != "1" {
-		t.Errorf("expected EventID 1, got %s", mp.published[0].EventID)
+	// Неподтвержденное сообщение остается в outbox до следующего прохода.
+	if len(ms.outbox) != 1 || ms.outbox[0].ID != "3" {
+		t.Errorf("expected only message 3 to remain in outbox, got %+v", ms.outbox)
 	}
-	if len(ms.notifiedIDs) != 1 || ms.notifiedIDs[0] != "1" {
-		t.Errorf("expected event 1 to be marked as notified")
+
+	mp.failAfter = 0
+	s.RelayOutbox(context.Background())
+	if len(mp.published) != 3 || len(ms.outbox) != 0 {
+		t.Errorf("expected outbox to be drained, published %d, left %d", len(mp.published), len(ms.outbox))
 	}
 }
 
@@ -66,11 +184,129 @@ func TestScheduler_ProcessCleanup(t *testing.T) {
 	ms := &MockStorage{}
 	mp := &MockPublisher{}
 	log := logger.New("ERROR")
-	s := New(ms, mp, log)
+	s := New(ms, mp, log, Options{TrashRetention: 7 * 24 * time.Hour})
 
 	s.ProcessCleanup(context.Background())
 
 	if !ms.deleteOldCalled {
 		t.Errorf("expected DeleteOldEvents to be called")
 	}
+	if age := time.Since(ms.purgedBefore); age < 7*24*time.Hour || age > 7*24*time.Hour+time.Minute {
+		t.Errorf("expected trash older than a week to be purged, got cutoff %v", ms.purgedBefore)
+	}
+}
+
+// countingStorage считает вызовы MarkEventNotified поверх настоящего хранилища.
+type countingStorage struct {
+	*memorystorage.Storage
+	mu    sync.Mutex
+	marks map[string]int
+}
+
+func (c *countingStorage) MarkEventNotified(
+	ctx context.Context, id, reminderID string, occurrence time.Time, msgs ...storage.OutboxMessage,
+) error {
+	c.mu.Lock()
+	c.marks[reminderID]++
+	c.mu.Unlock()
+	return c.Storage.MarkEventNotified(ctx, id, reminderID, occurrence, msgs...)
+}
+
+func TestScheduler_ConcurrentInstances(t *testing.T) {
+	ctx := context.Background()
+	stor := &countingStorage{Storage: memorystorage.New(), marks: make(map[string]int)}
+	const events = 50
+	start := time.Now().Add(10 * time.Minute)
+	for i := 0; i < events; i++ {
+		_, err := stor.CreateEvent(ctx, storage.Event{
+			Title:     fmt.Sprintf("Event %d", i),
+			StartTime: start.Add(time.Duration(i) * time.Hour),
+			EndTime:   start.Add(time.Duration(i)*time.Hour + time.Minute),
+			UserID:    "user1",
+			Reminders: []storage.Reminder{{Offset: 24 * 7 * time.Hour}},
+		})
+		if err != nil {
+			t.Fatalf("CreateEvent failed: %v", err)
+		}
+	}
+
+	replicas := make([]*Scheduler, 4)
+	publishers := make([]*MockPublisher, len(replicas))
+	for i := range replicas {
+		publishers[i] = &MockPublisher{}
+		replicas[i] = New(stor, publishers[i], logger.New("ERROR"), Options{InstanceID: fmt.Sprintf("replica-%d", i)})
+	}
+	run := func(fn func(s *Scheduler)) {
+		var wg sync.WaitGroup
+		for _, s := range replicas {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fn(s)
+			}()
+		}
+		wg.Wait()
+	}
+	run(func(s *Scheduler) { s.ProcessNotifications(ctx) })
+
+	if len(stor.marks) != events {
+		t.Fatalf("expected %d reminders to be handled, got %d", events, len(stor.marks))
+	}
+	for id, n := range stor.marks {
+		if n != 1 {
+			t.Errorf("expected reminder %s to be handled by a single replica, got %d", id, n)
+		}
+	}
+	if pending, _ := stor.PendingOutbox(ctx, 2*events); len(pending) != events {
+		t.Errorf("expected %d outbox messages, got %d", events, len(pending))
+	}
+
+	run(func(s *Scheduler) { s.RelayOutbox(ctx) })
+	published := make(map[string]int)
+	for _, p := range publishers {
+		for _, n := range p.published {
+			published[n.IdempotencyKey]++
+		}
+	}
+	if len(published) != events {
+		t.Fatalf("expected %d notifications to be published, got %d", events, len(published))
+	}
+	for key, n := range published {
+		if n != 1 {
+			t.Errorf("expected notification %s to be published once, got %d", key, n)
+		}
+	}
+	if pending, _ := stor.PendingOutbox(ctx, 2*events); len(pending) != 0 {
+		t.Errorf("expected outbox to be drained, got %d messages", len(pending))
+	}
+}
+
+func TestScheduler_ProcessCleanupLeader(t *testing.T) {
+	ctx := context.Background()
+	leader := memorystorage.New()
+	ms := &MockStorage{}
+	s := New(ms, &MockPublisher{}, logger.New("ERROR"), Options{Leader: leader})
+
+	// Очистку уже выполняет другая реплика: этот экземпляр должен пропустить проход.
+	started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = leader.RunExclusive(ctx, cleanupTask, func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	s.ProcessCleanup(ctx)
+	if ms.deleteOldCalled {
+		t.Fatal("expected cleanup to be skipped while another instance holds the lock")
+	}
+
+	close(release)
+	<-done
+	s.ProcessCleanup(ctx)
+	if !ms.deleteOldCalled {
+		t.Error("expected DeleteOldEvents to be called after the lock is released")
+	}
 }
@@ -0,0 +1,158 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+)
+
+// smtpTimeout ограничивает сеанс SMTP, если у контекста нет своего срока.
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig - параметры SMTP-сервера для отправки писем.
+type SMTPConfig struct {
+	Host string
+	Port string
+	// Username и Password включают аутентификацию PLAIN; пустой Username - без аутентификации.
+	Username string
+	Password string
+	From     string
+}
+
+// EmailNotifier отправляет уведомления письмом на адрес пользователя из Recipients.
+type EmailNotifier struct {
+	conf       SMTPConfig
+	recipients Recipients
+	renderer   *Renderer
+}
+
+func NewEmailNotifier(conf SMTPConfig, recipients Recipients, renderer *Renderer) *EmailNotifier {
+	return &EmailNotifier{conf: conf, recipients: recipients, renderer: renderer}
+}
+
+func (e *EmailNotifier) Notify(ctx context.Context, n rabbitmq.Notification) error {
+	rcpt := e.recipients[n.UserID]
+	if rcpt.Email == "" {
+		return fmt.Errorf("%w: no email for user %s", ErrUndeliverable, n.UserID)
+	}
+	msg, err := e.renderer.Render(ChannelEmail, n, rcpt)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUndeliverable, err)
+	}
+	body, err := e.message(rcpt.Email, n, msg)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUndeliverable, err)
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(e.conf.Host, e.conf.Port))
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to set SMTP deadline: %w", err)
+	}
+
+	c, err := smtp.NewClient(conn, e.conf.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: e.conf.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if e.conf.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.conf.Username, e.conf.Password, e.conf.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+	if err := c.Mail(e.conf.From); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
+	}
+	if err := c.Rcpt(rcpt.Email); err != nil {
+		return fmt.Errorf("failed to set recipient: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return c.Quit()
+}
+
+// message собирает письмо в формате RFC 5322: только текст или, если есть HTML,
+// multipart/alternative из текстовой и HTML-частей.
+func (e *EmailNotifier) message(to string, n rabbitmq.Notification, msg Message) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", e.conf.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	if n.IdempotencyKey != "" {
+		fmt.Fprintf(&b, "X-Calendar-Notification: %s\r\n", n.IdempotencyKey)
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&b, msg.Text); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType+"; charset=utf-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		w, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
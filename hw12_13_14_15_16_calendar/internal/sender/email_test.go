@@ -0,0 +1,144 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+)
+
+// smtpMessage - письмо, принятое тестовым SMTP-сервером.
+type smtpMessage struct {
+	from, to, data string
+}
+
+// startSMTPServer запускает минимальный SMTP-сервер, принимающий одно письмо.
+func startSMTPServer(t *testing.T) (host, port string, messages <-chan smtpMessage) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	out := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		var msg smtpMessage
+		_ = tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch {
+			case cmd == "EHLO" || cmd == "HELO":
+				_ = tp.PrintfLine("250 localhost")
+			case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+				msg.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				_ = tp.PrintfLine("250 OK")
+			case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+				msg.to = strings.Trim(line[len("RCPT TO:"):], "<>")
+				_ = tp.PrintfLine("250 OK")
+			case cmd == "DATA":
+				_ = tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				msg.data = string(data)
+				out <- msg
+				_ = tp.PrintfLine("250 OK")
+			case cmd == "QUIT":
+				_ = tp.PrintfLine("221 Bye")
+				return
+			default:
+				_ = tp.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(ln.Addr().String())
+	return host, port, out
+}
+
+func TestEmailNotifier_Notify(t *testing.T) {
+	host, port, messages := startSMTPServer(t)
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEmailNotifier(
+		SMTPConfig{Host: host, Port: port, From: "calendar@example.com"},
+		Recipients{"user1": {Email: "user1@example.com", Locale: LocaleRU, Location: moscow}},
+		newTestRenderer(t),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Date(2026, time.October, 17, 11, 30, 0, 0, time.UTC)
+	n := rabbitmq.Notification{EventID: "1", Title: "Встреча", UserID: "user1", StartTime: start}
+	if err := e.Notify(ctx, n); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	msg := <-messages
+	if msg.from != "calendar@example.com" || msg.to != "user1@example.com" {
+		t.Errorf("unexpected envelope %s -> %s", msg.from, msg.to)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(msg.data))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if parsed.Header.Get("To") != "user1@example.com" || subject != "Напоминание: Встреча" {
+		t.Errorf("unexpected message header %v", parsed.Header)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative message, got %q", parsed.Header.Get("Content-Type"))
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var types []string
+	for {
+		p, err := parts.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		body, _ := io.ReadAll(p)
+		types = append(types, p.Header.Get("Content-Type"))
+		// Время показывается в поясе получателя: 11:30 UTC - это 14:30 по Москве.
+		if !strings.Contains(string(body), "суббота, 17 октября 2026 в 14:30") {
+			t.Errorf("expected localized start time in %s part, got %q", p.Header.Get("Content-Type"), body)
+		}
+	}
+	if len(types) != 2 {
+		t.Errorf("expected text and html parts, got %v", types)
+	}
+
+	err = e.Notify(ctx, rabbitmq.Notification{EventID: "2", UserID: "user2"})
+	if !errors.Is(err, ErrUndeliverable) {
+		t.Errorf("expected ErrUndeliverable for user without email, got %v", err)
+	}
+}
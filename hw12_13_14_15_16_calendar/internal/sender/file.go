@@ -0,0 +1,64 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+)
+
+// FileNotifier дописывает уведомления с текстом для получателя в файл
+// по одному JSON-объекту на строку.
+type FileNotifier struct {
+	recipients Recipients
+	renderer   *Renderer
+
+	mu sync.Mutex
+	w  io.Writer
+	// file - открытый файл; nil при выводе в stdout.
+	file *os.File
+}
+
+// NewFileNotifier открывает файл path на дозапись; пустой path или "-" означает stdout.
+func NewFileNotifier(path string, recipients Recipients, renderer *Renderer) (*FileNotifier, error) {
+	f := &FileNotifier{recipients: recipients, renderer: renderer, w: os.Stdout}
+	if path == "" || path == "-" {
+		return f, nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open notification file: %w", err)
+	}
+	f.w, f.file = file, file
+	return f, nil
+}
+
+func (f *FileNotifier) Notify(_ context.Context, n rabbitmq.Notification) error {
+	p, err := newPayload(f.renderer, ChannelFile, n, f.recipients[n.UserID])
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUndeliverable, err)
+	}
+	line = append(line, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, err := f.w.Write(line); err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+	return nil
+}
+
+func (f *FileNotifier) Close() error {
+	if f.file != nil {
+		return f.file.Close()
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package sender
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+)
+
+func TestFileNotifier_Notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	f, err := NewFileNotifier(path, Recipients{}, newTestRenderer(t))
+	if err != nil {
+		t.Fatalf("NewFileNotifier failed: %v", err)
+	}
+
+	ctx := context.Background()
+	for _, id := range []string{"1", "2"} {
+		if err := f.Notify(ctx, rabbitmq.Notification{EventID: id}); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var n rabbitmq.Notification
+		if err := json.Unmarshal(scanner.Bytes(), &n); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, n.EventID)
+	}
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Errorf("expected one line per notification, got %v", ids)
+	}
+}
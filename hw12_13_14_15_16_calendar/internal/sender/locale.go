@@ -0,0 +1,48 @@
+package sender
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Поддерживаемые локали сообщений.
+const (
+	LocaleEN = "en"
+	LocaleRU = "ru"
+)
+
+var (
+	ruMonths = [...]string{
+		"января", "февраля", "марта", "апреля", "мая", "июня",
+		"июля", "августа", "сентября", "октября", "ноября", "декабря",
+	}
+	ruWeekdays = [...]string{
+		"воскресенье", "понедельник", "вторник", "среда", "четверг", "пятница", "суббота",
+	}
+)
+
+// normalizeLocale приводит тег вида "ru-RU" или "en_US" к языку: "ru", "en".
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(locale)
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+// formatDate возвращает дату с днем недели на языке locale.
+func formatDate(t time.Time, locale string) string {
+	if locale == LocaleRU {
+		return fmt.Sprintf("%s, %d %s %d", ruWeekdays[t.Weekday()], t.Day(), ruMonths[t.Month()-1], t.Year())
+	}
+	return t.Format("Monday, January 2, 2006")
+}
+
+// formatClock возвращает время суток в принятом для locale виде.
+func formatClock(t time.Time, locale string) string {
+	if locale == LocaleRU {
+		return t.Format("15:04")
+	}
+	return t.Format("3:04 PM")
+}
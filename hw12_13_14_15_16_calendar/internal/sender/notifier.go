@@ -0,0 +1,122 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+)
+
+// Каналы доставки. Канал выбирается по напоминанию, затем по настройкам пользователя.
+const (
+	ChannelLog     = "log"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelFile    = "file"
+)
+
+// ErrUndeliverable - уведомление нельзя доставить и повторять бессмысленно:
+// нет адреса получателя, неизвестный канал, получатель отверг запрос.
+var ErrUndeliverable = errors.New("notification is undeliverable")
+
+// Notifier доставляет уведомление пользователю.
+type Notifier interface {
+	Notify(ctx context.Context, n rabbitmq.Notification) error
+}
+
+// Recipient - настройки доставки пользователю.
+type Recipient struct {
+	// Channel - канал по умолчанию для напоминаний без канала.
+	Channel    string
+	Email      string
+	WebhookURL string
+	// Locale - язык сообщений ("ru", "en"); пустой - локаль по умолчанию.
+	Locale string
+	// Location - часовой пояс, в котором показывается время события; nil - пояс по умолчанию.
+	Location *time.Location
+}
+
+// Recipients - настройки доставки по ID пользователя.
+type Recipients map[string]Recipient
+
+// payload - уведомление вместе с текстом для получателя; так его получают вебхуки и файл.
+type payload struct {
+	rabbitmq.Notification
+	Subject string `json:"subject,omitempty"`
+	Text    string `json:"text"`
+}
+
+func newPayload(r *Renderer, channel string, n rabbitmq.Notification, rcpt Recipient) (payload, error) {
+	msg, err := r.Render(channel, n, rcpt)
+	if err != nil {
+		return payload{}, fmt.Errorf("%w: %w", ErrUndeliverable, err)
+	}
+	return payload{Notification: n, Subject: msg.Subject, Text: msg.Text}, nil
+}
+
+// Logger - журнал, в который LogNotifier пишет уведомления; его реализует *logger.Logger.
+type Logger interface {
+	Info(msg string)
+}
+
+var _ Logger = (*logger.Logger)(nil)
+
+// LogNotifier "доставляет" уведомления в лог текстом для получателя.
+type LogNotifier struct {
+	logger     Logger
+	recipients Recipients
+	renderer   *Renderer
+}
+
+func NewLogNotifier(l Logger, recipients Recipients, renderer *Renderer) *LogNotifier {
+	return &LogNotifier{logger: l, recipients: recipients, renderer: renderer}
+}
+
+func (l *LogNotifier) Notify(_ context.Context, n rabbitmq.Notification) error {
+	p, err := newPayload(l.renderer, ChannelLog, n, l.recipients[n.UserID])
+	if err != nil {
+		return err
+	}
+	l.logger.Info(fmt.Sprintf("Notification: EventID=%s, UserID=%s, Subject=%q, Text=%q",
+		n.EventID, n.UserID, p.Subject, p.Text))
+	return nil
+}
+
+// Router выбирает канал доставки: канал напоминания, иначе канал пользователя,
+// иначе канал по умолчанию.
+type Router struct {
+	notifiers      map[string]Notifier
+	recipients     Recipients
+	defaultChannel string
+}
+
+func NewRouter(defaultChannel string, recipients Recipients) *Router {
+	return &Router{
+		notifiers:      make(map[string]Notifier),
+		recipients:     recipients,
+		defaultChannel: defaultChannel,
+	}
+}
+
+// Handle регистрирует notifier для канала channel.
+func (r *Router) Handle(channel string, notifier Notifier) {
+	r.notifiers[channel] = notifier
+}
+
+func (r *Router) Notify(ctx context.Context, n rabbitmq.Notification) error {
+	channel := n.Channel
+	if channel == "" {
+		channel = r.recipients[n.UserID].Channel
+	}
+	if channel == "" {
+		channel = r.defaultChannel
+	}
+	notifier, ok := r.notifiers[channel]
+	if !ok {
+		return fmt.Errorf("%w: channel %q is not configured", ErrUndeliverable, channel)
+	}
+	return notifier.Notify(ctx, n)
+}
@@ -0,0 +1,81 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+)
+
+func TestRouter_Notify(t *testing.T) {
+	email, webhook, log := &MockNotifier{}, &MockNotifier{}, &MockNotifier{}
+	r := NewRouter(ChannelLog, Recipients{"user1": {Channel: ChannelWebhook}})
+	r.Handle(ChannelEmail, email)
+	r.Handle(ChannelWebhook, webhook)
+	r.Handle(ChannelLog, log)
+
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		n    rabbitmq.Notification
+		want *MockNotifier
+	}{
+		{name: "reminder channel", n: rabbitmq.Notification{UserID: "user1", Channel: ChannelEmail}, want: email},
+		{name: "user channel", n: rabbitmq.Notification{UserID: "user1"}, want: webhook},
+		{name: "default channel", n: rabbitmq.Notification{UserID: "user2"}, want: log},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(tt.want.sent)
+			if err := r.Notify(ctx, tt.n); err != nil {
+				t.Fatalf("Notify failed: %v", err)
+			}
+			if len(tt.want.sent) != before+1 {
+				t.Errorf("expected notification to be routed to %s", tt.name)
+			}
+		})
+	}
+
+	err := r.Notify(ctx, rabbitmq.Notification{UserID: "user1", Channel: "sms"})
+	if !errors.Is(err, ErrUndeliverable) {
+		t.Errorf("expected ErrUndeliverable for unknown channel, got %v", err)
+	}
+}
+
+type logRecorder struct {
+	lines []string
+}
+
+func (l *logRecorder) Info(msg string) {
+	l.lines = append(l.lines, msg)
+}
+
+func TestLogNotifier_Notify(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &logRecorder{}
+	l := NewLogNotifier(rec, Recipients{"user1": {Locale: LocaleRU, Location: moscow}}, newTestRenderer(t))
+
+	n := rabbitmq.Notification{
+		EventID:   "1",
+		Title:     "Standup",
+		UserID:    "user1",
+		StartTime: time.Date(2026, time.October, 17, 11, 30, 0, 0, time.UTC),
+	}
+	if err := l.Notify(context.Background(), n); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(rec.lines) != 1 {
+		t.Fatalf("expected one log line, got %v", rec.lines)
+	}
+	for _, want := range []string{"Напоминание: Standup", "«Standup» начнется", "в 14:30 (MSK)"} {
+		if !strings.Contains(rec.lines[0], want) {
+			t.Errorf("expected %q in log line %q", want, rec.lines[0])
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package sender
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+)
+
+// defaultTemplates - шаблоны, используемые, если каталог шаблонов не задан.
+//
+//go:embed templates
+var defaultTemplates embed.FS
+
+// fallbackChannel - шаблоны для каналов без собственных шаблонов.
+const fallbackChannel = "default"
+
+// Части сообщения и суффиксы файлов их шаблонов: <locale>/<channel>.<part>.tmpl.
+const (
+	partSubject = "subject"
+	partText    = "txt"
+	partHTML    = "html"
+)
+
+// Message - уведомление, подготовленное для получателя.
+type Message struct {
+	Subject string
+	Text    string
+	// HTML пуст, если для канала нет HTML-шаблона.
+	HTML string
+}
+
+// messageData - данные, доступные шаблонам.
+type messageData struct {
+	EventID string
+	Title   string
+	UserID  string
+	Channel string
+	// StartTime - начало события в часовом поясе получателя.
+	StartTime time.Time
+	Locale    string
+}
+
+type templateKey struct {
+	locale, channel, part string
+}
+
+// Renderer готовит сообщения по шаблонам text/template и html/template. Шаблоны
+// ищутся по локали получателя и каналу, затем по шаблонам канала default,
+// затем по локали по умолчанию.
+type Renderer struct {
+	text            map[templateKey]*texttemplate.Template
+	html            map[templateKey]*htmltemplate.Template
+	defaultLocale   string
+	defaultLocation *time.Location
+}
+
+// NewRenderer загружает шаблоны из fsys; nil означает встроенные шаблоны.
+// defaultLocale и defaultLocation применяются к получателям без своих настроек.
+func NewRenderer(fsys fs.FS, defaultLocale string, defaultLocation *time.Location) (*Renderer, error) {
+	if fsys == nil {
+		sub, err := fs.Sub(defaultTemplates, "templates")
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	}
+	if defaultLocale == "" {
+		defaultLocale = LocaleEN
+	}
+	if defaultLocation == nil {
+		defaultLocation = time.UTC
+	}
+
+	r := &Renderer{
+		text:            make(map[templateKey]*texttemplate.Template),
+		html:            make(map[templateKey]*htmltemplate.Template),
+		defaultLocale:   normalizeLocale(defaultLocale),
+		defaultLocation: defaultLocation,
+	}
+	files, err := fs.Glob(fsys, "*/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if err := r.load(fsys, file); err != nil {
+			return nil, err
+		}
+	}
+	if !r.has(templateKey{r.defaultLocale, fallbackChannel, partText}) {
+		return nil, fmt.Errorf("no %s/%s.%s.tmpl template", r.defaultLocale, fallbackChannel, partText)
+	}
+	return r, nil
+}
+
+func (r *Renderer) load(fsys fs.FS, file string) error {
+	name := strings.TrimSuffix(path.Base(file), ".tmpl")
+	channel, part, ok := strings.Cut(name, ".")
+	if !ok {
+		return fmt.Errorf("template %s: expected <channel>.<part>.tmpl", file)
+	}
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return err
+	}
+	key := templateKey{locale: normalizeLocale(path.Dir(file)), channel: channel, part: part}
+	funcs := templateFuncs(key.locale)
+
+	switch part {
+	case partSubject, partText:
+		t, err := texttemplate.New(file).Funcs(funcs).Parse(string(data))
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		r.text[key] = t
+	case partHTML:
+		t, err := htmltemplate.New(file).Funcs(funcs).Parse(string(data))
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		r.html[key] = t
+	default:
+		return fmt.Errorf("template %s: unknown part %q", file, part)
+	}
+	return nil
+}
+
+func templateFuncs(locale string) map[string]any {
+	return map[string]any{
+		"date":     func(t time.Time) string { return formatDate(t, locale) },
+		"time":     func(t time.Time) string { return formatClock(t, locale) },
+		"timezone": func(t time.Time) string { return t.Format("MST") },
+	}
+}
+
+func (r *Renderer) has(key templateKey) bool {
+	_, text := r.text[key]
+	_, html := r.html[key]
+	return text || html
+}
+
+// resolve выбирает локаль и канал, для которых есть шаблон part.
+func (r *Renderer) resolve(locale, channel, part string) (templateKey, bool) {
+	for _, l := range []string{locale, r.defaultLocale} {
+		for _, c := range []string{channel, fallbackChannel} {
+			if key := (templateKey{l, c, part}); r.has(key) {
+				return key, true
+			}
+		}
+	}
+	return templateKey{}, false
+}
+
+// Render готовит сообщение канала channel для получателя rcpt.
+// Время события показывается в поясе получателя, иначе в поясе события, иначе в поясе по умолчанию.
+func (r *Renderer) Render(channel string, n rabbitmq.Notification, rcpt Recipient) (Message, error) {
+	locale := normalizeLocale(rcpt.Locale)
+	if locale == "" {
+		locale = r.defaultLocale
+	}
+	data := messageData{
+		EventID:   n.EventID,
+		Title:     n.Title,
+		UserID:    n.UserID,
+		Channel:   channel,
+		StartTime: n.StartTime.In(r.location(n, rcpt)),
+		Locale:    locale,
+	}
+
+	var msg Message
+	var err error
+	if msg.Subject, err = r.renderText(locale, channel, partSubject, data); err != nil {
+		return Message{}, err
+	}
+	if msg.Text, err = r.renderText(locale, channel, partText, data); err != nil {
+		return Message{}, err
+	}
+	// HTML берется только из шаблонов той же пары локали и канала, что и текст,
+	// чтобы части письма не расходились.
+	if key, ok := r.resolve(locale, channel, partText); ok {
+		if t, ok := r.html[templateKey{key.locale, key.channel, partHTML}]; ok {
+			var b bytes.Buffer
+			if err := t.Execute(&b, data); err != nil {
+				return Message{}, fmt.Errorf("failed to render %s: %w", t.Name(), err)
+			}
+			msg.HTML = b.String()
+		}
+	}
+	msg.Subject = strings.TrimSpace(msg.Subject)
+	return msg, nil
+}
+
+func (r *Renderer) location(n rabbitmq.Notification, rcpt Recipient) *time.Location {
+	if rcpt.Location != nil {
+		return rcpt.Location
+	}
+	if n.TimeZone != "" {
+		if loc, err := time.LoadLocation(n.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return r.defaultLocation
+}
+
+func (r *Renderer) renderText(locale, channel, part string, data messageData) (string, error) {
+	key, ok := r.resolve(locale, channel, part)
+	if !ok {
+		if part == partSubject {
+			return "", nil
+		}
+		return "", errors.New("no template for " + channel)
+	}
+	var b bytes.Buffer
+	t := r.text[key]
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", t.Name(), err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,134 @@
+package sender
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+)
+
+func newTestRenderer(t *testing.T) *Renderer {
+	t.Helper()
+
+	r, err := NewRenderer(nil, LocaleEN, time.UTC)
+	if err != nil {
+		t.Fatalf("NewRenderer failed: %v", err)
+	}
+	return r
+}
+
+func TestRenderer_Render(t *testing.T) {
+	r := newTestRenderer(t)
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := rabbitmq.Notification{
+		EventID:   "1",
+		Title:     "Standup <daily>",
+		UserID:    "user1",
+		StartTime: time.Date(2026, time.October, 17, 11, 30, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name        string
+		channel     string
+		rcpt        Recipient
+		timeZone    string
+		subject     string
+		text        string
+		html        string
+		htmlMissing bool
+	}{
+		{
+			name:     "english email in recipient time zone",
+			channel:  ChannelEmail,
+			rcpt:     Recipient{Location: newYork},
+			timeZone: "Europe/Moscow",
+			subject:  "Reminder: Standup <daily>",
+			text:     "Saturday, October 17, 2026 at 7:30 AM (EDT)",
+			html:     "<strong>Standup &lt;daily&gt;</strong>",
+		},
+		{
+			name:     "event time zone for recipient without one",
+			channel:  ChannelEmail,
+			rcpt:     Recipient{Locale: LocaleRU},
+			timeZone: "Europe/Moscow",
+			subject:  "Напоминание: Standup <daily>",
+			text:     "суббота, 17 октября 2026 в 14:30 (MSK)",
+			html:     "<html lang=\"ru\">",
+		},
+		{
+			name:    "russian email",
+			channel: ChannelEmail,
+			rcpt:    Recipient{Locale: "ru-RU"},
+			subject: "Напоминание: Standup <daily>",
+			text:    "суббота, 17 октября 2026 в 11:30 (UTC)",
+			html:    "<html lang=\"ru\">",
+		},
+		{
+			name:        "channel without templates falls back to default",
+			channel:     ChannelWebhook,
+			rcpt:        Recipient{Locale: LocaleRU},
+			subject:     "Напоминание: Standup <daily>",
+			text:        "«Standup <daily>» начнется суббота, 17 октября 2026 в 11:30",
+			htmlMissing: true,
+		},
+		{
+			name:        "unknown locale falls back to default locale",
+			channel:     ChannelFile,
+			rcpt:        Recipient{Locale: "de"},
+			subject:     "Reminder: Standup <daily>",
+			text:        "starts on Saturday, October 17, 2026 at 11:30 AM",
+			htmlMissing: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := n
+			n.TimeZone = tt.timeZone
+			msg, err := r.Render(tt.channel, n, tt.rcpt)
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if msg.Subject != tt.subject {
+				t.Errorf("expected subject %q, got %q", tt.subject, msg.Subject)
+			}
+			if !strings.Contains(msg.Text, tt.text) {
+				t.Errorf("expected text to contain %q, got %q", tt.text, msg.Text)
+			}
+			if tt.htmlMissing != (msg.HTML == "") || !strings.Contains(msg.HTML, tt.html) {
+				t.Errorf("expected html to contain %q, got %q", tt.html, msg.HTML)
+			}
+		})
+	}
+}
+
+func TestNewRenderer_Directory(t *testing.T) {
+	fsys := fstest.MapFS{
+		"en/default.txt.tmpl": {Data: []byte("{{.Title}} at {{time .StartTime}}")},
+		"ru/sms.txt.tmpl":     {Data: []byte("{{.Title}} в {{time .StartTime}}")},
+	}
+	r, err := NewRenderer(fsys, LocaleEN, time.UTC)
+	if err != nil {
+		t.Fatalf("NewRenderer failed: %v", err)
+	}
+
+	n := rabbitmq.Notification{Title: "Call", StartTime: time.Date(2026, time.October, 17, 18, 5, 0, 0, time.UTC)}
+	msg, err := r.Render("sms", n, Recipient{Locale: LocaleRU})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if msg.Text != "Call в 18:05" || msg.Subject != "" {
+		t.Errorf("expected custom russian sms template without subject, got %+v", msg)
+	}
+
+	if _, err := NewRenderer(fstest.MapFS{"ru/default.txt.tmpl": {Data: []byte("x")}}, LocaleEN, nil); err == nil {
+		t.Error("expected error without templates for the default locale")
+	}
+	if _, err := NewRenderer(fstest.MapFS{"en/default.txt.tmpl": {Data: []byte("{{")}}, LocaleEN, nil); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
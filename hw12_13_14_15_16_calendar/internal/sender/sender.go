@@ -0,0 +1,168 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+	"github.com/streadway/amqp"
+)
+
+const (
+	// dedupTTL - сколько помнить ключи идемпотентности доставленных уведомлений.
+	dedupTTL = 24 * time.Hour
+	// statsInterval - период записи счетчиков в лог.
+	statsInterval = time.Minute
+)
+
+// Consumer - очередь уведомлений с ручным подтверждением доставок.
+type Consumer interface {
+	Consume() (<-chan amqp.Delivery, error)
+	Retry(d amqp.Delivery, cause error) (bool, error)
+	DeadLetter(d amqp.Delivery, cause error) error
+}
+
+// Stats - счетчики обработанных сообщений с момента запуска.
+type Stats struct {
+	Received     int64
+	Sent         int64
+	Duplicates   int64
+	Retried      int64
+	DeadLettered int64
+}
+
+// Sender читает уведомления из очереди и доставляет их через Notifier. Сообщение
+// подтверждается только после доставки; неудачная доставка откладывается на повтор,
+// а нечитаемое или недоставляемое (ErrUndeliverable) сразу уходит в очередь недоставленных.
+type Sender struct {
+	consumer Consumer
+	notifier Notifier
+	dedup    *rabbitmq.Deduplicator
+	logger   *logger.Logger
+
+	received     atomic.Int64
+	sent         atomic.Int64
+	duplicates   atomic.Int64
+	retried      atomic.Int64
+	deadLettered atomic.Int64
+}
+
+func New(c Consumer, n Notifier, l *logger.Logger) *Sender {
+	return &Sender{
+		consumer: c,
+		notifier: n,
+		dedup:    rabbitmq.NewDeduplicator(dedupTTL),
+		logger:   l,
+	}
+}
+
+// Run обрабатывает сообщения, пока не отменен ctx или не закрыта очередь.
+func (s *Sender) Run(ctx context.Context) error {
+	msgs, err := s.consumer.Consume()
+	if err != nil {
+		return fmt.Errorf("failed to start consuming: %w", err)
+	}
+
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+	defer s.logStats()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.logStats()
+		case d, ok := <-msgs:
+			if !ok {
+				s.logger.Info("message channel closed")
+				return nil
+			}
+			s.handle(ctx, d)
+		}
+	}
+}
+
+func (s *Sender) Stats() Stats {
+	return Stats{
+		Received:     s.received.Load(),
+		Sent:         s.sent.Load(),
+		Duplicates:   s.duplicates.Load(),
+		Retried:      s.retried.Load(),
+		DeadLettered: s.deadLettered.Load(),
+	}
+}
+
+func (s *Sender) logStats() {
+	st := s.Stats()
+	s.logger.Infof("sender stats: received=%d, sent=%d, duplicates=%d, retried=%d, dead-lettered=%d",
+		st.Received, st.Sent, st.Duplicates, st.Retried, st.DeadLettered)
+}
+
+func (s *Sender) handle(ctx context.Context, d amqp.Delivery) {
+	s.received.Add(1)
+
+	var n rabbitmq.Notification
+	if err := json.Unmarshal(d.Body, &n); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to unmarshal notification %s: %v", d.MessageId, err))
+		s.deadLetter(d, err)
+		return
+	}
+
+	if s.dedup.Seen(n.IdempotencyKey, time.Now()) {
+		s.logger.Info(fmt.Sprintf("skipping duplicate notification %s", n.IdempotencyKey))
+		s.duplicates.Add(1)
+		s.ack(d)
+		return
+	}
+
+	if err := s.notifier.Notify(ctx, n); err != nil {
+		// Ключ забывается, чтобы повтор этого же сообщения не счелся дубликатом.
+		s.dedup.Forget(n.IdempotencyKey)
+		if errors.Is(err, ErrUndeliverable) {
+			s.logger.Error(fmt.Sprintf("notification for event %s is undeliverable: %v", n.EventID, err))
+			s.deadLetter(d, err)
+			return
+		}
+		s.retry(d, n, err)
+		return
+	}
+	s.sent.Add(1)
+	s.ack(d)
+}
+
+func (s *Sender) retry(d amqp.Delivery, n rabbitmq.Notification, cause error) {
+	attempt := rabbitmq.Attempts(d) + 1
+	dead, err := s.consumer.Retry(d, cause)
+	switch {
+	case err != nil:
+		s.logger.Error(fmt.Sprintf("failed to retry notification for event %s: %v", n.EventID, err))
+	case dead:
+		s.deadLettered.Add(1)
+		s.logger.Error(fmt.Sprintf("notification for event %s dead-lettered after %d attempts: %v",
+			n.EventID, attempt, cause))
+	default:
+		s.retried.Add(1)
+		s.logger.Warn(fmt.Sprintf("notification for event %s failed (attempt %d), will retry: %v",
+			n.EventID, attempt, cause))
+	}
+}
+
+func (s *Sender) deadLetter(d amqp.Delivery, cause error) {
+	if err := s.consumer.DeadLetter(d, cause); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to dead-letter notification %s: %v", d.MessageId, err))
+		return
+	}
+	s.deadLettered.Add(1)
+}
+
+func (s *Sender) ack(d amqp.Delivery) {
+	if err := d.Ack(false); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to ack notification %s: %v", d.MessageId, err))
+	}
+}
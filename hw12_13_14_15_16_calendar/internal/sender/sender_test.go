@@ -0,0 +1,148 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/logger"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+	"github.com/streadway/amqp"
+)
+
+// MockAcknowledger запоминает подтверждения доставок.
+type MockAcknowledger struct {
+	acked []uint64
+}
+
+func (m *MockAcknowledger) Ack(tag uint64, _ bool) error {
+	m.acked = append(m.acked, tag)
+	return nil
+}
+
+func (m *MockAcknowledger) Nack(uint64, bool, bool) error { return nil }
+
+func (m *MockAcknowledger) Reject(uint64, bool) error { return nil }
+
+type MockConsumer struct {
+	msgs         chan amqp.Delivery
+	retried      []uint64
+	deadLettered []uint64
+	// deadAfter - начиная с какой попытки Retry отправляет сообщение в очередь недоставленных.
+	deadAfter int
+}
+
+func (m *MockConsumer) Consume() (<-chan amqp.Delivery, error) {
+	return m.msgs, nil
+}
+
+func (m *MockConsumer) Retry(d amqp.Delivery, _ error) (bool, error) {
+	if m.deadAfter > 0 && rabbitmq.Attempts(d)+1 >= m.deadAfter {
+		m.deadLettered = append(m.deadLettered, d.DeliveryTag)
+		return true, nil
+	}
+	m.retried = append(m.retried, d.DeliveryTag)
+	return false, nil
+}
+
+func (m *MockConsumer) DeadLetter(d amqp.Delivery, _ error) error {
+	m.deadLettered = append(m.deadLettered, d.DeliveryTag)
+	return nil
+}
+
+type MockNotifier struct {
+	failures int
+	sent     []rabbitmq.Notification
+}
+
+func (m *MockNotifier) Notify(_ context.Context, n rabbitmq.Notification) error {
+	if m.failures > 0 {
+		m.failures--
+		return errors.New("smtp is down")
+	}
+	m.sent = append(m.sent, n)
+	return nil
+}
+
+func delivery(t *testing.T, ack amqp.Acknowledger, tag uint64, n rabbitmq.Notification, attempts int64) amqp.Delivery {
+	t.Helper()
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return amqp.Delivery{
+		Acknowledger: ack,
+		DeliveryTag:  tag,
+		MessageId:    n.IdempotencyKey,
+		Headers:      amqp.Table{"x-attempts": attempts},
+		Body:         body,
+	}
+}
+
+func TestSender_Run(t *testing.T) {
+	ack := &MockAcknowledger{}
+	mc := &MockConsumer{msgs: make(chan amqp.Delivery, 10), deadAfter: 3}
+	mn := &MockNotifier{failures: 2}
+	s := New(mc, mn, logger.New("ERROR"))
+
+	n := rabbitmq.Notification{EventID: "1", IdempotencyKey: "key-1"}
+	// Две неудачи: первая уходит на повтор, вторая (уже третья попытка) - в недоставленные.
+	mc.msgs <- delivery(t, ack, 1, n, 0)
+	mc.msgs <- delivery(t, ack, 2, n, 2)
+	// Успешная доставка и ее дубликат.
+	mc.msgs <- delivery(t, ack, 3, n, 0)
+	mc.msgs <- delivery(t, ack, 4, n, 0)
+	// Нечитаемое сообщение.
+	mc.msgs <- amqp.Delivery{Acknowledger: ack, DeliveryTag: 5, Body: []byte("not json")}
+	close(mc.msgs)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Run(ctx); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(mn.sent) != 1 || mn.sent[0].EventID != "1" {
+		t.Errorf("expected a single delivered notification, got %+v", mn.sent)
+	}
+	if len(mc.retried) != 1 || mc.retried[0] != 1 {
+		t.Errorf("expected delivery 1 to be retried, got %v", mc.retried)
+	}
+	if len(mc.deadLettered) != 2 || mc.deadLettered[0] != 2 || mc.deadLettered[1] != 5 {
+		t.Errorf("expected deliveries 2 and 5 to be dead-lettered, got %v", mc.deadLettered)
+	}
+	if len(ack.acked) != 2 || ack.acked[0] != 3 || ack.acked[1] != 4 {
+		t.Errorf("expected delivered message and its duplicate to be acked, got %v", ack.acked)
+	}
+
+	want := Stats{Received: 5, Sent: 1, Duplicates: 1, Retried: 1, DeadLettered: 2}
+	if got := s.Stats(); got != want {
+		t.Errorf("expected stats %+v, got %+v", want, got)
+	}
+}
+
+// undeliverableNotifier отвергает все уведомления как недоставляемые.
+type undeliverableNotifier struct{}
+
+func (undeliverableNotifier) Notify(context.Context, rabbitmq.Notification) error {
+	return ErrUndeliverable
+}
+
+func TestSender_Undeliverable(t *testing.T) {
+	mc := &MockConsumer{msgs: make(chan amqp.Delivery, 1)}
+	s := New(mc, undeliverableNotifier{}, logger.New("ERROR"))
+
+	mc.msgs <- delivery(t, &MockAcknowledger{}, 1, rabbitmq.Notification{EventID: "1"}, 0)
+	close(mc.msgs)
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(mc.retried) != 0 || len(mc.deadLettered) != 1 {
+		t.Errorf("expected undeliverable notification to skip retries, got retried %v, dead %v",
+			mc.retried, mc.deadLettered)
+	}
+}
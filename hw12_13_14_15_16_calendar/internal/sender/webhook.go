@@ -0,0 +1,117 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+)
+
+// defaultWebhookTimeout - таймаут запроса, если WebhookConfig.Timeout не задан.
+const defaultWebhookTimeout = 10 * time.Second
+
+// Заголовки запроса вебхука: время отправки в секундах Unix и подпись "sha256=<hex>"
+// HMAC-SHA256 от строки "<timestamp>.<тело>".
+const (
+	TimestampHeader = "X-Calendar-Timestamp"
+	SignatureHeader = "X-Calendar-Signature"
+)
+
+// WebhookConfig - параметры исходящих вебхуков.
+type WebhookConfig struct {
+	// URL - адрес по умолчанию для пользователей без своего WebhookURL.
+	URL string
+	// Secret - ключ подписи запросов.
+	Secret  string
+	Timeout time.Duration
+}
+
+// WebhookNotifier отправляет уведомление с текстом для получателя POST-запросом
+// с JSON и подписью HMAC.
+type WebhookNotifier struct {
+	conf       WebhookConfig
+	recipients Recipients
+	renderer   *Renderer
+	client     *http.Client
+}
+
+func NewWebhookNotifier(conf WebhookConfig, recipients Recipients, renderer *Renderer) *WebhookNotifier {
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &WebhookNotifier{
+		conf:       conf,
+		recipients: recipients,
+		renderer:   renderer,
+		client:     &http.Client{Timeout: timeout},
+	}
+}
+
+// Sign возвращает значение SignatureHeader для тела body, отправленного в момент timestamp.
+// Получатель вебхука проверяет подпись, вычисляя ее тем же способом.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n rabbitmq.Notification) error {
+	rcpt := w.recipients[n.UserID]
+	url := rcpt.WebhookURL
+	if url == "" {
+		url = w.conf.URL
+	}
+	if url == "" {
+		return fmt.Errorf("%w: no webhook for user %s", ErrUndeliverable, n.UserID)
+	}
+
+	p, err := newPayload(w.renderer, ChannelWebhook, n, rcpt)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUndeliverable, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUndeliverable, err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(w.conf.Secret, timestamp, body))
+	if n.IdempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", n.IdempotencyKey)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= 500:
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	default:
+		// Остальные ответы 4xx не изменятся от повтора.
+		return fmt.Errorf("%w: webhook responded with %s", ErrUndeliverable, resp.Status)
+	}
+}
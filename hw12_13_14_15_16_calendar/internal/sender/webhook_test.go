@@ -0,0 +1,58 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/rabbitmq"
+)
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	const secret = "s3cret"
+	status := http.StatusNoContent
+	var got struct {
+		rabbitmq.Notification
+		Text string `json:"text"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != Sign(secret, r.Header.Get(TimestampHeader), body) {
+			t.Errorf("invalid signature %q", r.Header.Get(SignatureHeader))
+		}
+		if r.Header.Get("Idempotency-Key") != "key-1" {
+			t.Errorf("expected idempotency key key-1, got %q", r.Header.Get("Idempotency-Key"))
+		}
+		_ = json.Unmarshal(body, &got)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	w := NewWebhookNotifier(WebhookConfig{Secret: secret}, Recipients{"user1": {WebhookURL: srv.URL}}, newTestRenderer(t))
+	ctx := context.Background()
+	n := rabbitmq.Notification{EventID: "1", Title: "Standup", UserID: "user1", IdempotencyKey: "key-1"}
+
+	if err := w.Notify(ctx, n); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if got.EventID != "1" || !strings.Contains(got.Text, "Standup") {
+		t.Errorf("expected event 1 with rendered text in webhook body, got %+v", got)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := w.Notify(ctx, n); err == nil || errors.Is(err, ErrUndeliverable) {
+		t.Errorf("expected retryable error on 503, got %v", err)
+	}
+	status = http.StatusGone
+	if err := w.Notify(ctx, n); !errors.Is(err, ErrUndeliverable) {
+		t.Errorf("expected ErrUndeliverable on 410, got %v", err)
+	}
+	if err := w.Notify(ctx, rabbitmq.Notification{UserID: "user2"}); !errors.Is(err, ErrUndeliverable) {
+		t.Errorf("expected ErrUndeliverable without webhook url, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/api/gen"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+var attendeeRoles = map[storage.AttendeeRole]gen.AttendeeRole{
+	storage.RoleRequired: gen.AttendeeRole_ATTENDEE_ROLE_REQUIRED,
+	storage.RoleOptional: gen.AttendeeRole_ATTENDEE_ROLE_OPTIONAL,
+}
+
+var rsvpStatuses = map[storage.RSVPStatus]gen.RSVPStatus{
+	storage.StatusNeedsAction: gen.RSVPStatus_RSVP_STATUS_NEEDS_ACTION,
+	storage.StatusAccepted:    gen.RSVPStatus_RSVP_STATUS_ACCEPTED,
+	storage.StatusDeclined:    gen.RSVPStatus_RSVP_STATUS_DECLINED,
+	storage.StatusTentative:   gen.RSVPStatus_RSVP_STATUS_TENTATIVE,
+}
+
+func (s *Server) InviteAttendees(
+	ctx context.Context, req *gen.InviteAttendeesRequest,
+) (*gen.InviteAttendeesResponse, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	ev, err := s.app.InviteAttendees(ctx, userID, req.GetId(), fromPBAttendees(req.GetAttendees()))
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.InviteAttendeesResponse{Event: toPB(ev)}, nil
+}
+
+func (s *Server) RespondToInvitation(
+	ctx context.Context, req *gen.RespondToInvitationRequest,
+) (*gen.RespondToInvitationResponse, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	// Неизвестный или неуказанный статус превращается в пустой, и хранилище его отклонит.
+	var status storage.RSVPStatus
+	for st, pb := range rsvpStatuses {
+		if pb == req.GetStatus() {
+			status = st
+		}
+	}
+	ev, err := s.app.RespondToInvitation(ctx, userID, req.GetId(), status)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.RespondToInvitationResponse{Event: toPB(ev)}, nil
+}
+
+func toPBAttendees(list []storage.Attendee) []*gen.Attendee {
+	res := make([]*gen.Attendee, 0, len(list))
+	for _, a := range list {
+		res = append(res, &gen.Attendee{UserId: a.UserID, Role: attendeeRoles[a.Role], Status: rsvpStatuses[a.Status]})
+	}
+	return res
+}
+
+// fromPBAttendees переносит только участников и роли: ответы задают сами участники.
+func fromPBAttendees(list []*gen.Attendee) []storage.Attendee {
+	var res []storage.Attendee
+	for _, pb := range list {
+		a := storage.Attendee{UserID: pb.GetUserId()}
+		for role, r := range attendeeRoles {
+			if r == pb.GetRole() {
+				a.Role = role
+			}
+		}
+		res = append(res, a)
+	}
+	return res
+}
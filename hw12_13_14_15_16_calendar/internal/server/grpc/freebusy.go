@@ -0,0 +1,69 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/api/gen"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Server) FreeBusy(ctx context.Context, req *gen.FreeBusyRequest) (*gen.FreeBusyResponse, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	busy, err := s.app.FreeBusy(ctx, userID, req.GetUserIds(), req.GetFrom().AsTime(), req.GetTo().AsTime())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	res := &gen.FreeBusyResponse{Busy: make(map[string]*gen.BusyIntervals, len(busy))}
+	for id, list := range busy {
+		res.Busy[id] = &gen.BusyIntervals{Intervals: toPBIntervals(list)}
+	}
+	return res, nil
+}
+
+func (s *Server) FindSlots(ctx context.Context, req *gen.FindSlotsRequest) (*gen.FindSlotsResponse, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	loc, err := s.app.Location(ctx, userID, req.GetTz())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	query := storage.SlotQuery{
+		From:      req.GetFrom().AsTime(),
+		To:        req.GetTo().AsTime(),
+		Duration:  req.GetDuration().AsDuration(),
+		Count:     max(int(req.GetCount()), 1),
+		WorkStart: durationOr(req.GetWorkStart(), storage.DefaultWorkStart),
+		WorkEnd:   durationOr(req.GetWorkEnd(), storage.DefaultWorkEnd),
+		Weekends:  req.GetWeekends(),
+		Location:  loc,
+	}
+	slots, err := s.app.FindSlots(ctx, userID, req.GetUserIds(), query)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.FindSlotsResponse{Slots: toPBIntervals(slots)}, nil
+}
+
+// durationOr возвращает d или def, если d не задана.
+func durationOr(d *durationpb.Duration, def time.Duration) time.Duration {
+	if d == nil {
+		return def
+	}
+	return d.AsDuration()
+}
+
+func toPBIntervals(list []storage.Interval) []*gen.Interval {
+	res := make([]*gen.Interval, 0, len(list))
+	for _, iv := range list {
+		res = append(res, &gen.Interval{Start: timestamppb.New(iv.Start), End: timestamppb.New(iv.End)})
+	}
+	return res
+}
@@ -0,0 +1,28 @@
+package grpcserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/api/gen"
+)
+
+// userIDHeader - HTTP-заголовок с ID пользователя, который шлюз передает в метаданные userIDMetadataKey.
+const userIDHeader = "X-User-ID"
+
+// Gateway возвращает REST-шлюз, построенный по аннотациям google.api.http из EventService.proto.
+// Запросы обрабатываются этим же сервером напрямую, без сетевого вызова gRPC.
+func (s *Server) Gateway(ctx context.Context) (http.Handler, error) {
+	mux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+		if strings.EqualFold(key, userIDHeader) {
+			return userIDMetadataKey, true
+		}
+		return runtime.DefaultHeaderMatcher(key)
+	}))
+	if err := gen.RegisterCalendarServiceHandlerServer(ctx, mux, s); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
@@ -0,0 +1,58 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+func TestGateway(t *testing.T) {
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	mockApp := &mockApplication{events: map[string]storage.Event{
+		"1": {ID: "1", Title: "Standup", StartTime: start, EndTime: start.Add(time.Hour)},
+	}}
+	srv := &Server{app: mockApp, logger: &mockLogger{}}
+	gw, err := srv.Gateway(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(path, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if userID != "" {
+			req.Header.Set(userIDHeader, userID)
+		}
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("/api/v1/events/1", "user1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Event struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"event"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Event.ID != "1" || resp.Event.Title != "Standup" {
+		t.Errorf("unexpected event: %+v", resp.Event)
+	}
+
+	if w := do("/api/v1/events/1", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 without %s, got %d", userIDHeader, w.Code)
+	}
+	if w := do("/api/v1/events/404", "user1"); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
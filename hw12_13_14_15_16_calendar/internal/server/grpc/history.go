@@ -0,0 +1,48 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/api/gen"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var auditActions = map[storage.AuditAction]gen.AuditAction{
+	storage.AuditCreated:  gen.AuditAction_AUDIT_ACTION_CREATED,
+	storage.AuditUpdated:  gen.AuditAction_AUDIT_ACTION_UPDATED,
+	storage.AuditDeleted:  gen.AuditAction_AUDIT_ACTION_DELETED,
+	storage.AuditRestored: gen.AuditAction_AUDIT_ACTION_RESTORED,
+}
+
+func (s *Server) GetEventHistory(
+	ctx context.Context, req *gen.GetEventHistoryRequest,
+) (*gen.GetEventHistoryResponse, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	entries, err := s.app.EventHistory(ctx, userID, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.GetEventHistoryResponse{Entries: toPBAuditEntries(entries)}, nil
+}
+
+func toPBAuditEntries(entries []storage.AuditEntry) []*gen.AuditEntry {
+	res := make([]*gen.AuditEntry, 0, len(entries))
+	for _, e := range entries {
+		pb := &gen.AuditEntry{
+			Id:      e.ID,
+			Actor:   e.Actor,
+			Action:  auditActions[e.Action],
+			At:      timestamppb.New(e.At),
+			Changes: make([]*gen.FieldChange, 0, len(e.Changes)),
+		}
+		for _, c := range e.Changes {
+			pb.Changes = append(pb.Changes, &gen.FieldChange{Field: c.Field, Old: c.Old, New: c.New})
+		}
+		res = append(res, pb)
+	}
+	return res
+}
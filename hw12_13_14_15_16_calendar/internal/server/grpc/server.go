@@ -1,15 +1,20 @@
-//go:build grpcapi
-
 package grpcserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"time"
 
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/api/gen"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/app"
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -21,15 +26,34 @@ type Logger interface {
 
 // Application - интерфейс доменной логики (совпадает с HTTP слоем).
 type Application interface {
-	CreateEvent(ctx context.Context, event storage.Event) error
-	UpdateEvent(ctx context.Context, id string, event storage.Event) error
-	DeleteEvent(ctx context.Context, id string) error
-	GetEventByID(ctx context.Context, id string) (*storage.Event, error)
-	ListEventsForDay(ctx context.Context, date time.Time) ([]storage.Event, error)
-	ListEventsForWeek(ctx context.Context, startDate time.Time) ([]storage.Event, error)
-	ListEventsForMonth(ctx context.Context, startDate time.Time) ([]storage.Event, error)
+	CreateEvent(ctx context.Context, userID string, event storage.Event) (storage.Event, []string, error)
+	UpdateEvent(ctx context.Context, userID, id string, event storage.Event) ([]string, error)
+	DeleteEvent(ctx context.Context, userID, id string, version int64) error
+	ListTrash(ctx context.Context, userID string) ([]storage.Event, error)
+	RestoreEvent(ctx context.Context, userID, id string) (storage.Event, []string, error)
+	EventHistory(ctx context.Context, userID, id string) ([]storage.AuditEntry, error)
+	GetEventByID(ctx context.Context, userID, id string) (*storage.Event, error)
+	InviteAttendees(ctx context.Context, userID, id string, attendees []storage.Attendee) (storage.Event, error)
+	RespondToInvitation(ctx context.Context, userID, id string, status storage.RSVPStatus) (storage.Event, error)
+	ListEventsForDay(ctx context.Context, userID string, date time.Time) ([]storage.Event, error)
+	ListEventsForWeek(ctx context.Context, userID string, startDate time.Time) ([]storage.Event, error)
+	ListEventsForMonth(ctx context.Context, userID string, startDate time.Time) ([]storage.Event, error)
+	ListEvents(ctx context.Context, userID string, filter storage.EventFilter) (storage.EventPage, error)
+	SearchEvents(ctx context.Context, userID string, query storage.SearchQuery) (storage.SearchPage, error)
+	WatchEvents(ctx context.Context, userID string, filter app.WatchFilter) (*app.Subscription, error)
+	Location(ctx context.Context, userID, tz string) (*time.Location, error)
+	WeekStart(ctx context.Context, userID, weekStart string) (time.Weekday, error)
+	GetSettings(ctx context.Context, userID string) (storage.UserSettings, error)
+	UpdateSettings(ctx context.Context, userID string, settings storage.UserSettings) (storage.UserSettings, error)
+	FreeBusy(
+		ctx context.Context, userID string, userIDs []string, from, to time.Time,
+	) (map[string][]storage.Interval, error)
+	FindSlots(ctx context.Context, userID string, userIDs []string, query storage.SlotQuery) ([]storage.Interval, error)
 }
 
+// userIDMetadataKey - ключ метаданных запроса с ID пользователя.
+const userIDMetadataKey = "x-user-id"
+
 type Server struct {
 	gen.UnimplementedCalendarServiceServer
 	logger Logger
@@ -53,97 +77,432 @@ func New(logger Logger, app Application, host, port string) (*Server, error) {
 	return s, nil
 }
 
+// Start обслуживает запросы и блокируется до остановки сервера.
 func (s *Server) Start() error {
+	s.logger.Info("grpc server listening on " + s.lis.Addr().String())
 	return s.srv.Serve(s.lis)
 }
 
-func (s *Server) Stop() {
-	s.srv.GracefulStop()
+// Stop дожидается завершения активных вызовов; по истечении ctx обрывает их.
+func (s *Server) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.srv.Stop()
+		return ctx.Err()
+	}
 }
 
 // ===== RPC handlers =====
 
 func (s *Server) CreateEvent(ctx context.Context, req *gen.CreateEventRequest) (*gen.CreateEventResponse, error) {
-	ev := fromPB(req.GetEvent())
-	return &gen.CreateEventResponse{}, s.app.CreateEvent(ctx, ev)
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	ev, err := fromPB(req.GetEvent())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	// ID всегда генерирует хранилище: клиентский ID мог бы совпасть с чужим событием.
+	ev.ID = ""
+	created, conflicts, err := s.app.CreateEvent(ctx, userID, ev)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.CreateEventResponse{Event: toPB(created), Conflicts: conflicts}, nil
 }
 
 func (s *Server) UpdateEvent(ctx context.Context, req *gen.UpdateEventRequest) (*gen.UpdateEventResponse, error) {
-	ev := fromPB(req.GetEvent())
-	return &gen.UpdateEventResponse{}, s.app.UpdateEvent(ctx, req.GetId(), ev)
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	ev, err := fromPB(req.GetEvent())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	ev.Version = req.GetVersion()
+	conflicts, err := s.app.UpdateEvent(ctx, userID, req.GetId(), ev)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.UpdateEventResponse{Conflicts: conflicts}, nil
 }
 
 func (s *Server) DeleteEvent(ctx context.Context, req *gen.DeleteEventRequest) (*gen.DeleteEventResponse, error) {
-	return &gen.DeleteEventResponse{}, s.app.DeleteEvent(ctx, req.GetId())
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.DeleteEventResponse{}, statusError(s.app.DeleteEvent(ctx, userID, req.GetId(), req.GetVersion()))
 }
 
 func (s *Server) GetEventByID(ctx context.Context, req *gen.GetEventByIDRequest) (*gen.GetEventByIDResponse, error) {
-	ev, err := s.app.GetEventByID(ctx, req.GetId())
+	userID, err := callerID(ctx)
 	if err != nil {
-		return nil, err
+		return nil, statusError(err)
+	}
+	ev, err := s.app.GetEventByID(ctx, userID, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
 	}
 	return &gen.GetEventByIDResponse{Event: toPB(*ev)}, nil
 }
 
 func (s *Server) ListEventsForDay(ctx context.Context, req *gen.ListForDayRequest) (*gen.ListEventsResponse, error) {
-	date := req.GetDate().AsTime()
-	list, err := s.app.ListEventsForDay(ctx, date)
+	userID, err := callerID(ctx)
 	if err != nil {
-		return nil, err
+		return nil, statusError(err)
+	}
+	loc, err := s.app.Location(ctx, userID, req.GetTz())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	date := req.GetDate().AsTime().In(loc)
+	list, err := s.app.ListEventsForDay(ctx, userID, date)
+	if err != nil {
+		return nil, statusError(err)
 	}
 	return &gen.ListEventsResponse{Events: toPBList(list)}, nil
 }
 
 func (s *Server) ListEventsForWeek(ctx context.Context, req *gen.ListForWeekRequest) (*gen.ListEventsResponse, error) {
-	start := req.GetStart().AsTime()
-	list, err := s.app.ListEventsForWeek(ctx, start)
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	loc, err := s.app.Location(ctx, userID, req.GetTz())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	start, err := s.weekStart(ctx, userID, req, loc)
 	if err != nil {
 		return nil, err
 	}
+	list, err := s.app.ListEventsForWeek(ctx, userID, start)
+	if err != nil {
+		return nil, statusError(err)
+	}
 	return &gen.ListEventsResponse{Events: toPBList(list)}, nil
 }
 
+// weekStart возвращает начало запрошенной недели в поясе loc.
+func (s *Server) weekStart(
+	ctx context.Context, userID string, req *gen.ListForWeekRequest, loc *time.Location,
+) (time.Time, error) {
+	if req.GetIsoWeek() != "" {
+		start, err := storage.ParseISOWeek(req.GetIsoWeek(), loc)
+		if err != nil {
+			return time.Time{}, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return start, nil
+	}
+	first, err := s.app.WeekStart(ctx, userID, req.GetWeekStart())
+	if err != nil {
+		return time.Time{}, statusError(err)
+	}
+	return storage.StartOfWeek(req.GetStart().AsTime().In(loc), first), nil
+}
+
 func (s *Server) ListEventsForMonth(ctx context.Context, req *gen.ListForMonthRequest) (*gen.ListEventsResponse, error) {
-	start := req.GetStart().AsTime()
-	list, err := s.app.ListEventsForMonth(ctx, start)
+	userID, err := callerID(ctx)
 	if err != nil {
-		return nil, err
+		return nil, statusError(err)
+	}
+	loc, err := s.app.Location(ctx, userID, req.GetTz())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	start := req.GetStart().AsTime().In(loc)
+	list, err := s.app.ListEventsForMonth(ctx, userID, start)
+	if err != nil {
+		return nil, statusError(err)
 	}
 	return &gen.ListEventsResponse{Events: toPBList(list)}, nil
 }
 
+func (s *Server) ListEvents(ctx context.Context, req *gen.ListEventsRequest) (*gen.ListEventsPageResponse, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	filter := storage.EventFilter{
+		UserID: req.GetUserId(),
+		Query:  req.GetQuery(),
+		Limit:  int(req.GetLimit()),
+		Cursor: req.GetCursor(),
+	}
+	if req.GetFrom() != nil {
+		filter.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		filter.To = req.GetTo().AsTime()
+	}
+	page, err := s.app.ListEvents(ctx, userID, filter)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.ListEventsPageResponse{Events: toPBList(page.Events), NextCursor: page.NextCursor}, nil
+}
+
+func (s *Server) SearchEvents(ctx context.Context, req *gen.SearchEventsRequest) (*gen.SearchEventsResponse, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	page, err := s.app.SearchEvents(ctx, userID, storage.SearchQuery{
+		Query:  req.GetQuery(),
+		Limit:  int(req.GetLimit()),
+		Cursor: req.GetCursor(),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp := &gen.SearchEventsResponse{NextCursor: page.NextCursor}
+	for _, r := range page.Results {
+		resp.Results = append(resp.Results, &gen.SearchResult{Event: toPB(r.Event), Rank: r.Rank})
+	}
+	return resp, nil
+}
+
+func (s *Server) GetSettings(ctx context.Context, _ *gen.GetSettingsRequest) (*gen.UserSettings, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	settings, err := s.app.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toPBSettings(settings), nil
+}
+
+func (s *Server) UpdateSettings(ctx context.Context, req *gen.UpdateSettingsRequest) (*gen.UserSettings, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	settings, err := s.app.UpdateSettings(ctx, userID, storage.UserSettings{
+		TimeZone:  req.GetSettings().GetTimeZone(),
+		Locale:    req.GetSettings().GetLocale(),
+		WeekStart: req.GetSettings().GetWeekStart(),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toPBSettings(settings), nil
+}
+
+func toPBSettings(s storage.UserSettings) *gen.UserSettings {
+	return &gen.UserSettings{TimeZone: s.TimeZone, Locale: s.Locale, WeekStart: s.WeekStart}
+}
+
+// WatchEvents передает клиенту изменения событий до отмены вызова или отключения подписки.
+func (s *Server) WatchEvents(req *gen.WatchEventsRequest, stream gen.CalendarService_WatchEventsServer) error {
+	ctx := stream.Context()
+	userID, err := callerID(ctx)
+	if err != nil {
+		return statusError(err)
+	}
+	filter := app.WatchFilter{}
+	if req.GetFrom() != nil {
+		filter.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		filter.To = req.GetTo().AsTime()
+	}
+	sub, err := s.app.WatchEvents(ctx, userID, filter)
+	if err != nil {
+		return statusError(err)
+	}
+	defer sub.Close()
+	// Заголовки сразу после подписки: клиент знает, что изменения уже не будут пропущены.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return statusError(ctx.Err())
+		case change, ok := <-sub.C:
+			if !ok {
+				return statusError(sub.Err())
+			}
+			if err := stream.Send(toPBChange(change)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// callerID извлекает ID пользователя из метаданных запроса.
+func callerID(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(userIDMetadataKey); len(values) > 0 && values[0] != "" {
+		return values[0], nil
+	}
+	return "", status.Errorf(codes.Unauthenticated, "missing %s metadata", userIDMetadataKey)
+}
+
+// statusError преобразует ошибки приложения и хранилища в статусы gRPC.
+// Ошибки, уже несущие статус, возвращаются без изменений.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var code codes.Code
+	switch {
+	case errors.Is(err, storage.ErrEventNotFound):
+		code = codes.NotFound
+	case errors.Is(err, storage.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, storage.ErrDateBusy), errors.Is(err, storage.ErrAlreadyExists):
+		code = codes.AlreadyExists
+	case errors.Is(err, storage.ErrVersionConflict):
+		code = codes.FailedPrecondition
+	case errors.Is(err, storage.ErrInvalidEvent), errors.Is(err, storage.ErrInvalidCursor),
+		errors.Is(err, storage.ErrInvalidQuery), errors.Is(err, storage.ErrInvalidTimeZone),
+		errors.Is(err, storage.ErrInvalidWeekStart), errors.Is(err, storage.ErrInvalidRange):
+		code = codes.InvalidArgument
+	case errors.Is(err, app.ErrSlowConsumer):
+		code = codes.ResourceExhausted
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	default:
+		code = codes.Internal
+	}
+	return status.Error(code, err.Error())
+}
+
 // ===== mapping =====
 
+var transparencies = map[storage.Transparency]gen.Transparency{
+	storage.TransparencyBusy: gen.Transparency_TRANSPARENCY_BUSY,
+	storage.TransparencyFree: gen.Transparency_TRANSPARENCY_FREE,
+}
+
 func toPB(e storage.Event) *gen.Event {
-	var notify *timestamppb.Timestamp
-	if e.NotifyAt != nil {
-		notify = timestamppb.New(*e.NotifyAt)
+	var rrule string
+	if e.RRule != nil {
+		rrule = e.RRule.String()
+	}
+	exDates := make([]*timestamppb.Timestamp, 0, len(e.ExDates))
+	for _, d := range e.ExDates {
+		exDates = append(exDates, timestamppb.New(d))
 	}
-	return &gen.Event{
+	pb := &gen.Event{
 		Id:          e.ID,
 		Title:       e.Title,
 		StartTime:   timestamppb.New(e.StartTime),
 		EndTime:     timestamppb.New(e.EndTime),
 		Description: e.Description,
 		UserId:      e.UserID,
-		NotifyAt:    notify,
+		TimeZone:    e.TimeZone,
+		AllDay:      e.AllDay,
+		Rrule:       rrule,
+		ExDates:     exDates,
+		Version:     e.Version,
+		Reminders:   toPBReminders(e.Reminders),
+		Attendees:   toPBAttendees(e.Attendees),
+
+		Transparency: transparencies[e.Transparency],
+	}
+	if e.AllDay {
+		pb.StartDate, pb.EndDate = e.Dates()
+	}
+	if e.DeletedAt != nil {
+		pb.DeletedAt = timestamppb.New(*e.DeletedAt)
 	}
+	return pb
 }
 
-func fromPB(e *gen.Event) storage.Event {
-	var notify *time.Time
-	if e.GetNotifyAt() != nil {
-		t := e.GetNotifyAt().AsTime()
-		notify = &t
+func toPBReminders(list []storage.Reminder) []*gen.Reminder {
+	res := make([]*gen.Reminder, 0, len(list))
+	for _, r := range list {
+		pb := &gen.Reminder{Id: r.ID, Offset: durationpb.New(r.Offset), Channel: r.Channel}
+		if r.SentAt != nil {
+			pb.SentAt = timestamppb.New(*r.SentAt)
+		}
+		res = append(res, pb)
 	}
-	return storage.Event{
+	return res
+}
+
+func fromPB(e *gen.Event) (storage.Event, error) {
+	ev := storage.Event{
 		ID:          e.GetId(),
 		Title:       e.GetTitle(),
 		StartTime:   e.GetStartTime().AsTime(),
 		EndTime:     e.GetEndTime().AsTime(),
 		Description: e.GetDescription(),
 		UserID:      e.GetUserId(),
-		NotifyAt:    notify,
+		TimeZone:    e.GetTimeZone(),
+		AllDay:      e.GetAllDay(),
+		Attendees:   fromPBAttendees(e.GetAttendees()),
+	}
+	if t := e.GetTransparency(); t != gen.Transparency_TRANSPARENCY_UNSPECIFIED {
+		// Неизвестное значение превращается в пустое и считалось бы BUSY, поэтому отклоняется здесь.
+		for st, pb := range transparencies {
+			if pb == t {
+				ev.Transparency = st
+			}
+		}
+		if ev.Transparency == "" {
+			return storage.Event{}, fmt.Errorf("%w: unknown transparency %v", storage.ErrInvalidEvent, t)
+		}
+	}
+	if ev.AllDay {
+		var err error
+		if ev, err = ev.WithDates(e.GetStartDate(), e.GetEndDate()); err != nil {
+			return storage.Event{}, fmt.Errorf("%w: %w", storage.ErrInvalidEvent, err)
+		}
+	}
+	for _, r := range e.GetReminders() {
+		ev.Reminders = append(ev.Reminders, storage.Reminder{
+			ID:      r.GetId(),
+			Offset:  r.GetOffset().AsDuration(),
+			Channel: r.GetChannel(),
+		})
+	}
+	for _, d := range e.GetExDates() {
+		ev.ExDates = append(ev.ExDates, d.AsTime())
+	}
+	if e.GetRrule() != "" {
+		rule, err := storage.ParseRRule(e.GetRrule())
+		if err != nil {
+			return storage.Event{}, fmt.Errorf("%w: %w", storage.ErrInvalidEvent, err)
+		}
+		ev.RRule = rule
+	}
+	return ev, nil
+}
+
+var changeTypes = map[app.ChangeType]gen.ChangeType{
+	app.ChangeCreated: gen.ChangeType_CHANGE_TYPE_CREATED,
+	app.ChangeUpdated: gen.ChangeType_CHANGE_TYPE_UPDATED,
+	app.ChangeDeleted: gen.ChangeType_CHANGE_TYPE_DELETED,
+}
+
+func toPBChange(c app.Change) *gen.EventChange {
+	return &gen.EventChange{
+		Type:  changeTypes[c.Type],
+		Event: toPB(c.Event),
+		At:    timestamppb.New(c.At),
 	}
 }
 
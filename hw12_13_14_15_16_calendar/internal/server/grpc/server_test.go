@@ -1,19 +1,26 @@
-//go:build grpcapi
-
 package grpcserver
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net"
 	"testing"
 	"time"
 
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/api/gen"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/logger"
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage/memory"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -23,26 +30,35 @@ var lis *bufconn.Listener
 
 type mockApplication struct {
 	events map[string]storage.Event
-	err    error
+	// days и weeks - начала суток и недель, запрошенных через ListEventsForDay и ListEventsForWeek.
+	days     []time.Time
+	weeks    []time.Time
+	settings storage.UserSettings
+	err      error
+	// live - настоящее приложение, источник ленты изменений для WatchEvents.
+	live *app.App
 }
 
-func (m *mockApplication) CreateEvent(ctx context.Context, event storage.Event) error {
+func (m *mockApplication) CreateEvent(ctx context.Context, userID string, event storage.Event) (storage.Event, []string, error) {
 	if m.err != nil {
-		return m.err
+		return storage.Event{}, nil, m.err
+	}
+	if event.ID == "" {
+		event.ID = storage.NewID()
 	}
 	m.events[event.ID] = event
-	return nil
+	return event, nil, nil
 }
 
-func (m *mockApplication) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
+func (m *mockApplication) UpdateEvent(ctx context.Context, userID, id string, event storage.Event) ([]string, error) {
 	if m.err != nil {
-		return m.err
+		return nil, m.err
 	}
 	m.events[id] = event
-	return nil
+	return nil, nil
 }
 
-func (m *mockApplication) DeleteEvent(ctx context.Context, id string) error {
+func (m *mockApplication) DeleteEvent(ctx context.Context, userID, id string, version int64) error {
 	if m.err != nil {
 		return m.err
 	}
@@ -50,7 +66,19 @@ func (m *mockApplication) DeleteEvent(ctx context.Context, id string) error {
 	return nil
 }
 
-func (m *mockApplication) GetEventByID(ctx context.Context, id string) (*storage.Event, error) {
+func (m *mockApplication) ListTrash(_ context.Context, _ string) ([]storage.Event, error) {
+	return nil, m.err
+}
+
+func (m *mockApplication) RestoreEvent(_ context.Context, _, _ string) (storage.Event, []string, error) {
+	return storage.Event{}, nil, storage.ErrEventNotFound
+}
+
+func (m *mockApplication) EventHistory(_ context.Context, _, _ string) ([]storage.AuditEntry, error) {
+	return nil, m.err
+}
+
+func (m *mockApplication) GetEventByID(ctx context.Context, userID, id string) (*storage.Event, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -61,21 +89,88 @@ func (m *mockApplication) GetEventByID(ctx context.Context, id string) (*storage
 	return &ev, nil
 }
 
-func (m *mockApplication) ListEventsForDay(ctx context.Context, date time.Time) ([]storage.Event, error) {
+func (m *mockApplication) InviteAttendees(ctx context.Context, userID, id string, _ []storage.Attendee) (storage.Event, error) {
+	ev, err := m.GetEventByID(ctx, userID, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	return *ev, nil
+}
+
+func (m *mockApplication) RespondToInvitation(_ context.Context, _, _ string, _ storage.RSVPStatus) (storage.Event, error) {
+	return storage.Event{}, storage.ErrForbidden
+}
+
+func (m *mockApplication) FreeBusy(_ context.Context, _ string, _ []string, _, _ time.Time) (map[string][]storage.Interval, error) {
+	return nil, m.err
+}
+
+func (m *mockApplication) FindSlots(_ context.Context, _ string, _ []string, _ storage.SlotQuery) ([]storage.Interval, error) {
+	return nil, m.err
+}
+
+func (m *mockApplication) ListEventsForDay(ctx context.Context, userID string, date time.Time) ([]storage.Event, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
+	start, _ := storage.DayBounds(date)
+	m.days = append(m.days, start)
 	return nil, nil
 }
 
-func (m *mockApplication) ListEventsForWeek(ctx context.Context, startDate time.Time) ([]storage.Event, error) {
+func (m *mockApplication) ListEventsForWeek(ctx context.Context, userID string, startDate time.Time) ([]storage.Event, error) {
+	m.weeks = append(m.weeks, startDate)
 	return nil, nil
 }
 
-func (m *mockApplication) ListEventsForMonth(ctx context.Context, startDate time.Time) ([]storage.Event, error) {
+func (m *mockApplication) ListEventsForMonth(ctx context.Context, userID string, startDate time.Time) ([]storage.Event, error) {
 	return nil, nil
 }
 
+func (m *mockApplication) ListEvents(ctx context.Context, userID string, filter storage.EventFilter) (storage.EventPage, error) {
+	return storage.EventPage{}, nil
+}
+
+func (m *mockApplication) SearchEvents(ctx context.Context, userID string, query storage.SearchQuery) (storage.SearchPage, error) {
+	return storage.SearchPage{}, nil
+}
+
+func (m *mockApplication) WatchEvents(ctx context.Context, userID string, filter app.WatchFilter) (*app.Subscription, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.live.WatchEvents(ctx, userID, filter)
+}
+
+func (m *mockApplication) Location(ctx context.Context, userID, tz string) (*time.Location, error) {
+	if tz == "" {
+		tz = m.settings.TimeZone
+	}
+	return storage.LoadLocation(tz)
+}
+
+func (m *mockApplication) WeekStart(ctx context.Context, userID, weekStart string) (time.Weekday, error) {
+	if weekStart != "" {
+		return storage.ParseWeekday(weekStart)
+	}
+	return m.settings.FirstDayOfWeek(), nil
+}
+
+func (m *mockApplication) GetSettings(ctx context.Context, userID string) (storage.UserSettings, error) {
+	return m.settings, m.err
+}
+
+func (m *mockApplication) UpdateSettings(
+	ctx context.Context, userID string, settings storage.UserSettings,
+) (storage.UserSettings, error) {
+	if err := settings.Validate(); err != nil {
+		return storage.UserSettings{}, err
+	}
+	settings.UserID = userID
+	m.settings = settings
+	return settings, nil
+}
+
 type mockLogger struct{}
 
 func (m *mockLogger) Info(msg string)  {}
@@ -94,7 +189,7 @@ func dialer(ctx context.Context, s *grpc.Server) func(context.Context, string) (
 }
 
 func TestGRPCServer_CreateEvent(t *testing.T) {
-	ctx := context.Background()
+	ctx := metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user1")
 	mockApp := &mockApplication{events: make(map[string]storage.Event)}
 
 	s := grpc.NewServer()
@@ -113,14 +208,13 @@ func TestGRPCServer_CreateEvent(t *testing.T) {
 	client := gen.NewCalendarServiceClient(conn)
 
 	event := &gen.Event{
-		Id:        "1",
 		Title:     "GRPC Event",
 		StartTime: timestamppb.Now(),
 		EndTime:   timestamppb.Now(),
 		UserId:    "user1",
 	}
 
-	_, err = client.CreateEvent(ctx, &gen.CreateEventRequest{Event: event})
+	resp, err := client.CreateEvent(ctx, &gen.CreateEventRequest{Event: event})
 	if err != nil {
 		t.Fatalf("CreateEvent failed: %v", err)
 	}
@@ -128,12 +222,15 @@ func TestGRPCServer_CreateEvent(t *testing.T) {
 	if len(mockApp.events) != 1 {
 		t.Errorf("expected 1 event, got %d", len(mockApp.events))
 	}
+	if _, ok := mockApp.events[resp.GetEvent().GetId()]; !ok {
+		t.Errorf("expected created event with generated id, got %v", resp.GetEvent())
+	}
 
 	s.Stop()
 }
 
 func TestGRPCServer_GetEventByID(t *testing.T) {
-	ctx := context.Background()
+	ctx := metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user1")
 	id := "123"
 	mockApp := &mockApplication{
 		events: map[string]storage.Event{
@@ -169,7 +266,7 @@ func TestGRPCServer_GetEventByID(t *testing.T) {
 }
 
 func TestGRPCServer_DeleteEvent(t *testing.T) {
-	ctx := context.Background()
+	ctx := metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user1")
 	id := "123"
 	mockApp := &mockApplication{
 		events: map[string]storage.Event{
@@ -203,3 +300,514 @@ func TestGRPCServer_DeleteEvent(t *testing.T) {
 
 	s.Stop()
 }
+
+func TestGRPCServer_ErrorCodes(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{storage.ErrEventNotFound, codes.NotFound},
+		{storage.ErrForbidden, codes.PermissionDenied},
+		{storage.ErrDateBusy, codes.AlreadyExists},
+		{storage.ErrAlreadyExists, codes.AlreadyExists},
+		{storage.ErrVersionConflict, codes.FailedPrecondition},
+		{fmt.Errorf("wrapped: %w", storage.ErrInvalidEvent), codes.InvalidArgument},
+		{storage.ErrInvalidCursor, codes.InvalidArgument},
+		{errors.New("database is down"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user1")
+			s := grpc.NewServer()
+			gen.RegisterCalendarServiceServer(s, &Server{
+				app:    &mockApplication{events: map[string]storage.Event{}, err: tt.err},
+				logger: &mockLogger{},
+				srv:    s,
+			})
+			defer s.Stop()
+
+			conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(dialer(ctx, s)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+			if err != nil {
+				t.Fatalf("Failed to dial bufnet: %v", err)
+			}
+			defer conn.Close()
+
+			_, err = gen.NewCalendarServiceClient(conn).GetEventByID(ctx, &gen.GetEventByIDRequest{Id: "1"})
+			if got := status.Code(err); got != tt.code {
+				t.Errorf("expected code %s, got %s (%v)", tt.code, got, err)
+			}
+		})
+	}
+}
+
+func TestGRPCServer_WatchEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user1"))
+	defer cancel()
+	live := app.New(logger.New("error"), memorystorage.New(), app.Options{})
+
+	s := grpc.NewServer()
+	gen.RegisterCalendarServiceServer(s, &Server{
+		app:    &mockApplication{events: map[string]storage.Event{}, live: live},
+		logger: &mockLogger{},
+		srv:    s,
+	})
+	defer s.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(dialer(ctx, s)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+
+	stream, err := gen.NewCalendarServiceClient(conn).WatchEvents(ctx, &gen.WatchEventsRequest{})
+	if err != nil {
+		t.Fatalf("WatchEvents failed: %v", err)
+	}
+	// Сервер отправляет заголовки после оформления подписки.
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("Header failed: %v", err)
+	}
+
+	start := time.Now()
+	created, _, err := live.CreateEvent(context.Background(), "user1", storage.Event{
+		Title: "Watched", StartTime: start, EndTime: start.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+
+	change, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %v", err)
+	}
+	if change.GetType() != gen.ChangeType_CHANGE_TYPE_CREATED || change.GetEvent().GetId() != created.ID {
+		t.Errorf("expected created change of %s, got %v", created.ID, change)
+	}
+}
+
+func TestGRPCServer_TimeZone(t *testing.T) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user1")
+	mockApp := &mockApplication{events: map[string]storage.Event{}}
+	s := grpc.NewServer()
+	gen.RegisterCalendarServiceServer(s, &Server{app: mockApp, logger: &mockLogger{}, srv: s})
+	defer s.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(dialer(ctx, s)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+	client := gen.NewCalendarServiceClient(conn)
+
+	// 22:30 UTC 16 октября - уже 17 октября в Москве.
+	moment := timestamppb.New(time.Date(2026, 10, 16, 22, 30, 0, 0, time.UTC))
+	if _, err := client.ListEventsForDay(ctx, &gen.ListForDayRequest{Date: moment, Tz: "Europe/Moscow"}); err != nil {
+		t.Fatalf("ListEventsForDay failed: %v", err)
+	}
+	if _, err := client.ListEventsForDay(ctx, &gen.ListForDayRequest{Date: moment}); err != nil {
+		t.Fatalf("ListEventsForDay failed: %v", err)
+	}
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	want := []time.Time{
+		time.Date(2026, 10, 17, 0, 0, 0, 0, moscow),
+		time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+	}
+	if len(mockApp.days) != 2 || !mockApp.days[0].Equal(want[0]) || !mockApp.days[1].Equal(want[1]) {
+		t.Errorf("expected days %v, got %v", want, mockApp.days)
+	}
+
+	_, err = client.ListEventsForDay(ctx, &gen.ListForDayRequest{Date: moment, Tz: "Mars/Olympus"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for unknown time zone, got %v", err)
+	}
+
+	settings, err := client.UpdateSettings(ctx, &gen.UpdateSettingsRequest{
+		Settings: &gen.UserSettings{TimeZone: "Europe/Moscow"},
+	})
+	if err != nil || settings.GetTimeZone() != "Europe/Moscow" {
+		t.Fatalf("UpdateSettings failed: %v, %v", settings, err)
+	}
+	if got, err := client.GetSettings(ctx, &gen.GetSettingsRequest{}); err != nil || got.GetTimeZone() != "Europe/Moscow" {
+		t.Errorf("expected saved time zone, got %v, %v", got, err)
+	}
+	if _, err := client.ListEventsForDay(ctx, &gen.ListForDayRequest{Date: moment}); err != nil {
+		t.Fatalf("ListEventsForDay failed: %v", err)
+	}
+	if last := mockApp.days[len(mockApp.days)-1]; !last.Equal(want[0]) {
+		t.Errorf("expected day from user settings %v, got %v", want[0], last)
+	}
+}
+
+func TestGRPCServer_ListEventsForWeek(t *testing.T) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user1")
+	mockApp := &mockApplication{events: map[string]storage.Event{}}
+	s := grpc.NewServer()
+	gen.RegisterCalendarServiceServer(s, &Server{app: mockApp, logger: &mockLogger{}, srv: s})
+	defer s.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(dialer(ctx, s)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+	client := gen.NewCalendarServiceClient(conn)
+
+	// Суббота, 17 октября 2026.
+	saturday := timestamppb.New(time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC))
+	requests := []*gen.ListForWeekRequest{
+		{Start: saturday},
+		{Start: saturday, WeekStart: "sunday"},
+		{IsoWeek: "2026-W42", Tz: "Europe/Moscow"},
+	}
+	for _, req := range requests {
+		if _, err := client.ListEventsForWeek(ctx, req); err != nil {
+			t.Fatalf("ListEventsForWeek(%v) failed: %v", req, err)
+		}
+	}
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	want := []time.Time{
+		time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 12, 0, 0, 0, 0, moscow),
+	}
+	if len(mockApp.weeks) != len(want) {
+		t.Fatalf("expected %d weeks, got %v", len(want), mockApp.weeks)
+	}
+	for i := range want {
+		if !mockApp.weeks[i].Equal(want[i]) {
+			t.Errorf("request %d: expected week start %v, got %v", i, want[i], mockApp.weeks[i])
+		}
+	}
+
+	for _, req := range []*gen.ListForWeekRequest{{IsoWeek: "2026-W54"}, {Start: saturday, WeekStart: "someday"}} {
+		if _, err := client.ListEventsForWeek(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected InvalidArgument for %v, got %v", req, err)
+		}
+	}
+}
+
+func TestGRPCServer_AllDay(t *testing.T) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user1")
+	s := grpc.NewServer()
+	gen.RegisterCalendarServiceServer(s, &Server{
+		app: app.New(logger.New("error"), memorystorage.New(), app.Options{}), logger: &mockLogger{}, srv: s,
+	})
+	defer s.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(dialer(ctx, s)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+	client := gen.NewCalendarServiceClient(conn)
+
+	resp, err := client.CreateEvent(ctx, &gen.CreateEventRequest{Event: &gen.Event{
+		Title: "Conference", TimeZone: "Europe/Moscow", AllDay: true, StartDate: "2026-10-16", EndDate: "2026-10-19",
+	}})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	created := resp.GetEvent()
+	if !created.GetAllDay() || created.GetStartDate() != "2026-10-16" || created.GetEndDate() != "2026-10-19" {
+		t.Errorf("expected dates 2026-10-16..2026-10-19, got %v", created)
+	}
+
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	list, err := client.ListEventsForDay(ctx, &gen.ListForDayRequest{
+		Date: timestamppb.New(time.Date(2026, 10, 17, 12, 0, 0, 0, moscow)), Tz: "Europe/Moscow",
+	})
+	if err != nil {
+		t.Fatalf("ListEventsForDay failed: %v", err)
+	}
+	if len(list.GetEvents()) != 1 || list.GetEvents()[0].GetId() != created.GetId() {
+		t.Errorf("expected the conference on its second day, got %v", list.GetEvents())
+	}
+
+	_, err = client.CreateEvent(ctx, &gen.CreateEventRequest{Event: &gen.Event{
+		Title: "Broken", AllDay: true, StartDate: "17.10.2026",
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for malformed date, got %v", err)
+	}
+}
+
+func TestGRPCServer_Attendees(t *testing.T) {
+	ctx := context.Background()
+	as := func(userID string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, userIDMetadataKey, userID)
+	}
+	s := grpc.NewServer()
+	gen.RegisterCalendarServiceServer(s, &Server{
+		app: app.New(logger.New("error"), memorystorage.New(), app.Options{}), logger: &mockLogger{}, srv: s,
+	})
+	defer s.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(dialer(ctx, s)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+	client := gen.NewCalendarServiceClient(conn)
+
+	start := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	created, err := client.CreateEvent(as("owner"), &gen.CreateEventRequest{Event: &gen.Event{
+		Title: "Sync", StartTime: timestamppb.New(start), EndTime: timestamppb.New(start.Add(time.Hour)),
+	}})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	id := created.GetEvent().GetId()
+
+	invited, err := client.InviteAttendees(as("owner"), &gen.InviteAttendeesRequest{Id: id, Attendees: []*gen.Attendee{
+		{UserId: "alice", Role: gen.AttendeeRole_ATTENDEE_ROLE_OPTIONAL},
+	}})
+	if err != nil {
+		t.Fatalf("InviteAttendees failed: %v", err)
+	}
+	attendees := invited.GetEvent().GetAttendees()
+	if len(attendees) != 1 || attendees[0].GetRole() != gen.AttendeeRole_ATTENDEE_ROLE_OPTIONAL ||
+		attendees[0].GetStatus() != gen.RSVPStatus_RSVP_STATUS_NEEDS_ACTION {
+		t.Errorf("expected alice as optional attendee without response, got %v", attendees)
+	}
+
+	_, err = client.RespondToInvitation(as("alice"), &gen.RespondToInvitationRequest{Id: id})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument without status, got %v", err)
+	}
+	resp, err := client.RespondToInvitation(as("alice"), &gen.RespondToInvitationRequest{
+		Id: id, Status: gen.RSVPStatus_RSVP_STATUS_ACCEPTED,
+	})
+	if err != nil || resp.GetEvent().GetAttendees()[0].GetStatus() != gen.RSVPStatus_RSVP_STATUS_ACCEPTED {
+		t.Fatalf("RespondToInvitation failed: %v, %v", resp, err)
+	}
+	list, err := client.ListEventsForDay(as("alice"), &gen.ListForDayRequest{Date: timestamppb.New(start)})
+	if err != nil || len(list.GetEvents()) != 1 || list.GetEvents()[0].GetId() != id {
+		t.Errorf("expected accepted event in alice's listing, got %v, %v", list, err)
+	}
+
+	_, err = client.RespondToInvitation(as("bob"), &gen.RespondToInvitationRequest{
+		Id: id, Status: gen.RSVPStatus_RSVP_STATUS_DECLINED,
+	})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied without invitation, got %v", err)
+	}
+}
+
+func TestGRPCServer_FreeBusy(t *testing.T) {
+	ctx := context.Background()
+	as := func(userID string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, userIDMetadataKey, userID)
+	}
+	s := grpc.NewServer()
+	gen.RegisterCalendarServiceServer(s, &Server{
+		app: app.New(logger.New("error"), memorystorage.New(), app.Options{}), logger: &mockLogger{}, srv: s,
+	})
+	defer s.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(dialer(ctx, s)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+	client := gen.NewCalendarServiceClient(conn)
+
+	// 2026-10-19 - понедельник.
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	review, err := client.CreateEvent(as("bob"), &gen.CreateEventRequest{Event: &gen.Event{
+		Title:     "Review",
+		StartTime: timestamppb.New(day.Add(9 * time.Hour)),
+		EndTime:   timestamppb.New(day.Add(10 * time.Hour)),
+	}})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	// Занятость bob видна alice, потому что она приглашена на его встречу.
+	_, err = client.InviteAttendees(as("bob"), &gen.InviteAttendeesRequest{
+		Id: review.GetEvent().GetId(), Attendees: []*gen.Attendee{{UserId: "alice"}},
+	})
+	if err != nil {
+		t.Fatalf("InviteAttendees failed: %v", err)
+	}
+
+	fb, err := client.FreeBusy(as("alice"), &gen.FreeBusyRequest{
+		UserIds: []string{"alice", "bob"}, From: timestamppb.New(day), To: timestamppb.New(day.AddDate(0, 0, 1)),
+	})
+	if err != nil {
+		t.Fatalf("FreeBusy failed: %v", err)
+	}
+	if len(fb.GetBusy()["alice"].GetIntervals()) != 0 || len(fb.GetBusy()["bob"].GetIntervals()) != 1 {
+		t.Errorf("unexpected free/busy: %v", fb)
+	}
+
+	slots, err := client.FindSlots(as("alice"), &gen.FindSlotsRequest{
+		UserIds:  []string{"alice", "bob"},
+		From:     timestamppb.New(day),
+		To:       timestamppb.New(day.AddDate(0, 0, 1)),
+		Duration: durationpb.New(30 * time.Minute),
+		Count:    2,
+	})
+	if err != nil {
+		t.Fatalf("FindSlots failed: %v", err)
+	}
+	got := slots.GetSlots()
+	if len(got) != 2 || !got[0].GetStart().AsTime().Equal(day.Add(10*time.Hour)) ||
+		!got[1].GetStart().AsTime().Equal(day.Add(10*time.Hour+30*time.Minute)) {
+		t.Errorf("expected slots from 10:00 after bob's review, got %v", got)
+	}
+
+	_, err = client.FindSlots(as("alice"), &gen.FindSlotsRequest{
+		From: timestamppb.New(day), To: timestamppb.New(day.AddDate(0, 0, 1)),
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument without duration, got %v", err)
+	}
+
+	_, err = client.FreeBusy(as("carol"), &gen.FreeBusyRequest{
+		UserIds: []string{"bob"}, From: timestamppb.New(day), To: timestamppb.New(day.AddDate(0, 0, 1)),
+	})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied for a stranger's free/busy, got %v", err)
+	}
+}
+
+func TestGRPCServer_OverlapPolicy(t *testing.T) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user1")
+	s := grpc.NewServer()
+	gen.RegisterCalendarServiceServer(s, &Server{
+		app:    app.New(logger.New("error"), memorystorage.New(), app.Options{Overlap: storage.OverlapWarn}),
+		logger: &mockLogger{}, srv: s,
+	})
+	defer s.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(dialer(ctx, s)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+	client := gen.NewCalendarServiceClient(conn)
+
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	first, err := client.CreateEvent(ctx, &gen.CreateEventRequest{Event: &gen.Event{
+		Title: "Meeting", StartTime: timestamppb.New(start), EndTime: timestamppb.New(start.Add(time.Hour)),
+	}})
+	if err != nil || first.GetEvent().GetTransparency() != gen.Transparency_TRANSPARENCY_BUSY {
+		t.Fatalf("expected busy event to be created, got %v, %v", first, err)
+	}
+	second, err := client.CreateEvent(ctx, &gen.CreateEventRequest{Event: &gen.Event{
+		Title: "Overlap", StartTime: timestamppb.New(start.Add(30 * time.Minute)), EndTime: timestamppb.New(start.Add(2 * time.Hour)),
+	}})
+	if err != nil || len(second.GetConflicts()) != 1 || second.GetConflicts()[0] != first.GetEvent().GetId() {
+		t.Errorf("expected conflict with the first event, got %v, %v", second, err)
+	}
+
+	updated, err := client.UpdateEvent(ctx, &gen.UpdateEventRequest{Id: second.GetEvent().GetId(), Event: &gen.Event{
+		Title: "Overlap", StartTime: timestamppb.New(start.Add(30 * time.Minute)), EndTime: timestamppb.New(start.Add(2 * time.Hour)),
+		Transparency: gen.Transparency_TRANSPARENCY_FREE,
+	}})
+	if err != nil || len(updated.GetConflicts()) != 0 {
+		t.Errorf("expected free event without conflicts, got %v, %v", updated, err)
+	}
+
+	_, err = client.CreateEvent(ctx, &gen.CreateEventRequest{Event: &gen.Event{
+		Title: "Bad", StartTime: timestamppb.New(start), EndTime: timestamppb.New(start.Add(time.Hour)), Transparency: 7,
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for unknown transparency, got %v", err)
+	}
+}
+
+func TestGRPCServer_Trash(t *testing.T) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user1")
+	s := grpc.NewServer()
+	gen.RegisterCalendarServiceServer(s, &Server{
+		app:    app.New(logger.New("error"), memorystorage.New(), app.Options{}),
+		logger: &mockLogger{}, srv: s,
+	})
+	defer s.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(dialer(ctx, s)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+	client := gen.NewCalendarServiceClient(conn)
+
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	created, err := client.CreateEvent(ctx, &gen.CreateEventRequest{Event: &gen.Event{
+		Title: "Meeting", StartTime: timestamppb.New(start), EndTime: timestamppb.New(start.Add(time.Hour)),
+	}})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	id := created.GetEvent().GetId()
+	if _, err := client.DeleteEvent(ctx, &gen.DeleteEventRequest{Id: id}); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+
+	trash, err := client.ListTrash(ctx, &gen.ListTrashRequest{})
+	if err != nil || len(trash.GetEvents()) != 1 || trash.GetEvents()[0].GetDeletedAt() == nil {
+		t.Fatalf("expected the deleted event in trash, got %v, %v", trash, err)
+	}
+	other := metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user2")
+	if _, err := client.RestoreEvent(other, &gen.RestoreEventRequest{Id: id}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for another user's trash, got %v", err)
+	}
+
+	restored, err := client.RestoreEvent(ctx, &gen.RestoreEventRequest{Id: id})
+	if err != nil || restored.GetEvent().GetId() != id || restored.GetEvent().GetDeletedAt() != nil {
+		t.Fatalf("expected the event to be restored, got %v, %v", restored, err)
+	}
+	if trash, _ := client.ListTrash(ctx, &gen.ListTrashRequest{}); len(trash.GetEvents()) != 0 {
+		t.Errorf("expected empty trash after restore, got %v", trash.GetEvents())
+	}
+	if _, err := client.RestoreEvent(ctx, &gen.RestoreEventRequest{Id: id}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for an event outside trash, got %v", err)
+	}
+}
+
+func TestGRPCServer_EventHistory(t *testing.T) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user1")
+	s := grpc.NewServer()
+	gen.RegisterCalendarServiceServer(s, &Server{
+		app:    app.New(logger.New("error"), memorystorage.New(), app.Options{}),
+		logger: &mockLogger{}, srv: s,
+	})
+	defer s.Stop()
+
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(dialer(ctx, s)), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+	client := gen.NewCalendarServiceClient(conn)
+
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	created, err := client.CreateEvent(ctx, &gen.CreateEventRequest{Event: &gen.Event{
+		Title: "Sync", StartTime: timestamppb.New(start), EndTime: timestamppb.New(start.Add(time.Hour)),
+	}})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	id := created.GetEvent().GetId()
+	if _, err := client.DeleteEvent(ctx, &gen.DeleteEventRequest{Id: id}); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+
+	history, err := client.GetEventHistory(ctx, &gen.GetEventHistoryRequest{Id: id})
+	if err != nil || len(history.GetEntries()) != 2 {
+		t.Fatalf("expected create and delete entries, got %v, %v", history, err)
+	}
+	created0, deleted := history.GetEntries()[0], history.GetEntries()[1]
+	if created0.GetAction() != gen.AuditAction_AUDIT_ACTION_CREATED || created0.GetActor() != "user1" {
+		t.Errorf("expected creation by user1, got %v", created0)
+	}
+	if deleted.GetAction() != gen.AuditAction_AUDIT_ACTION_DELETED || deleted.GetChanges()[0].GetOld() != "Sync" {
+		t.Errorf("expected deletion with the old title, got %v", deleted)
+	}
+
+	other := metadata.AppendToOutgoingContext(context.Background(), userIDMetadataKey, "user2")
+	if _, err := client.GetEventHistory(other, &gen.GetEventHistoryRequest{Id: id}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for another user, got %v", err)
+	}
+}
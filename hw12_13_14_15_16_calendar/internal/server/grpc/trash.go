@@ -0,0 +1,31 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/api/gen"
+)
+
+func (s *Server) ListTrash(ctx context.Context, _ *gen.ListTrashRequest) (*gen.ListTrashResponse, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	list, err := s.app.ListTrash(ctx, userID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.ListTrashResponse{Events: toPBList(list)}, nil
+}
+
+func (s *Server) RestoreEvent(ctx context.Context, req *gen.RestoreEventRequest) (*gen.RestoreEventResponse, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	restored, conflicts, err := s.app.RestoreEvent(ctx, userID, req.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &gen.RestoreEventResponse{Event: toPB(restored), Conflicts: conflicts}, nil
+}
@@ -0,0 +1,78 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// attendeeDTO - участник события: role - "required" (по умолчанию) или "optional";
+// status - ответ на приглашение, заполняет сервер.
+type attendeeDTO struct {
+	UserID string `json:"userId"`
+	Role   string `json:"role,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+// inviteDTO - тело запроса приглашения участников.
+type inviteDTO struct {
+	Attendees []attendeeDTO `json:"attendees"`
+}
+
+// responses - ответы на приглашение по последнему сегменту пути /api/events/{id}/{action}.
+var responses = map[string]storage.RSVPStatus{
+	"accept":    storage.StatusAccepted,
+	"decline":   storage.StatusDeclined,
+	"tentative": storage.StatusTentative,
+}
+
+func toAttendeeDTOs(list []storage.Attendee) []attendeeDTO {
+	var res []attendeeDTO
+	for _, a := range list {
+		res = append(res, attendeeDTO{UserID: a.UserID, Role: string(a.Role), Status: string(a.Status)})
+	}
+	return res
+}
+
+func fromAttendeeDTOs(list []attendeeDTO) []storage.Attendee {
+	var res []storage.Attendee
+	for _, a := range list {
+		res = append(res, storage.Attendee{UserID: a.UserID, Role: storage.AttendeeRole(a.Role)})
+	}
+	return res
+}
+
+// handleInvitation обрабатывает POST /api/events/{id}/invite (владелец приглашает участников)
+// и POST /api/events/{id}/accept, decline, tentative (участник отвечает на приглашение).
+// В ответ отдается событие в сохраненном виде.
+func (s *Server) handleInvitation(w http.ResponseWriter, r *http.Request, userID, id, action string) {
+	status, isResponse := responses[action]
+	if action != "invite" && !isResponse {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var ev storage.Event
+	var err error
+	if isResponse {
+		ev, err = s.app.RespondToInvitation(r.Context(), userID, id, status)
+	} else {
+		var d inviteDTO
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil || len(d.Attendees) == 0 {
+			http.Error(w, "invalid json: attendees are required", http.StatusBadRequest)
+			return
+		}
+		ev, err = s.app.InviteAttendees(r.Context(), userID, id, fromAttendeeDTOs(d.Attendees))
+	}
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	w.Header().Set("ETag", etag(ev.Version))
+	_ = json.NewEncoder(w).Encode(toDTO(ev))
+}
@@ -0,0 +1,135 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// freeBusyDTO - тело запроса занятости; пустой userIds - сам вызывающий пользователь.
+type freeBusyDTO struct {
+	UserIDs []string  `json:"userIds"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+}
+
+// slotsDTO - тело запроса поиска слотов: duration - длительность Go ("30m", "1h"),
+// count - число слотов (по умолчанию 1), workStart и workEnd - рабочие часы "HH:MM"
+// в часовом поясе запроса (по умолчанию 09:00-18:00), weekends - искать и в выходные.
+type slotsDTO struct {
+	freeBusyDTO
+	Duration  string `json:"duration"`
+	Count     int    `json:"count,omitempty"`
+	WorkStart string `json:"workStart,omitempty"`
+	WorkEnd   string `json:"workEnd,omitempty"`
+	Weekends  bool   `json:"weekends,omitempty"`
+}
+
+type intervalDTO struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func toIntervalDTOs(list []storage.Interval, loc *time.Location) []intervalDTO {
+	res := make([]intervalDTO, 0, len(list))
+	for _, iv := range list {
+		res = append(res, intervalDTO{Start: iv.Start.In(loc), End: iv.End.In(loc)})
+	}
+	return res
+}
+
+// parseClock разбирает время суток "HH:MM" как смещение от полуночи; "24:00" - конец суток,
+// пустое значение - def.
+func parseClock(v string, def time.Duration) (time.Duration, error) {
+	if v == "" {
+		return def, nil
+	}
+	var h, m int
+	if _, err := fmt.Sscanf(v, "%d:%d", &h, &m); err != nil || h < 0 || h > 24 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("%w: invalid time of day %q, want HH:MM", storage.ErrInvalidRange, v)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// handleFreeBusy обрабатывает POST /api/freebusy: объединенные интервалы занятости
+// каждого пользователя в часовом поясе запроса (параметр tz).
+func (s *Server) handleFreeBusy(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var d freeBusyDTO
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	loc, ok := s.location(w, r, userID)
+	if !ok {
+		return
+	}
+	busy, err := s.app.FreeBusy(r.Context(), userID, d.UserIDs, d.From, d.To)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	res := make(map[string][]intervalDTO, len(busy))
+	for id, list := range busy {
+		res[id] = toIntervalDTOs(list, loc)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]map[string][]intervalDTO{"busy": res})
+}
+
+// handleFindSlots обрабатывает POST /api/freebusy/slots: самые ранние слоты, в которые
+// свободны все пользователи; рабочие часы считаются в часовом поясе запроса (параметр tz).
+func (s *Server) handleFindSlots(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	d := slotsDTO{Count: 1}
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	duration, err := time.ParseDuration(d.Duration)
+	if err != nil {
+		http.Error(w, "invalid duration", http.StatusBadRequest)
+		return
+	}
+	loc, ok := s.location(w, r, userID)
+	if !ok {
+		return
+	}
+	query := storage.SlotQuery{
+		From:     d.From,
+		To:       d.To,
+		Duration: duration,
+		Count:    d.Count,
+		Weekends: d.Weekends,
+		Location: loc,
+	}
+	if query.WorkStart, err = parseClock(d.WorkStart, storage.DefaultWorkStart); err == nil {
+		query.WorkEnd, err = parseClock(d.WorkEnd, storage.DefaultWorkEnd)
+	}
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	slots, err := s.app.FindSlots(r.Context(), userID, d.UserIDs, query)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string][]intervalDTO{"slots": toIntervalDTOs(slots, loc)})
+}
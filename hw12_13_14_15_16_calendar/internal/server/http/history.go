@@ -0,0 +1,59 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// auditEntryDTO - запись журнала изменений события; action - created, updated, deleted или restored.
+type auditEntryDTO struct {
+	ID      string           `json:"id"`
+	Actor   string           `json:"actor"`
+	Action  string           `json:"action"`
+	At      time.Time        `json:"at"`
+	Changes []fieldChangeDTO `json:"changes"`
+}
+
+// fieldChangeDTO - изменение поля события; отсутствующее old или new - поле не было задано.
+type fieldChangeDTO struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// handleHistory обрабатывает GET /api/events/{id}/history: журнал изменений события,
+// начиная с самых ранних записей.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request, userID, id string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries, err := s.app.EventHistory(r.Context(), userID, id)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string][]auditEntryDTO{"entries": toAuditEntryDTOs(entries)})
+}
+
+func toAuditEntryDTOs(entries []storage.AuditEntry) []auditEntryDTO {
+	res := make([]auditEntryDTO, 0, len(entries))
+	for _, e := range entries {
+		d := auditEntryDTO{
+			ID:      e.ID,
+			Actor:   e.Actor,
+			Action:  string(e.Action),
+			At:      e.At,
+			Changes: make([]fieldChangeDTO, 0, len(e.Changes)),
+		}
+		for _, c := range e.Changes {
+			d.Changes = append(d.Changes, fieldChangeDTO{Field: c.Field, Old: c.Old, New: c.New})
+		}
+		res = append(res, d)
+	}
+	return res
+}
@@ -0,0 +1,161 @@
+package internalhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/ical"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// maxImportSize ограничивает размер загружаемого .ics файла.
+const maxImportSize = 10 << 20
+
+const (
+	importStatusCreated  = "created"
+	importStatusConflict = "conflict"
+	importStatusInvalid  = "invalid"
+)
+
+type importResultDTO struct {
+	UID    string `json:"uid"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// handleExportICS отдает события пользователя в формате iCalendar.
+// Параметры: from, to (YYYY-MM-DD), tz - часовой пояс дат; по умолчанию - год, начиная с сегодняшнего дня.
+func (s *Server) handleExportICS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	loc, ok := s.location(w, r, userID)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	now := time.Now()
+	from, _ := storage.DayBounds(now.In(loc))
+	if v := q.Get("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			http.Error(w, "invalid from format, want YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+	to := from.AddDate(1, 0, 0)
+	if v := q.Get("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			http.Error(w, "invalid to format, want YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+	if !to.After(from) {
+		http.Error(w, "to must be after from", http.StatusBadRequest)
+		return
+	}
+
+	list, err := s.app.ListEventsBetween(r.Context(), userID, from, to)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+
+	// Повторяющиеся события выгружаются один раз - исходной серией с RRULE.
+	seen := make(map[string]bool)
+	events := make([]storage.Event, 0, len(list))
+	for _, e := range list {
+		if seen[e.ID] {
+			continue
+		}
+		seen[e.ID] = true
+		if e.IsRecurring() {
+			master, err := s.app.GetEventByID(r.Context(), userID, e.ID)
+			if err != nil {
+				s.writeStorageError(w, err)
+				return
+			}
+			e = *master
+		}
+		events = append(events, e)
+	}
+
+	var buf bytes.Buffer
+	if err := ical.Encode(&buf, events, now); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="calendar.ics"`)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// handleImportICS создает события из загруженного .ics (тело запроса или поле формы file)
+// и возвращает результат по каждому VEVENT.
+func (s *Server) handleImportICS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	var src io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "missing file", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		src = file
+	}
+
+	items, err := ical.Decode(src)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]importResultDTO, 0, len(items))
+	for _, item := range items {
+		res := importResultDTO{UID: item.UID, Status: importStatusCreated}
+		err := item.Err
+		if err == nil {
+			_, _, err = s.app.CreateEvent(r.Context(), userID, item.Event)
+		}
+		switch {
+		case err == nil:
+		case errors.Is(err, storage.ErrDateBusy), errors.Is(err, storage.ErrAlreadyExists):
+			res.Status = importStatusConflict
+			res.Error = err.Error()
+		case errors.Is(err, storage.ErrInvalidEvent):
+			res.Status = importStatusInvalid
+			res.Error = err.Error()
+		default:
+			s.writeStorageError(w, err)
+			return
+		}
+		results = append(results, res)
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string][]importResultDTO{"results": results})
+}
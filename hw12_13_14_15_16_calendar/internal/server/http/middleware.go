@@ -3,6 +3,7 @@ package internalhttp
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -26,6 +27,51 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
+// Flush нужен потоковым ответам (SSE), которые идут через middleware.
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного ResponseWriter.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// legacyActions - действия над событием, которые в /api/v1 называются иначе:
+// приглашение - POST /attendees, ответы на него - POST /rsvp.
+var legacyActions = map[string]string{
+	"invite":    "attendees",
+	"accept":    "rsvp",
+	"decline":   "rsvp",
+	"tentative": "rsvp",
+}
+
+// legacyRoute помечает маршрут, у которого есть замена в каноническом REST API /api/v1
+// (grpc-gateway): ответ получает заголовки Deprecation и Link на замену (см. successorPath).
+// Такие маршруты оставлены для совместимости; новые возможности добавляются только в /api/v1.
+func legacyRoute(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successorPath(r.URL.Path)+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
+
+// successorPath возвращает путь в /api/v1, заменяющий путь старого API: тот же путь
+// под /api/v1, а для переименованных действий (см. legacyActions) - с новым именем.
+func successorPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, "/api/events/"); ok {
+		if id, action, ok := strings.Cut(rest, "/"); ok {
+			if renamed, ok := legacyActions[action]; ok {
+				return "/api/v1/events/" + id + "/" + renamed
+			}
+		}
+	}
+	return "/api/v1" + strings.TrimPrefix(path, "/api")
+}
+
 // loggingMiddleware логирует HTTP запросы.
 func loggingMiddleware(logger Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -6,9 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/api"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/app"
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
 )
 
@@ -17,6 +21,8 @@ type Server struct {
 	app    Application
 	srv    *http.Server
 	mux    *http.ServeMux
+	// closing закрывается при остановке сервера, завершая долгие SSE-потоки.
+	closing chan struct{}
 }
 
 // Logger описывает минимальные методы логгера, используемые сервером и middleware.
@@ -26,24 +32,46 @@ type Logger interface {
 }
 
 // Application - интерфейс доменной логики, используемый HTTP-слоем.
+// Все методы выполняются от имени пользователя userID.
 type Application interface {
-	CreateEvent(ctx context.Context, event storage.Event) error
-	UpdateEvent(ctx context.Context, id string, event storage.Event) error
-	DeleteEvent(ctx context.Context, id string) error
-	GetEventByID(ctx context.Context, id string) (*storage.Event, error)
-	ListEventsForDay(ctx context.Context, date time.Time) ([]storage.Event, error)
-	ListEventsForWeek(ctx context.Context, startDate time.Time) ([]storage.Event, error)
-	ListEventsForMonth(ctx context.Context, startDate time.Time) ([]storage.Event, error)
+	CreateEvent(ctx context.Context, userID string, event storage.Event) (storage.Event, []string, error)
+	UpdateEvent(ctx context.Context, userID, id string, event storage.Event) ([]string, error)
+	DeleteEvent(ctx context.Context, userID, id string, version int64) error
+	ListTrash(ctx context.Context, userID string) ([]storage.Event, error)
+	RestoreEvent(ctx context.Context, userID, id string) (storage.Event, []string, error)
+	EventHistory(ctx context.Context, userID, id string) ([]storage.AuditEntry, error)
+	GetEventByID(ctx context.Context, userID, id string) (*storage.Event, error)
+	InviteAttendees(ctx context.Context, userID, id string, attendees []storage.Attendee) (storage.Event, error)
+	RespondToInvitation(ctx context.Context, userID, id string, status storage.RSVPStatus) (storage.Event, error)
+	ListEventsForDay(ctx context.Context, userID string, date time.Time) ([]storage.Event, error)
+	ListEventsForWeek(ctx context.Context, userID string, startDate time.Time) ([]storage.Event, error)
+	ListEventsForMonth(ctx context.Context, userID string, startDate time.Time) ([]storage.Event, error)
+	ListEventsBetween(ctx context.Context, userID string, start, end time.Time) ([]storage.Event, error)
+	ListEvents(ctx context.Context, userID string, filter storage.EventFilter) (storage.EventPage, error)
+	SearchEvents(ctx context.Context, userID string, query storage.SearchQuery) (storage.SearchPage, error)
+	WatchEvents(ctx context.Context, userID string, filter app.WatchFilter) (*app.Subscription, error)
+	Location(ctx context.Context, userID, tz string) (*time.Location, error)
+	WeekStart(ctx context.Context, userID, weekStart string) (time.Weekday, error)
+	GetSettings(ctx context.Context, userID string) (storage.UserSettings, error)
+	UpdateSettings(ctx context.Context, userID string, settings storage.UserSettings) (storage.UserSettings, error)
+	FreeBusy(
+		ctx context.Context, userID string, userIDs []string, from, to time.Time,
+	) (map[string][]storage.Interval, error)
+	FindSlots(ctx context.Context, userID string, userIDs []string, query storage.SlotQuery) ([]storage.Interval, error)
 }
 
+// userIDHeader - заголовок, в котором клиент передает ID пользователя.
+const userIDHeader = "X-User-ID"
+
 // NewServer конструирует HTTP-сервер с hello endpoint и логирующей middleware.
 func NewServer(logger Logger, app Application, host, port string) *Server {
 	mux := http.NewServeMux()
 
 	s := &Server{
-		logger: logger,
-		app:    app,
-		mux:    mux,
+		logger:  logger,
+		app:     app,
+		mux:     mux,
+		closing: make(chan struct{}),
 	}
 
 	s.registerRoutes(mux)
@@ -57,6 +85,8 @@ func NewServer(logger Logger, app Application, host, port string) *Server {
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
+	srv.RegisterOnShutdown(func() { close(s.closing) })
+
 	s.srv = srv
 	return s
 }
@@ -87,37 +117,122 @@ func (s *Server) Stop(ctx context.Context) error {
 // ===== HTTP API =====
 
 type eventDTO struct {
-	ID          string     `json:"id,omitempty"`
-	Title       string     `json:"title"`
-	StartTime   time.Time  `json:"startTime"`
-	EndTime     time.Time  `json:"endTime"`
-	Description string     `json:"description,omitempty"`
-	UserID      string     `json:"userId"`
-	NotifyAt    *time.Time `json:"notifyAt,omitempty"`
+	// ID назначает сервер; переданный при создании игнорируется.
+	ID          string    `json:"id,omitempty"`
+	Title       string    `json:"title"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+	Description string    `json:"description,omitempty"`
+	UserID      string    `json:"userId"`
+	TimeZone    string    `json:"timeZone,omitempty"`
+	AllDay      bool      `json:"allDay,omitempty"`
+	StartDate   string    `json:"startDate,omitempty"`
+	EndDate     string    `json:"endDate,omitempty"`
+	// Transparency - "busy" или "free": свободное событие не занимает время.
+	// По умолчанию "busy", а для события на весь день - "free".
+	Transparency string        `json:"transparency,omitempty"`
+	Reminders    []reminderDTO `json:"reminders,omitempty"`
+	Attendees    []attendeeDTO `json:"attendees,omitempty"`
+	RRule        string        `json:"rrule,omitempty"`
+	ExDates      []time.Time   `json:"exDates,omitempty"`
+	Version      int64         `json:"version,omitempty"`
+	// Conflicts - ID пересекающихся событий, если политика пересечений пользователя - warn.
+	// Заполняется только в ответах на создание и восстановление.
+	Conflicts []string `json:"conflicts,omitempty"`
+	// DeletedAt - время переноса события в корзину; заполняется только в списке корзины.
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// updateResultDTO - ответ на изменение события; conflicts - как в eventDTO.
+type updateResultDTO struct {
+	Status    string   `json:"status"`
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+// reminderDTO - напоминание; offset задается длительностью Go ("15m", "24h").
+// id и sentAt заполняет сервер.
+type reminderDTO struct {
+	ID      string     `json:"id,omitempty"`
+	Offset  string     `json:"offset"`
+	Channel string     `json:"channel,omitempty"`
+	SentAt  *time.Time `json:"sentAt,omitempty"`
 }
 
+// toDTO отдает время начала и окончания в часовом поясе события;
+// для события на весь день дополнительно заполняет startDate и endDate (endDate не включается).
 func toDTO(e storage.Event) eventDTO {
-	return eventDTO{
+	loc := e.Location()
+	d := eventDTO{
 		ID:          e.ID,
 		Title:       e.Title,
-		StartTime:   e.StartTime,
-		EndTime:     e.EndTime,
+		StartTime:   e.StartTime.In(loc),
+		EndTime:     e.EndTime.In(loc),
 		Description: e.Description,
 		UserID:      e.UserID,
-		NotifyAt:    e.NotifyAt,
+		TimeZone:    e.TimeZone,
+		AllDay:      e.AllDay,
+		ExDates:     e.ExDates,
+		Version:     e.Version,
+
+		Transparency: string(e.Transparency),
+		DeletedAt:    e.DeletedAt,
+	}
+	for _, r := range e.Reminders {
+		d.Reminders = append(d.Reminders, reminderDTO{
+			ID:      r.ID,
+			Offset:  r.Offset.String(),
+			Channel: r.Channel,
+			SentAt:  r.SentAt,
+		})
+	}
+	d.Attendees = toAttendeeDTOs(e.Attendees)
+	if e.RRule != nil {
+		d.RRule = e.RRule.String()
+	}
+	if e.AllDay {
+		d.StartDate, d.EndDate = e.Dates()
 	}
+	return d
 }
 
-func fromDTO(d eventDTO) storage.Event {
-	return storage.Event{
+// fromDTO для события на весь день берет границы из startDate и endDate, если они заданы,
+// иначе из startTime и endTime.
+func fromDTO(d eventDTO) (storage.Event, error) {
+	e := storage.Event{
 		ID:          d.ID,
 		Title:       d.Title,
 		StartTime:   d.StartTime,
 		EndTime:     d.EndTime,
 		Description: d.Description,
 		UserID:      d.UserID,
-		NotifyAt:    d.NotifyAt,
+		TimeZone:    d.TimeZone,
+		AllDay:      d.AllDay,
+		ExDates:     d.ExDates,
+
+		Transparency: storage.Transparency(d.Transparency),
+	}
+	if d.AllDay {
+		var err error
+		if e, err = e.WithDates(d.StartDate, d.EndDate); err != nil {
+			return storage.Event{}, fmt.Errorf("%w: %w", storage.ErrInvalidEvent, err)
+		}
+	}
+	for _, r := range d.Reminders {
+		offset, err := time.ParseDuration(r.Offset)
+		if err != nil {
+			return storage.Event{}, fmt.Errorf("%w: reminder offset: %w", storage.ErrInvalidEvent, err)
+		}
+		e.Reminders = append(e.Reminders, storage.Reminder{ID: r.ID, Offset: offset, Channel: r.Channel})
+	}
+	e.Attendees = fromAttendeeDTOs(d.Attendees)
+	if d.RRule != "" {
+		rule, err := storage.ParseRRule(d.RRule)
+		if err != nil {
+			return storage.Event{}, fmt.Errorf("%w: %w", storage.ErrInvalidEvent, err)
+		}
+		e.RRule = rule
 	}
+	return e, nil
 }
 
 func (s *Server) registerRoutes(mux *http.ServeMux) {
@@ -131,66 +246,155 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 		_, _ = w.Write([]byte("hello world"))
 	})
 
+	// Канонический REST API - /api/v1 (grpc-gateway, монтируется через Mount). Маршруты ниже,
+	// обернутые в legacyRoute, его дублируют и оставлены для совместимости.
+
 	// CRUD для событий
-	mux.HandleFunc("/api/events", s.handleEventsRoot)
-	mux.HandleFunc("/api/events/", s.handleEventByID)
+	mux.HandleFunc("/api/events", legacyRoute(s.handleEventsRoot))
+	mux.HandleFunc("/api/events/", legacyRoute(s.handleEventByID))
 
 	// Листинги по интервалам
-	mux.HandleFunc("/api/events/day", s.handleListDay)
-	mux.HandleFunc("/api/events/week", s.handleListWeek)
-	mux.HandleFunc("/api/events/month", s.handleListMonth)
+	mux.HandleFunc("/api/events/day", legacyRoute(s.handleListDay))
+	mux.HandleFunc("/api/events/week", legacyRoute(s.handleListWeek))
+	mux.HandleFunc("/api/events/month", legacyRoute(s.handleListMonth))
+	mux.HandleFunc("/api/events/search", legacyRoute(s.handleSearch))
+	mux.HandleFunc("/api/events/stream", s.handleStream)
+
+	// Корзина удаленных событий; восстановление - POST /api/events/{id}/restore
+	mux.HandleFunc("/api/trash", legacyRoute(s.handleTrash))
+
+	// Спецификация REST-отображения gRPC API
+	mux.HandleFunc("/api/openapi.json", s.handleOpenAPI)
+
+	// Настройки пользователя
+	mux.HandleFunc("/api/settings", legacyRoute(s.handleSettings))
+
+	// Занятость пользователей и поиск свободного времени
+	mux.HandleFunc("/api/freebusy", legacyRoute(s.handleFreeBusy))
+	mux.HandleFunc("/api/freebusy/slots", legacyRoute(s.handleFindSlots))
+
+	// Импорт/экспорт iCalendar
+	mux.HandleFunc("/api/events/export.ics", s.handleExportICS)
+	mux.HandleFunc("/api/events/import", s.handleImportICS)
+}
+
+// Mount подключает дополнительный обработчик, например REST-шлюз gRPC API.
+func (s *Server) Mount(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(api.OpenAPI)
 }
 
 func (s *Server) handleEventsRoot(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
 	switch r.Method {
+	case http.MethodGet:
+		s.handleListEvents(w, r, userID)
 	case http.MethodPost:
 		var d eventDTO
 		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
 			http.Error(w, "invalid json", http.StatusBadRequest)
 			return
 		}
-		ev := fromDTO(d)
-		if err := s.app.CreateEvent(r.Context(), ev); err != nil {
+		ev, err := fromDTO(d)
+		if err != nil {
+			s.writeStorageError(w, err)
+			return
+		}
+		// ID всегда генерирует хранилище: клиентский ID мог бы совпасть с чужим событием.
+		ev.ID = ""
+		created, conflicts, err := s.app.CreateEvent(r.Context(), userID, ev)
+		if err != nil {
 			s.writeStorageError(w, err)
 			return
 		}
+		w.Header().Set("Location", "/api/events/"+url.PathEscape(created.ID))
+		w.Header().Set("ETag", etag(created.Version))
 		w.WriteHeader(http.StatusCreated)
-		_ = json.NewEncoder(w).Encode(map[string]string{"status": "created"})
+		res := toDTO(created)
+		res.Conflicts = conflicts
+		_ = json.NewEncoder(w).Encode(res)
 	default:
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
 func (s *Server) handleEventByID(w http.ResponseWriter, r *http.Request) {
-	// URL: /api/events/{id}
+	// URL: /api/events/{id} или /api/events/{id}/{action}
 	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/events/"), "/")
-	if len(parts) == 0 || parts[0] == "" {
+	if len(parts) == 0 || parts[0] == "" || len(parts) > 2 {
 		http.NotFound(w, r)
 		return
 	}
 	id := parts[0]
 
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+	if len(parts) == 2 {
+		switch parts[1] {
+		case "restore":
+			s.handleRestore(w, r, userID, id)
+		case "history":
+			s.handleHistory(w, r, userID, id)
+		default:
+			s.handleInvitation(w, r, userID, id, parts[1])
+		}
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
-		ev, err := s.app.GetEventByID(r.Context(), id)
+		ev, err := s.app.GetEventByID(r.Context(), userID, id)
 		if err != nil {
 			s.writeStorageError(w, err)
 			return
 		}
+		w.Header().Set("ETag", etag(ev.Version))
 		_ = json.NewEncoder(w).Encode(toDTO(*ev))
 	case http.MethodPut:
+		version, ok := ifMatchVersion(w, r)
+		if !ok {
+			return
+		}
 		var d eventDTO
 		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
 			http.Error(w, "invalid json", http.StatusBadRequest)
 			return
 		}
-		if err := s.app.UpdateEvent(r.Context(), id, fromDTO(d)); err != nil {
+		ev, err := fromDTO(d)
+		if err != nil {
+			s.writeStorageError(w, err)
+			return
+		}
+		ev.Version = version
+		conflicts, err := s.app.UpdateEvent(r.Context(), userID, id, ev)
+		if err != nil {
 			s.writeStorageError(w, err)
 			return
 		}
-		_ = json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
+		if version != 0 {
+			w.Header().Set("ETag", etag(version+1))
+		}
+		_ = json.NewEncoder(w).Encode(updateResultDTO{Status: "updated", Conflicts: conflicts})
 	case http.MethodDelete:
-		if err := s.app.DeleteEvent(r.Context(), id); err != nil {
+		version, ok := ifMatchVersion(w, r)
+		if !ok {
+			return
+		}
+		if err := s.app.DeleteEvent(r.Context(), userID, id, version); err != nil {
 			s.writeStorageError(w, err)
 			return
 		}
@@ -200,22 +404,22 @@ func (s *Server) handleEventByID(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleListDay отдает события за сутки date (YYYY-MM-DD) в часовом поясе tz.
+// Без tz используется пояс из настроек пользователя, а без них UTC.
 func (s *Server) handleListDay(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	dateStr := r.URL.Query().Get("date")
-	if dateStr == "" {
-		http.Error(w, "missing date", http.StatusBadRequest)
+	userID, ok := callerID(w, r)
+	if !ok {
 		return
 	}
-	date, err := time.Parse("2006-01-02", dateStr)
-	if err != nil {
-		http.Error(w, "invalid date format, want YYYY-MM-DD", http.StatusBadRequest)
+	date, ok := s.dateParam(w, r, userID, "date")
+	if !ok {
 		return
 	}
-	list, err := s.app.ListEventsForDay(r.Context(), date)
+	list, err := s.app.ListEventsForDay(r.Context(), userID, date)
 	if err != nil {
 		s.writeStorageError(w, err)
 		return
@@ -223,22 +427,23 @@ func (s *Server) handleListDay(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(map[string][]eventDTO{"events": toDTOList(list)})
 }
 
+// handleListWeek отдает события за неделю в часовом поясе tz: неделю ISO 8601 isoWeek (2026-W42)
+// или неделю, содержащую start (YYYY-MM-DD). Неделя начинается с дня weekStart ("monday", "sunday"),
+// а без него - с первого дня недели из настроек пользователя.
 func (s *Server) handleListWeek(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	startStr := r.URL.Query().Get("start")
-	if startStr == "" {
-		http.Error(w, "missing start", http.StatusBadRequest)
+	userID, ok := callerID(w, r)
+	if !ok {
 		return
 	}
-	start, err := time.Parse("2006-01-02", startStr)
-	if err != nil {
-		http.Error(w, "invalid start format, want YYYY-MM-DD", http.StatusBadRequest)
+	start, ok := s.weekParam(w, r, userID)
+	if !ok {
 		return
 	}
-	list, err := s.app.ListEventsForWeek(r.Context(), start)
+	list, err := s.app.ListEventsForWeek(r.Context(), userID, start)
 	if err != nil {
 		s.writeStorageError(w, err)
 		return
@@ -246,22 +451,32 @@ func (s *Server) handleListWeek(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(map[string][]eventDTO{"events": toDTOList(list)})
 }
 
+// handleListMonth отдает события за месяц month (YYYY-MM) или месяц, содержащий start (YYYY-MM-DD),
+// в часовом поясе tz.
 func (s *Server) handleListMonth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	startStr := r.URL.Query().Get("start")
-	if startStr == "" {
-		http.Error(w, "missing start", http.StatusBadRequest)
+	userID, ok := callerID(w, r)
+	if !ok {
 		return
 	}
-	start, err := time.Parse("2006-01-02", startStr)
-	if err != nil {
-		http.Error(w, "invalid start format, want YYYY-MM-DD", http.StatusBadRequest)
+	var start time.Time
+	if month := r.URL.Query().Get("month"); month != "" {
+		loc, ok := s.location(w, r, userID)
+		if !ok {
+			return
+		}
+		var err error
+		if start, err = time.ParseInLocation("2006-01", month, loc); err != nil {
+			http.Error(w, "invalid month format, want YYYY-MM", http.StatusBadRequest)
+			return
+		}
+	} else if start, ok = s.dateParam(w, r, userID, "start"); !ok {
 		return
 	}
-	list, err := s.app.ListEventsForMonth(r.Context(), start)
+	list, err := s.app.ListEventsForMonth(r.Context(), userID, start)
 	if err != nil {
 		s.writeStorageError(w, err)
 		return
@@ -269,6 +484,171 @@ func (s *Server) handleListMonth(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(map[string][]eventDTO{"events": toDTOList(list)})
 }
 
+type eventPageDTO struct {
+	Events     []eventDTO `json:"events"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+// handleListEvents отдает страницу событий по фильтру.
+// Параметры: from, to (RFC3339 или YYYY-MM-DD), tz - часовой пояс дат, userId, q, limit, cursor.
+func (s *Server) handleListEvents(w http.ResponseWriter, r *http.Request, userID string) {
+	q := r.URL.Query()
+	filter := storage.EventFilter{
+		UserID: q.Get("userId"),
+		Query:  q.Get("q"),
+		Cursor: q.Get("cursor"),
+	}
+
+	loc, ok := s.location(w, r, userID)
+	if !ok {
+		return
+	}
+	var err error
+	if filter.From, err = parseTimeParam(q.Get("from"), loc); err != nil {
+		http.Error(w, "invalid from format, want RFC3339 or YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if filter.To, err = parseTimeParam(q.Get("to"), loc); err != nil {
+		http.Error(w, "invalid to format, want RFC3339 or YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		http.Error(w, "to must be after from", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := s.app.ListEvents(r.Context(), userID, filter)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(eventPageDTO{Events: toDTOList(page.Events), NextCursor: page.NextCursor})
+}
+
+type searchResultDTO struct {
+	eventDTO
+	Rank float64 `json:"rank"`
+}
+
+type searchPageDTO struct {
+	Results    []searchResultDTO `json:"results"`
+	NextCursor string            `json:"nextCursor,omitempty"`
+}
+
+// handleSearch выполняет полнотекстовый поиск. Параметры: q, limit, cursor.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	query := storage.SearchQuery{Query: q.Get("q"), Cursor: q.Get("cursor")}
+	if query.Query == "" {
+		http.Error(w, "missing q", http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("limit"); v != "" {
+		var err error
+		if query.Limit, err = strconv.Atoi(v); err != nil || query.Limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := s.app.SearchEvents(r.Context(), userID, query)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	resp := searchPageDTO{Results: make([]searchResultDTO, 0, len(page.Results)), NextCursor: page.NextCursor}
+	for _, res := range page.Results {
+		resp.Results = append(resp.Results, searchResultDTO{eventDTO: toDTO(res.Event), Rank: res.Rank})
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// parseTimeParam разбирает время в формате RFC3339 или дату YYYY-MM-DD - начало суток в поясе loc.
+// Пустая строка - нулевое время.
+func parseTimeParam(v string, loc *time.Location) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", v, loc)
+}
+
+// location возвращает часовой пояс запроса: параметр tz, иначе пояс из настроек пользователя.
+// Для неизвестного пояса отвечает 400.
+func (s *Server) location(w http.ResponseWriter, r *http.Request, userID string) (*time.Location, bool) {
+	loc, err := s.app.Location(r.Context(), userID, r.URL.Query().Get("tz"))
+	if err != nil {
+		s.writeStorageError(w, err)
+		return nil, false
+	}
+	return loc, true
+}
+
+// dateParam разбирает обязательный параметр name в формате YYYY-MM-DD как начало суток
+// в часовом поясе запроса (см. location). При ошибке отвечает 400.
+func (s *Server) dateParam(w http.ResponseWriter, r *http.Request, userID, name string) (time.Time, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		http.Error(w, "missing "+name, http.StatusBadRequest)
+		return time.Time{}, false
+	}
+	loc, ok := s.location(w, r, userID)
+	if !ok {
+		return time.Time{}, false
+	}
+	date, err := time.ParseInLocation("2006-01-02", v, loc)
+	if err != nil {
+		http.Error(w, "invalid "+name+" format, want YYYY-MM-DD", http.StatusBadRequest)
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// weekParam возвращает начало запрошенной недели: понедельник недели ISO 8601 из параметра isoWeek
+// или начало недели, содержащей дату start (см. handleListWeek). При ошибке отвечает 400.
+func (s *Server) weekParam(w http.ResponseWriter, r *http.Request, userID string) (time.Time, bool) {
+	q := r.URL.Query()
+	if isoWeek := q.Get("isoWeek"); isoWeek != "" {
+		loc, ok := s.location(w, r, userID)
+		if !ok {
+			return time.Time{}, false
+		}
+		start, err := storage.ParseISOWeek(isoWeek, loc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return time.Time{}, false
+		}
+		return start, true
+	}
+
+	date, ok := s.dateParam(w, r, userID, "start")
+	if !ok {
+		return time.Time{}, false
+	}
+	first, err := s.app.WeekStart(r.Context(), userID, q.Get("weekStart"))
+	if err != nil {
+		s.writeStorageError(w, err)
+		return time.Time{}, false
+	}
+	return storage.StartOfWeek(date, first), true
+}
+
 func toDTOList(list []storage.Event) []eventDTO {
 	res := make([]eventDTO, 0, len(list))
 	for _, e := range list {
@@ -277,17 +657,60 @@ func toDTOList(list []storage.Event) []eventDTO {
 	return res
 }
 
+// callerID извлекает ID пользователя из заголовка запроса; при его отсутствии отвечает 401.
+func callerID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := r.Header.Get(userIDHeader)
+	if userID == "" {
+		http.Error(w, "missing "+userIDHeader+" header", http.StatusUnauthorized)
+		return "", false
+	}
+	return userID, true
+}
+
+// etag формирует значение заголовка ETag по версии события.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion извлекает ожидаемую версию события из обязательного заголовка If-Match.
+// Значение "*" означает любую версию (0). При отсутствии заголовка отвечает 428, при ошибке - 400.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch v {
+	case "":
+		http.Error(w, "missing If-Match header", http.StatusPreconditionRequired)
+		return 0, false
+	case "*":
+		return 0, true
+	}
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(v, "W/"), `"`), 10, 64)
+	if err != nil || version <= 0 {
+		http.Error(w, "invalid If-Match header", http.StatusBadRequest)
+		return 0, false
+	}
+	return version, true
+}
+
 func (s *Server) writeStorageError(w http.ResponseWriter, err error) {
 	var status int
 	switch {
-	case errors.Is(err, storage.ErrInvalidEvent):
+	case errors.Is(err, storage.ErrInvalidEvent), errors.Is(err, storage.ErrInvalidCursor),
+		errors.Is(err, storage.ErrInvalidQuery), errors.Is(err, storage.ErrInvalidTimeZone),
+		errors.Is(err, storage.ErrInvalidWeekStart), errors.Is(err, storage.ErrInvalidRange):
 		status = http.StatusBadRequest
 	case errors.Is(err, storage.ErrEventNotFound):
 		status = http.StatusNotFound
-	case errors.Is(err, storage.ErrDateBusy):
+	case errors.Is(err, storage.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, storage.ErrDateBusy), errors.Is(err, storage.ErrAlreadyExists):
 		status = http.StatusConflict
+	case errors.Is(err, storage.ErrVersionConflict):
+		status = http.StatusPreconditionFailed
 	default:
-		status = http.StatusInternalServerError
+		// Текст внутренней ошибки (например, ошибки базы данных) клиенту не отдается.
+		s.logger.Error(fmt.Sprintf("request failed: %v", err))
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
 	}
 	http.Error(w, err.Error(), status)
 }
@@ -1,51 +1,87 @@
 package internalhttp
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/app"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/logger"
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+	memorystorage "github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage/memory"
 )
 
 type mockApplication struct {
 	events map[string]storage.Event
 	err    error
+	// live - настоящее приложение, источник ленты изменений для WatchEvents.
+	live *app.App
+	// starts - даты, переданные в ListEventsForWeek и ListEventsForMonth.
+	starts []time.Time
 }
 
-func (m *mockApplication) CreateEvent(_ context.Context, event storage.Event) error {
+func (m *mockApplication) CreateEvent(
+	_ context.Context, userID string, event storage.Event,
+) (storage.Event, []string, error) {
 	if m.err != nil {
-		return m.err
+		return storage.Event{}, nil, m.err
+	}
+	if event.ID == "" {
+		event.ID = storage.NewID()
 	}
+	event.UserID = userID
+	event.Version = 1
 	m.events[event.ID] = event
-	return nil
+	return event, nil, nil
 }
 
-func (m *mockApplication) UpdateEvent(_ context.Context, id string, event storage.Event) error {
+func (m *mockApplication) UpdateEvent(_ context.Context, _, id string, event storage.Event) ([]string, error) {
 	if m.err != nil {
-		return m.err
+		return nil, m.err
+	}
+	current, ok := m.events[id]
+	if !ok {
+		return nil, storage.ErrEventNotFound
 	}
-	if _, ok := m.events[id]; !ok {
-		return storage.ErrEventNotFound
+	if event.Version != 0 && event.Version != current.Version {
+		return nil, storage.ErrVersionConflict
 	}
+	event.Version = current.Version + 1
 	m.events[id] = event
-	return nil
+	return nil, nil
 }
 
-func (m *mockApplication) DeleteEvent(_ context.Context, id string) error {
+func (m *mockApplication) DeleteEvent(_ context.Context, _, id string, version int64) error {
 	if m.err != nil {
 		return m.err
 	}
+	if version != 0 && version != m.events[id].Version {
+		return storage.ErrVersionConflict
+	}
 	delete(m.events, id)
 	return nil
 }
 
-func (m *mockApplication) GetEventByID(_ context.Context, id string) (*storage.Event, error) {
+func (m *mockApplication) ListTrash(_ context.Context, _ string) ([]storage.Event, error) {
+	return nil, m.err
+}
+
+func (m *mockApplication) RestoreEvent(_ context.Context, _, _ string) (storage.Event, []string, error) {
+	return storage.Event{}, nil, storage.ErrEventNotFound
+}
+
+func (m *mockApplication) EventHistory(_ context.Context, _, _ string) ([]storage.AuditEntry, error) {
+	return nil, m.err
+}
+
+func (m *mockApplication) GetEventByID(_ context.Context, userID, id string) (*storage.Event, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -53,10 +89,41 @@ func (m *mockApplication) GetEventByID(_ context.Context, id string) (*storage.E
 	if !ok {
 		return nil, storage.ErrEventNotFound
 	}
+	if ev.UserID != userID {
+		return nil, storage.ErrForbidden
+	}
 	return &ev, nil
 }
 
-func (m *mockApplication) ListEventsForDay(_ context.Context, date time.Time) ([]storage.Event, error) {
+func (m *mockApplication) InviteAttendees(
+	ctx context.Context, userID, id string, _ []storage.Attendee,
+) (storage.Event, error) {
+	ev, err := m.GetEventByID(ctx, userID, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	return *ev, nil
+}
+
+func (m *mockApplication) RespondToInvitation(
+	_ context.Context, _, _ string, _ storage.RSVPStatus,
+) (storage.Event, error) {
+	return storage.Event{}, storage.ErrForbidden
+}
+
+func (m *mockApplication) FreeBusy(
+	_ context.Context, _ string, _ []string, _, _ time.Time,
+) (map[string][]storage.Interval, error) {
+	return nil, m.err
+}
+
+func (m *mockApplication) FindSlots(
+	_ context.Context, _ string, _ []string, _ storage.SlotQuery,
+) ([]storage.Interval, error) {
+	return nil, m.err
+}
+
+func (m *mockApplication) ListEventsForDay(_ context.Context, _ string, date time.Time) ([]storage.Event, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -69,20 +136,96 @@ func (m *mockApplication) ListEventsForDay(_ context.Context, date time.Time) ([
 	return res, nil
 }
 
-func (m *mockApplication) ListEventsForWeek(_ context.Context, _ time.Time) ([]storage.Event, error) {
+func (m *mockApplication) ListEventsForWeek(_ context.Context, _ string, start time.Time) ([]storage.Event, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
+	m.starts = append(m.starts, start)
 	return nil, nil // Simple mock
 }
 
-func (m *mockApplication) ListEventsForMonth(_ context.Context, _ time.Time) ([]storage.Event, error) {
+func (m *mockApplication) ListEventsForMonth(_ context.Context, _ string, start time.Time) ([]storage.Event, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
+	m.starts = append(m.starts, start)
 	return nil, nil // Simple mock
 }
 
+func (m *mockApplication) ListEventsBetween(_ context.Context, userID string, start, end time.Time) ([]storage.Event, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var res []storage.Event
+	for _, e := range m.events {
+		if e.UserID == userID && !e.StartTime.Before(start) && e.StartTime.Before(end) {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockApplication) ListEvents(_ context.Context, userID string, filter storage.EventFilter) (storage.EventPage, error) {
+	if m.err != nil {
+		return storage.EventPage{}, m.err
+	}
+	if filter.UserID != "" && filter.UserID != userID {
+		return storage.EventPage{}, storage.ErrForbidden
+	}
+	var own []storage.Event
+	for _, e := range m.events {
+		if e.UserID == userID {
+			own = append(own, e)
+		}
+	}
+	return storage.BuildPage(own, filter)
+}
+
+func (m *mockApplication) SearchEvents(_ context.Context, userID string, query storage.SearchQuery) (storage.SearchPage, error) {
+	if m.err != nil {
+		return storage.SearchPage{}, m.err
+	}
+	if _, err := query.Terms(); err != nil {
+		return storage.SearchPage{}, err
+	}
+	page := storage.SearchPage{}
+	for _, e := range m.events {
+		if e.UserID == userID && strings.Contains(strings.ToLower(e.Title), strings.ToLower(query.Query)) {
+			page.Results = append(page.Results, storage.SearchResult{Event: e, Rank: 1})
+		}
+	}
+	return page, nil
+}
+
+func (m *mockApplication) WatchEvents(ctx context.Context, userID string, filter app.WatchFilter) (*app.Subscription, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.live.WatchEvents(ctx, userID, filter)
+}
+
+func (m *mockApplication) Location(_ context.Context, _, tz string) (*time.Location, error) {
+	return storage.LoadLocation(tz)
+}
+
+func (m *mockApplication) WeekStart(_ context.Context, _, weekStart string) (time.Weekday, error) {
+	if weekStart == "" {
+		return time.Monday, nil
+	}
+	return storage.ParseWeekday(weekStart)
+}
+
+func (m *mockApplication) GetSettings(_ context.Context, userID string) (storage.UserSettings, error) {
+	return storage.UserSettings{UserID: userID}, m.err
+}
+
+func (m *mockApplication) UpdateSettings(
+	_ context.Context, userID string, settings storage.UserSettings,
+) (storage.UserSettings, error) {
+	settings.UserID = userID
+	return settings, m.err
+}
+
 type mockLogger struct{}
 
 func (m *mockLogger) Info(_ string)  {}
@@ -102,6 +245,7 @@ func TestServer_CreateEvent(t *testing.T) {
 	body, _ := json.Marshal(event)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/events", bytes.NewBuffer(body))
+	req.Header.Set(userIDHeader, "user1")
 	w := httptest.NewRecorder()
 
 	server.mux.ServeHTTP(w, req)
@@ -115,13 +259,43 @@ func TestServer_CreateEvent(t *testing.T) {
 	}
 }
 
+func TestServer_CreateEvent_GeneratedID(t *testing.T) {
+	mockApp := &mockApplication{events: make(map[string]storage.Event)}
+	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
+
+	body, _ := json.Marshal(eventDTO{Title: "No ID", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)})
+	req := httptest.NewRequest(http.MethodPost, "/api/events", bytes.NewBuffer(body))
+	req.Header.Set(userIDHeader, "user1")
+	w := httptest.NewRecorder()
+
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", w.Code)
+	}
+	var resp eventDTO
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID == "" || resp.UserID != "user1" || resp.Title != "No ID" || resp.Version != 1 {
+		t.Errorf("unexpected created event: %+v", resp)
+	}
+	if loc := w.Header().Get("Location"); loc != "/api/events/"+resp.ID {
+		t.Errorf("expected Location /api/events/%s, got %q", resp.ID, loc)
+	}
+	if _, ok := mockApp.events[resp.ID]; !ok {
+		t.Errorf("event %s not stored", resp.ID)
+	}
+}
+
 func TestServer_GetEventByID(t *testing.T) {
 	id := "123"
-	event := storage.Event{ID: id, Title: "Existing Event"}
+	event := storage.Event{ID: id, Title: "Existing Event", UserID: "user1"}
 	mockApp := &mockApplication{events: map[string]storage.Event{id: event}}
 	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
 
 	req := httptest.NewRequest(http.MethodGet, "/api/events/"+id, nil)
+	req.Header.Set(userIDHeader, "user1")
 	w := httptest.NewRecorder()
 
 	server.mux.ServeHTTP(w, req)
@@ -141,11 +315,13 @@ func TestServer_GetEventByID(t *testing.T) {
 
 func TestServer_DeleteEvent(t *testing.T) {
 	id := "123"
-	event := storage.Event{ID: id, Title: "To be deleted"}
+	event := storage.Event{ID: id, Title: "To be deleted", UserID: "user1"}
 	mockApp := &mockApplication{events: map[string]storage.Event{id: event}}
 	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
 
 	req := httptest.NewRequest(http.MethodDelete, "/api/events/"+id, nil)
+	req.Header.Set(userIDHeader, "user1")
+	req.Header.Set("If-Match", "*")
 	w := httptest.NewRecorder()
 
 	server.mux.ServeHTTP(w, req)
@@ -159,6 +335,49 @@ func TestServer_DeleteEvent(t *testing.T) {
 	}
 }
 
+func TestServer_EventVersions(t *testing.T) {
+	id := "123"
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	event := storage.Event{ID: id, Title: "Meeting", UserID: "user1", StartTime: start, EndTime: start.Add(time.Hour), Version: 1}
+	mockApp := &mockApplication{events: map[string]storage.Event{id: event}}
+	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
+
+	do := func(method, ifMatch string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(toDTO(event))
+		req := httptest.NewRequest(method, "/api/events/"+id, bytes.NewReader(body))
+		req.Header.Set(userIDHeader, "user1")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodGet, ""); w.Header().Get("ETag") != `"1"` {
+		t.Errorf("expected ETag \"1\", got %q", w.Header().Get("ETag"))
+	}
+	if w := do(http.MethodPut, ""); w.Code != http.StatusPreconditionRequired {
+		t.Errorf("expected status 428 without If-Match, got %d", w.Code)
+	}
+	if w := do(http.MethodPut, "garbage"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid If-Match, got %d", w.Code)
+	}
+	w := do(http.MethodPut, `"1"`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Errorf("expected status 200 with ETag \"2\", got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	if w := do(http.MethodPut, `"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status 412 for stale version, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, `"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status 412 for stale delete, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, `W/"2"`); w.Code != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d", w.Code)
+	}
+}
+
 func TestServer_ListEventsForDay(t *testing.T) {
 	dateStr := "2023-10-27"
 	date, _ := time.Parse("2006-01-02", dateStr)
@@ -167,6 +386,7 @@ func TestServer_ListEventsForDay(t *testing.T) {
 	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
 
 	req := httptest.NewRequest(http.MethodGet, "/api/events/day?date="+dateStr, nil)
+	req.Header.Set(userIDHeader, "user1")
 	w := httptest.NewRecorder()
 
 	server.mux.ServeHTTP(w, req)
@@ -182,6 +402,116 @@ func TestServer_ListEventsForDay(t *testing.T) {
 	if len(resp["events"]) != 1 {
 		t.Errorf("expected 1 event, got %d", len(resp["events"]))
 	}
+	if w.Header().Get("Deprecation") != "true" ||
+		w.Header().Get("Link") != `</api/v1/events/day>; rel="successor-version"` {
+		t.Errorf("expected legacy route to point to /api/v1, got %v", w.Header())
+	}
+}
+
+func TestServer_ListEvents(t *testing.T) {
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	mockApp := &mockApplication{events: map[string]storage.Event{}}
+	for i, title := range []string{"Standup", "Review", "Standup retro"} {
+		id := fmt.Sprint(i + 1)
+		mockApp.events[id] = storage.Event{
+			ID: id, Title: title, UserID: "user1",
+			StartTime: start.Add(time.Duration(i) * time.Hour), EndTime: start.Add(time.Duration(i)*time.Hour + 30*time.Minute),
+		}
+	}
+	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
+
+	get := func(query string) (int, eventPageDTO) {
+		req := httptest.NewRequest(http.MethodGet, "/api/events?"+query, nil)
+		req.Header.Set(userIDHeader, "user1")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		var page eventPageDTO
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, page
+	}
+
+	code, page := get("from=2026-10-05&limit=2")
+	if code != http.StatusOK || len(page.Events) != 2 || page.NextCursor == "" {
+		t.Fatalf("first page: status %d, %d events, cursor %q", code, len(page.Events), page.NextCursor)
+	}
+	code, page = get("from=2026-10-05&limit=2&cursor=" + page.NextCursor)
+	if code != http.StatusOK || len(page.Events) != 1 || page.Events[0].ID != "3" || page.NextCursor != "" {
+		t.Fatalf("second page: status %d, events %+v, cursor %q", code, page.Events, page.NextCursor)
+	}
+
+	if _, page = get("q=standup"); len(page.Events) != 2 {
+		t.Errorf("expected 2 events matching query, got %d", len(page.Events))
+	}
+	if code, _ = get("cursor=garbage!"); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid cursor, got %d", code)
+	}
+	if code, _ = get("limit=abc"); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid limit, got %d", code)
+	}
+	if code, _ = get("userId=user2"); code != http.StatusForbidden {
+		t.Errorf("expected status 403 for foreign userId, got %d", code)
+	}
+}
+
+func TestServer_SearchEvents(t *testing.T) {
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	mockApp := &mockApplication{events: map[string]storage.Event{
+		"1": {ID: "1", Title: "Q3 budget", UserID: "user1", StartTime: start, EndTime: start.Add(time.Hour)},
+		"2": {ID: "2", Title: "Standup", UserID: "user1", StartTime: start, EndTime: start.Add(time.Hour)},
+	}}
+	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/search?q=budget", nil)
+	req.Header.Set(userIDHeader, "user1")
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp searchPageDTO
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 1 || resp.Results[0].ID != "1" || resp.Results[0].Rank == 0 {
+		t.Errorf("unexpected results: %+v", resp.Results)
+	}
+
+	for _, q := range []string{"", "q=%20-%20"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/events/search?"+q, nil)
+		req.Header.Set(userIDHeader, "user1")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("query %q: expected status 400, got %d", q, w.Code)
+		}
+	}
+}
+
+func TestServer_OpenAPI(t *testing.T) {
+	server := NewServer(&mockLogger{}, &mockApplication{}, "localhost", "8080")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	w := httptest.NewRecorder()
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var spec struct {
+		Swagger string                     `json:"swagger"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := spec.Paths["/api/v1/events/{id}"]; !ok || spec.Swagger == "" {
+		t.Errorf("unexpected spec: %s %v", spec.Swagger, spec.Paths)
+	}
 }
 
 func TestServer_StorageError(t *testing.T) {
@@ -189,6 +519,7 @@ func TestServer_StorageError(t *testing.T) {
 	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
 
 	req := httptest.NewRequest(http.MethodGet, "/api/events/123", nil)
+	req.Header.Set(userIDHeader, "user1")
 	w := httptest.NewRecorder()
 
 	server.mux.ServeHTTP(w, req)
@@ -196,4 +527,698 @@ func TestServer_StorageError(t *testing.T) {
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("expected status 500, got %d", w.Code)
 	}
+	if strings.Contains(w.Body.String(), "database") {
+		t.Errorf("expected internal error details to be hidden, got %q", w.Body.String())
+	}
+}
+
+func TestServer_ExportICS(t *testing.T) {
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	mockApp := &mockApplication{events: map[string]storage.Event{
+		"1": {ID: "1", Title: "Mine", StartTime: start, EndTime: start.Add(time.Hour), UserID: "user1"},
+		"2": {ID: "2", Title: "Other", StartTime: start, EndTime: start.Add(time.Hour), UserID: "user2"},
+	}}
+	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/export.ics?userId=user1&from=2026-10-01&to=2026-11-01", nil)
+	req.Header.Set(userIDHeader, "user1")
+	w := httptest.NewRecorder()
+
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "UID:1\r\n") || strings.Contains(body, "UID:2\r\n") {
+		t.Errorf("unexpected export body:\n%s", body)
+	}
+}
+
+func TestServer_ImportICS(t *testing.T) {
+	mockApp := &mockApplication{events: make(map[string]storage.Event)}
+	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
+
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:a",
+		"SUMMARY:Imported",
+		"DTSTART:20261005T100000Z",
+		"DTEND:20261005T110000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:b",
+		"SUMMARY:Broken",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/events/import?userId=user1", strings.NewReader(ics))
+	req.Header.Set(userIDHeader, "user1")
+	w := httptest.NewRecorder()
+
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp map[string][]importResultDTO
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	results := resp["results"]
+	if len(results) != 2 || results[0].Status != importStatusCreated || results[1].Status != importStatusInvalid {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	var imported *storage.Event
+	for _, ev := range mockApp.events {
+		if ev.UID == "a" {
+			imported = &ev
+		}
+	}
+	if imported == nil || imported.UserID != "user1" || imported.ID == "a" {
+		t.Errorf("expected imported event owned by user1 with server ID, got %+v", imported)
+	}
+}
+
+func TestServer_ImportICS_DuplicateUID(t *testing.T) {
+	a := app.New(logger.New("error"), memorystorage.New(), app.Options{Overlap: storage.OverlapAllow})
+	server := NewServer(&mockLogger{}, a, "localhost", "8080")
+
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:shared",
+		"SUMMARY:Imported",
+		"DTSTART:20261005T100000Z",
+		"DTEND:20261005T110000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	importAs := func(userID string) []importResultDTO {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/events/import", strings.NewReader(ics))
+		req.Header.Set(userIDHeader, userID)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp map[string][]importResultDTO
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp["results"]
+	}
+
+	if res := importAs("user1"); len(res) != 1 || res[0].Status != importStatusCreated {
+		t.Fatalf("unexpected first import: %+v", res)
+	}
+	if res := importAs("user1"); len(res) != 1 || res[0].Status != importStatusConflict {
+		t.Errorf("expected conflict on re-import, got %+v", res)
+	}
+	if res := importAs("user2"); len(res) != 1 || res[0].Status != importStatusCreated {
+		t.Errorf("expected another user to import the same UID, got %+v", res)
+	}
+
+	day := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	for _, userID := range []string{"user1", "user2"} {
+		events, err := a.ListEventsForDay(context.Background(), userID, day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || events[0].UID != "shared" || events[0].UserID != userID {
+			t.Errorf("unexpected events of %s: %+v", userID, events)
+		}
+	}
+}
+
+func TestServer_MissingUserID(t *testing.T) {
+	mockApp := &mockApplication{events: make(map[string]storage.Event)}
+	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/day?date=2023-10-27", nil)
+	w := httptest.NewRecorder()
+
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", w.Code)
+	}
+}
+
+func TestServer_ForeignEvent(t *testing.T) {
+	id := "123"
+	event := storage.Event{ID: id, Title: "Someone else's", UserID: "user2"}
+	mockApp := &mockApplication{events: map[string]storage.Event{id: event}}
+	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/events/"+id, nil)
+	req.Header.Set(userIDHeader, "user1")
+	w := httptest.NewRecorder()
+
+	server.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", w.Code)
+	}
+}
+
+func TestServer_Stream(t *testing.T) {
+	live := app.New(logger.New("error"), memorystorage.New(), app.Options{})
+	server := NewServer(&mockLogger{}, &mockApplication{events: map[string]storage.Event{}, live: live}, "localhost", "8080")
+	ts := httptest.NewServer(loggingMiddleware(&mockLogger{})(server.mux))
+	defer ts.Close()
+
+	t.Run("MissingUser", func(t *testing.T) {
+		resp, err := http.Get(ts.URL + "/api/events/stream")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/events/stream", nil)
+		req.Header.Set(userIDHeader, "user1")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("expected text/event-stream, got %q", ct)
+		}
+
+		// Заголовки ответа отправляются после подписки, поэтому изменение не потеряется.
+		start := time.Now()
+		created, _, err := live.CreateEvent(context.Background(), "user1", storage.Event{
+			Title: "Streamed", StartTime: start, EndTime: start.Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reader := bufio.NewReader(resp.Body)
+		var lines []string
+		for len(lines) < 2 {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("read stream: %v", err)
+			}
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if lines[0] != "event: created" {
+			t.Errorf("expected created event, got %q", lines[0])
+		}
+		var got eventDTO
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.ID != created.ID || got.Title != "Streamed" {
+			t.Errorf("unexpected streamed event: %+v", got)
+		}
+	})
+}
+
+func TestServer_Reminders(t *testing.T) {
+	mockApp := &mockApplication{events: make(map[string]storage.Event)}
+	server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/events", strings.NewReader(body))
+		req.Header.Set(userIDHeader, "user1")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"title":"Call","startTime":"2026-10-20T10:00:00Z","endTime":"2026-10-20T11:00:00Z",
+		"reminders":[{"offset":"24h","channel":"email"},{"offset":"15m"}]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body)
+	}
+	var resp eventDTO
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Reminders) != 2 || resp.Reminders[0].Offset != "24h0m0s" || resp.Reminders[0].Channel != "email" ||
+		resp.Reminders[1].Offset != "15m0s" {
+		t.Errorf("unexpected reminders: %+v", resp.Reminders)
+	}
+
+	w = post(`{"title":"Call","startTime":"2026-10-20T10:00:00Z","endTime":"2026-10-20T11:00:00Z",
+		"reminders":[{"offset":"one day"}]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid offset, got %d", w.Code)
+	}
+}
+
+func TestServer_TimeZone(t *testing.T) {
+	ctx := context.Background()
+	a := app.New(logger.New("error"), memorystorage.New(), app.Options{})
+	server := NewServer(&mockLogger{}, a, "localhost", "8080")
+	// 22:30 UTC 16 октября - уже 17 октября в Москве.
+	start := time.Date(2026, 10, 16, 22, 30, 0, 0, time.UTC)
+	_, _, err := a.CreateEvent(ctx, "user1", storage.Event{
+		Title: "Late call", StartTime: start, EndTime: start.Add(time.Hour), TimeZone: "Europe/Moscow",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(userIDHeader, "user1")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+	listDay := func(query string) []eventDTO {
+		t.Helper()
+		w := do(http.MethodGet, "/api/events/day?date=2026-10-17"+query, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp map[string][]eventDTO
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp["events"]
+	}
+
+	if events := listDay(""); len(events) != 0 {
+		t.Errorf("expected no events on 2026-10-17 in UTC, got %d", len(events))
+	}
+	events := listDay("&tz=Europe/Moscow")
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event on 2026-10-17 in Moscow, got %d", len(events))
+	}
+	if e := events[0]; e.TimeZone != "Europe/Moscow" || e.StartTime.Format(time.RFC3339) != "2026-10-17T01:30:00+03:00" {
+		t.Errorf("expected start in the event time zone, got %s %s", e.StartTime.Format(time.RFC3339), e.TimeZone)
+	}
+	if w := do(http.MethodGet, "/api/events/day?date=2026-10-17&tz=Mars/Olympus", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for unknown time zone, got %d", w.Code)
+	}
+
+	if w := do(http.MethodPut, "/api/settings", `{"timeZone":"Mars/Olympus"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for unknown time zone, got %d", w.Code)
+	}
+	if w := do(http.MethodPut, "/api/settings", `{"timeZone":"Europe/Moscow"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodGet, "/api/settings", ""); !strings.Contains(w.Body.String(), `"Europe/Moscow"`) {
+		t.Errorf("expected saved time zone, got %s", w.Body.String())
+	}
+	if events := listDay(""); len(events) != 1 {
+		t.Errorf("expected the user time zone to be used by default, got %d events", len(events))
+	}
+}
+
+func TestServer_ListEventsForWeekAndMonth(t *testing.T) {
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	tests := []struct {
+		target string
+		status int
+		start  time.Time
+	}{
+		{"/api/events/week?start=2026-10-17", http.StatusOK, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+		{"/api/events/week?start=2026-10-17&weekStart=sunday", http.StatusOK, time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC)},
+		{"/api/events/week?isoWeek=2026-W42&tz=Europe/Moscow", http.StatusOK, time.Date(2026, 10, 12, 0, 0, 0, 0, moscow)},
+		{"/api/events/week?isoWeek=2027-W01", http.StatusOK, time.Date(2027, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"/api/events/month?month=2026-10&tz=Europe/Moscow", http.StatusOK, time.Date(2026, 10, 1, 0, 0, 0, 0, moscow)},
+		{"/api/events/week?isoWeek=2027-W53", http.StatusBadRequest, time.Time{}},
+		{"/api/events/week?isoWeek=2026-42", http.StatusBadRequest, time.Time{}},
+		{"/api/events/week?start=2026-10-17&weekStart=someday", http.StatusBadRequest, time.Time{}},
+		{"/api/events/month?month=2026-13", http.StatusBadRequest, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			mockApp := &mockApplication{events: map[string]storage.Event{}}
+			server := NewServer(&mockLogger{}, mockApp, "localhost", "8080")
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set(userIDHeader, "user1")
+			w := httptest.NewRecorder()
+			server.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status == http.StatusOK && (len(mockApp.starts) != 1 || !mockApp.starts[0].Equal(tt.start)) {
+				t.Errorf("expected window to start at %v, got %v", tt.start, mockApp.starts)
+			}
+		})
+	}
+}
+
+func TestServer_AllDay(t *testing.T) {
+	a := app.New(logger.New("error"), memorystorage.New(), app.Options{})
+	server := NewServer(&mockLogger{}, a, "localhost", "8080")
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(userIDHeader, "user1")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/events",
+		`{"title":"Conference","timeZone":"Europe/Moscow","allDay":true,"startDate":"2026-10-16","endDate":"2026-10-19"}`)
+	if w.Code != http.StatusCreated && w.Code != http.StatusOK {
+		t.Fatalf("expected event to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	var created eventDTO
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if !created.AllDay || created.StartDate != "2026-10-16" || created.EndDate != "2026-10-19" {
+		t.Errorf("expected dates 2026-10-16..2026-10-19, got %+v", created)
+	}
+	if got := created.StartTime.Format(time.RFC3339); got != "2026-10-16T00:00:00+03:00" {
+		t.Errorf("expected start at local midnight, got %s", got)
+	}
+
+	w = do(http.MethodGet, "/api/events/day?date=2026-10-18&tz=Europe/Moscow", "")
+	if !strings.Contains(w.Body.String(), `"startDate":"2026-10-16"`) {
+		t.Errorf("expected the conference on its third day, got %s", w.Body.String())
+	}
+	if w := do(http.MethodGet, "/api/events/day?date=2026-10-19&tz=Europe/Moscow", ""); strings.Contains(
+		w.Body.String(), "Conference") {
+		t.Errorf("end date is exclusive, got %s", w.Body.String())
+	}
+
+	w = do(http.MethodPost, "/api/events", `{"title":"Broken","allDay":true,"startDate":"16.10.2026"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for malformed date, got %d", w.Code)
+	}
+}
+
+func TestServer_Invitations(t *testing.T) {
+	a := app.New(logger.New("error"), memorystorage.New(), app.Options{})
+	server := NewServer(&mockLogger{}, a, "localhost", "8080")
+	do := func(userID, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set(userIDHeader, userID)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+	listDay := func(userID string) string {
+		req := httptest.NewRequest(http.MethodGet, "/api/events/day?date=2026-10-17", nil)
+		req.Header.Set(userIDHeader, userID)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	w := do("owner", "/api/events",
+		`{"title":"Sync","startTime":"2026-10-17T10:00:00Z","endTime":"2026-10-17T11:00:00Z"}`)
+	var created eventDTO
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/events/" + created.ID
+
+	if w := do("alice", path+"/invite", `{"attendees":[{"userId":"bob"}]}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for a non-owner, got %d", w.Code)
+	}
+	w = do("owner", path+"/invite", `{"attendees":[{"userId":"alice"},{"userId":"bob","role":"optional"}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if link := w.Header().Get("Link"); link != "</api/v1/events/"+created.ID+`/attendees>; rel="successor-version"` {
+		t.Errorf("expected the attendees endpoint as successor, got %q", link)
+	}
+	if !strings.Contains(w.Body.String(), `{"userId":"bob","role":"optional","status":"needs-action"}`) {
+		t.Errorf("expected bob to be invited, got %s", w.Body.String())
+	}
+	if strings.Contains(listDay("alice"), "Sync") {
+		t.Error("pending invitation must not appear in listings")
+	}
+
+	w = do("alice", path+"/accept", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if link := w.Header().Get("Link"); link != "</api/v1/events/"+created.ID+`/rsvp>; rel="successor-version"` {
+		t.Errorf("expected the rsvp endpoint as successor, got %q", link)
+	}
+	if w := do("bob", path+"/tentative", ""); !strings.Contains(w.Body.String(), `"status":"tentative"`) {
+		t.Errorf("expected tentative response, got %s", w.Body.String())
+	}
+	if !strings.Contains(listDay("alice"), "Sync") || strings.Contains(listDay("bob"), "Sync") {
+		t.Error("expected the event in alice's listing only")
+	}
+	if w := do("alice", path+"/decline", ""); w.Code != http.StatusOK || strings.Contains(listDay("alice"), "Sync") {
+		t.Errorf("declined event must leave the listing, got %d", w.Code)
+	}
+
+	if w := do("carol", path+"/accept", ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 without invitation, got %d", w.Code)
+	}
+	if w := do("alice", path+"/maybe", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for unknown action, got %d", w.Code)
+	}
+	if w := do("owner", path+"/invite", `{"attendees":[{"userId":"owner"}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for inviting the owner, got %d", w.Code)
+	}
+}
+
+func TestServer_FreeBusy(t *testing.T) {
+	a := app.New(logger.New("error"), memorystorage.New(), app.Options{
+		FreeBusyShares: map[string][]string{"bob": {"alice"}},
+	})
+	server := NewServer(&mockLogger{}, a, "localhost", "8080")
+	do := func(userID, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		req.Header.Set(userIDHeader, userID)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	// 2026-10-19 - понедельник.
+	do("alice", "/api/events", `{"title":"Standup","startTime":"2026-10-19T09:00:00Z","endTime":"2026-10-19T10:00:00Z"}`)
+	do("bob", "/api/events", `{"title":"Review","startTime":"2026-10-19T09:30:00Z","endTime":"2026-10-19T11:00:00Z"}`)
+
+	w := do("alice", "/api/freebusy",
+		`{"userIds":["alice","bob"],"from":"2026-10-19T00:00:00Z","to":"2026-10-20T00:00:00Z"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	want := `{"busy":{"alice":[{"start":"2026-10-19T09:00:00Z","end":"2026-10-19T10:00:00Z"}],` +
+		`"bob":[{"start":"2026-10-19T09:30:00Z","end":"2026-10-19T11:00:00Z"}]}}`
+	if strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("unexpected free/busy: %s", w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "Standup") {
+		t.Error("free/busy must not disclose event titles")
+	}
+
+	w = do("alice", "/api/freebusy/slots", `{"userIds":["alice","bob"],"from":"2026-10-19T00:00:00Z",`+
+		`"to":"2026-10-21T00:00:00Z","duration":"1h","count":2,"workStart":"09:00","workEnd":"12:00"}`)
+	want = `{"slots":[{"start":"2026-10-19T11:00:00Z","end":"2026-10-19T12:00:00Z"},` +
+		`{"start":"2026-10-20T09:00:00Z","end":"2026-10-20T10:00:00Z"}]}`
+	if strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("unexpected slots: %d %s", w.Code, w.Body.String())
+	}
+
+	for _, body := range []string{
+		`{"from":"2026-10-20T00:00:00Z","to":"2026-10-19T00:00:00Z"}`,
+		`{"from":"2026-10-19T00:00:00Z","to":"2026-10-20T00:00:00Z","duration":"1h","workStart":"9am"}`,
+		`{"from":"2026-10-19T00:00:00Z","to":"2026-10-20T00:00:00Z","duration":"soon"}`,
+	} {
+		target := "/api/freebusy/slots"
+		if !strings.Contains(body, "duration") {
+			target = "/api/freebusy"
+		}
+		if w := do("alice", target, body); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %s, got %d", body, w.Code)
+		}
+	}
+
+	w = do("carol", "/api/freebusy", `{"userIds":["bob"],"from":"2026-10-19T00:00:00Z","to":"2026-10-20T00:00:00Z"}`)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for free/busy not shared with carol, got %d", w.Code)
+	}
+}
+
+func TestServer_OverlapPolicy(t *testing.T) {
+	a := app.New(logger.New("error"), memorystorage.New(), app.Options{
+		UserOverlap: map[string]storage.OverlapPolicy{"bob": storage.OverlapWarn},
+	})
+	server := NewServer(&mockLogger{}, a, "localhost", "8080")
+	do := func(method, userID, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(userIDHeader, userID)
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+	const meeting = `{"title":"Meeting","startTime":"2026-10-19T10:00:00Z","endTime":"2026-10-19T11:00:00Z"}`
+	const hold = `{"title":"Hold","startTime":"2026-10-19T10:30:00Z","endTime":"2026-10-19T11:30:00Z"`
+
+	do(http.MethodPost, "alice", "/api/events", meeting)
+	if w := do(http.MethodPost, "alice", "/api/events", hold+"}"); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 by default, got %d", w.Code)
+	}
+	w := do(http.MethodPost, "alice", "/api/events", hold+`,"transparency":"free"}`)
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"transparency":"free"`) {
+		t.Errorf("expected free hold to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "alice", "/api/events", hold+`,"transparency":"maybe"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for unknown transparency, got %d", w.Code)
+	}
+
+	var first eventDTO
+	_ = json.NewDecoder(do(http.MethodPost, "bob", "/api/events", meeting).Body).Decode(&first)
+	w = do(http.MethodPost, "bob", "/api/events", hold+"}")
+	var second eventDTO
+	if err := json.NewDecoder(w.Body).Decode(&second); err != nil || w.Code != http.StatusCreated {
+		t.Fatalf("expected overlapping event to be created with a warning, got %d, %v", w.Code, err)
+	}
+	if len(second.Conflicts) != 1 || second.Conflicts[0] != first.ID {
+		t.Errorf("expected conflict with %s, got %v", first.ID, second.Conflicts)
+	}
+	w = do(http.MethodPut, "bob", "/api/events/"+first.ID, `{"title":"Meeting","startTime":"2026-10-19T11:00:00Z",`+
+		`"endTime":"2026-10-19T12:00:00Z"}`)
+	if !strings.Contains(w.Body.String(), `"conflicts":["`+second.ID+`"]`) {
+		t.Errorf("expected update to report conflict with %s, got %s", second.ID, w.Body.String())
+	}
+}
+
+func TestServer_Trash(t *testing.T) {
+	a := app.New(logger.New("error"), memorystorage.New(), app.Options{})
+	server := NewServer(&mockLogger{}, a, "localhost", "8080")
+	do := func(method, userID, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(userIDHeader, userID)
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+	const meeting = `{"title":"Meeting","startTime":"2026-10-19T10:00:00Z","endTime":"2026-10-19T11:00:00Z"}`
+
+	var created eventDTO
+	_ = json.NewDecoder(do(http.MethodPost, "alice", "/api/events", meeting).Body).Decode(&created)
+	if w := do(http.MethodDelete, "alice", "/api/events/"+created.ID, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204 on delete, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "alice", "/api/events/"+created.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a trashed event, got %d", w.Code)
+	}
+
+	var trash struct {
+		Events []eventDTO `json:"events"`
+	}
+	w := do(http.MethodGet, "alice", "/api/trash", "")
+	if err := json.NewDecoder(w.Body).Decode(&trash); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected trash listing, got %d, %v", w.Code, err)
+	}
+	if len(trash.Events) != 1 || trash.Events[0].ID != created.ID || trash.Events[0].DeletedAt == nil {
+		t.Fatalf("expected the deleted event with deletedAt in trash, got %+v", trash.Events)
+	}
+
+	if w := do(http.MethodGet, "alice", "/api/events/"+created.ID+"/restore", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405 for GET restore, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "bob", "/api/events/"+created.ID+"/restore", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for another user's trash, got %d", w.Code)
+	}
+	do(http.MethodPost, "alice", "/api/events", meeting)
+	if w := do(http.MethodPost, "alice", "/api/events/"+created.ID+"/restore", ""); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 when the slot is taken, got %d", w.Code)
+	}
+
+	a = app.New(logger.New("error"), memorystorage.New(), app.Options{Overlap: storage.OverlapAllow})
+	server = NewServer(&mockLogger{}, a, "localhost", "8080")
+	_ = json.NewDecoder(do(http.MethodPost, "alice", "/api/events", meeting).Body).Decode(&created)
+	do(http.MethodDelete, "alice", "/api/events/"+created.ID, "")
+	w = do(http.MethodPost, "alice", "/api/events/"+created.ID+"/restore", "")
+	var restored eventDTO
+	if err := json.NewDecoder(w.Body).Decode(&restored); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected restore to succeed, got %d, %v", w.Code, err)
+	}
+	if restored.ID != created.ID || restored.DeletedAt != nil || w.Header().Get("ETag") == "" {
+		t.Errorf("expected the restored event with an ETag, got %+v", restored)
+	}
+	if w := do(http.MethodGet, "alice", "/api/events/"+created.ID, ""); w.Code != http.StatusOK {
+		t.Errorf("expected restored event to be readable, got %d", w.Code)
+	}
+}
+
+func TestServer_EventHistory(t *testing.T) {
+	a := app.New(logger.New("error"), memorystorage.New(), app.Options{})
+	server := NewServer(&mockLogger{}, a, "localhost", "8080")
+	do := func(method, userID, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(userIDHeader, userID)
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		return w
+	}
+
+	var created eventDTO
+	_ = json.NewDecoder(do(http.MethodPost, "alice", "/api/events",
+		`{"title":"Sync","startTime":"2026-10-19T10:00:00Z","endTime":"2026-10-19T11:00:00Z"}`).Body).Decode(&created)
+	do(http.MethodPut, "alice", "/api/events/"+created.ID,
+		`{"title":"Planning","startTime":"2026-10-19T10:00:00Z","endTime":"2026-10-19T11:00:00Z"}`)
+
+	w := do(http.MethodGet, "alice", "/api/events/"+created.ID+"/history", "")
+	var history struct {
+		Entries []auditEntryDTO `json:"entries"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected history, got %d, %v", w.Code, err)
+	}
+	if len(history.Entries) != 2 || history.Entries[0].Action != "created" || history.Entries[1].Actor != "alice" {
+		t.Fatalf("expected create and update by alice, got %+v", history.Entries)
+	}
+	want := []fieldChangeDTO{{Field: "title", Old: "Sync", New: "Planning"}}
+	if fmt.Sprint(history.Entries[1].Changes) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, history.Entries[1].Changes)
+	}
+
+	if w := do(http.MethodGet, "bob", "/api/events/"+created.ID+"/history", ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403 for a stranger, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "alice", "/api/events/"+created.ID+"/history", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405 for POST, got %d", w.Code)
+	}
+}
+
+func TestServer_CreateEvent_IgnoresClientID(t *testing.T) {
+	a := app.New(logger.New("error"), memorystorage.New(), app.Options{Overlap: storage.OverlapAllow})
+	server := NewServer(&mockLogger{}, a, "localhost", "8080")
+	create := func(userID string) eventDTO {
+		req := httptest.NewRequest(http.MethodPost, "/api/events", strings.NewReader(
+			`{"id":"x","title":"Sync","startTime":"2026-10-19T10:00:00Z","endTime":"2026-10-19T11:00:00Z"}`))
+		req.Header.Set(userIDHeader, userID)
+		w := httptest.NewRecorder()
+		server.mux.ServeHTTP(w, req)
+		var d eventDTO
+		if err := json.NewDecoder(w.Body).Decode(&d); err != nil || w.Code != http.StatusCreated {
+			t.Fatalf("expected event to be created, got %d, %v", w.Code, err)
+		}
+		return d
+	}
+
+	alice, mallory := create("alice"), create("mallory")
+	if alice.ID == "x" || mallory.ID == "x" || alice.ID == mallory.ID {
+		t.Errorf("expected server-generated IDs, got %q and %q", alice.ID, mallory.ID)
+	}
+	if ev, err := a.GetEventByID(context.Background(), "alice", alice.ID); err != nil || ev.UserID != "alice" {
+		t.Errorf("expected alice's event to be intact, got %+v, %v", ev, err)
+	}
 }
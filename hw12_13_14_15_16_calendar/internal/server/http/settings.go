@@ -0,0 +1,54 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// settingsDTO - настройки пользователя: timeZone - часовой пояс IANA, пустой - UTC;
+// weekStart - первый день недели ("monday", "sunday"), пустой - по региону locale.
+type settingsDTO struct {
+	TimeZone  string `json:"timeZone"`
+	Locale    string `json:"locale,omitempty"`
+	WeekStart string `json:"weekStart,omitempty"`
+}
+
+// handleSettings читает (GET) и заменяет (PUT) настройки вызывающего пользователя.
+func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	var settings storage.UserSettings
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		settings, err = s.app.GetSettings(r.Context(), userID)
+	case http.MethodPut:
+		var d settingsDTO
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		settings, err = s.app.UpdateSettings(r.Context(), userID, storage.UserSettings{
+			TimeZone:  d.TimeZone,
+			Locale:    d.Locale,
+			WeekStart: d.WeekStart,
+		})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(settingsDTO{
+		TimeZone:  settings.TimeZone,
+		Locale:    settings.Locale,
+		WeekStart: settings.WeekStart,
+	})
+}
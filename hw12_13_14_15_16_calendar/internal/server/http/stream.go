@@ -0,0 +1,96 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/app"
+)
+
+// streamHeartbeat - период комментариев-пингов, не дающих прокси закрыть простаивающее соединение.
+const streamHeartbeat = 15 * time.Second
+
+// handleStream отдает изменения событий пользователя как Server-Sent Events.
+// Параметры: from, to (RFC3339 или YYYY-MM-DD), tz - часовой пояс дат. Каждое изменение - событие
+// с именем created/updated/deleted и JSON события в data. Медленный клиент
+// получает событие error и отключается.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	loc, ok := s.location(w, r, userID)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	var filter app.WatchFilter
+	var err error
+	if filter.From, err = parseTimeParam(q.Get("from"), loc); err != nil {
+		http.Error(w, "invalid from format, want RFC3339 or YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if filter.To, err = parseTimeParam(q.Get("to"), loc); err != nil {
+		http.Error(w, "invalid to format, want RFC3339 or YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := s.app.WatchEvents(r.Context(), userID, filter)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.closing:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case change, ok := <-sub.C:
+			if !ok {
+				if errors.Is(sub.Err(), app.ErrSlowConsumer) {
+					_, _ = fmt.Fprintf(w, "event: error\ndata: %s\n\n", sub.Err())
+					flusher.Flush()
+				}
+				return
+			}
+			data, err := json.Marshal(toDTO(change.Event))
+			if err != nil {
+				s.logger.Error("encode stream event: " + err.Error())
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", change.Type, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
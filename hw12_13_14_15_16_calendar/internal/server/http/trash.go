@@ -0,0 +1,45 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleTrash обрабатывает GET /api/trash: удаленные события вызывающего пользователя,
+// недавно удаленные - первыми. Время удаления отдается в поле deletedAt.
+func (s *Server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	userID, ok := callerID(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := s.app.ListTrash(r.Context(), userID)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string][]eventDTO{"events": toDTOList(list)})
+}
+
+// handleRestore обрабатывает POST /api/events/{id}/restore: возвращает событие из корзины.
+// Восстановление проверяется на пересечения так же, как создание события.
+func (s *Server) handleRestore(w http.ResponseWriter, r *http.Request, userID, id string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	restored, conflicts, err := s.app.RestoreEvent(r.Context(), userID, id)
+	if err != nil {
+		s.writeStorageError(w, err)
+		return
+	}
+	w.Header().Set("ETag", etag(restored.Version))
+	res := toDTO(restored)
+	res.Conflicts = conflicts
+	_ = json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// DateLayout - формат даты событий на весь день.
+const DateLayout = time.DateOnly
+
+// NormalizeAllDay выравнивает событие на весь день по полуночи его часового пояса:
+// начало - на полночь дня начала, конец - на полночь следующего за последним днем.
+// Конец исключается, поэтому однодневное событие длится ровно сутки. Если прозрачность
+// не задана, событие считается свободным: праздник или день рождения не занимают время,
+// а отпуск или выездная встреча помечаются TransparencyBusy явно.
+// Для обычного события возвращает его без изменений.
+func NormalizeAllDay(e Event) Event {
+	if !e.AllDay {
+		return e
+	}
+	if e.Transparency == "" {
+		e.Transparency = TransparencyFree
+	}
+	loc := e.Location()
+	start, _ := DayBounds(e.StartTime.In(loc))
+	end, _ := DayBounds(e.EndTime.In(loc))
+	if end.Before(e.EndTime) {
+		end = end.AddDate(0, 0, 1)
+	}
+	if !end.After(start) {
+		end = start.AddDate(0, 0, 1)
+	}
+	e.StartTime, e.EndTime = start, end
+	return e
+}
+
+// ParseDate разбирает дату вида 2026-10-17 как полночь в часовом поясе loc.
+func ParseDate(s string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(DateLayout, s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+// Dates возвращает первый и следующий за последним дни события на весь день
+// в его часовом поясе в формате DateLayout.
+func (e Event) Dates() (string, string) {
+	loc := e.Location()
+	return e.StartTime.In(loc).Format(DateLayout), e.EndTime.In(loc).Format(DateLayout)
+}
+
+// WithDates задает границы события на весь день датами в его часовом поясе:
+// endDate - день после последнего; пустой endDate означает однодневное событие.
+// Пустой startDate оставляет StartTime и EndTime без изменений.
+func (e Event) WithDates(startDate, endDate string) (Event, error) {
+	if startDate == "" {
+		return e, nil
+	}
+	loc := e.Location()
+	start, err := ParseDate(startDate, loc)
+	if err != nil {
+		return Event{}, err
+	}
+	end := start.AddDate(0, 0, 1)
+	if endDate != "" {
+		if end, err = ParseDate(endDate, loc); err != nil {
+			return Event{}, err
+		}
+	}
+	e.StartTime, e.EndTime = start, end
+	return e, nil
+}
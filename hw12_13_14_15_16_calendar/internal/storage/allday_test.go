@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeAllDay(t *testing.T) {
+	msk, _ := time.LoadLocation("Europe/Moscow")
+	day := time.Date(2026, 11, 4, 0, 0, 0, 0, msk)
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		days       int
+	}{
+		{"Midnights", day, day.AddDate(0, 0, 3), 3},
+		{"InsideDay", day.Add(10 * time.Hour), day.Add(12 * time.Hour), 1},
+		{"EndBeforeStart", day.Add(10 * time.Hour), day.Add(-time.Hour), 1},
+		{"EndInsideLastDay", day.Add(10 * time.Hour), day.AddDate(0, 0, 1).Add(time.Hour), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NormalizeAllDay(Event{StartTime: tt.start.UTC(), EndTime: tt.end.UTC(), TimeZone: "Europe/Moscow", AllDay: true})
+			if !e.StartTime.Equal(day) || !e.EndTime.Equal(day.AddDate(0, 0, tt.days)) {
+				t.Errorf("expected %d days from %v, got [%v, %v)", tt.days, day, e.StartTime, e.EndTime)
+			}
+		})
+	}
+
+	if e := NormalizeAllDay(Event{StartTime: day, EndTime: day, AllDay: true}); e.Transparency != TransparencyFree {
+		t.Errorf("expected all-day event to be free by default, got %q", e.Transparency)
+	}
+	busy := Event{StartTime: day, EndTime: day, AllDay: true, Transparency: TransparencyBusy}
+	if e := NormalizeAllDay(busy); e.Transparency != TransparencyBusy {
+		t.Errorf("expected explicit transparency to be kept, got %q", e.Transparency)
+	}
+
+	timed := Event{StartTime: day.Add(10 * time.Hour), EndTime: day.Add(11 * time.Hour)}
+	if got := NormalizeAllDay(timed); !got.StartTime.Equal(timed.StartTime) || !got.EndTime.Equal(timed.EndTime) {
+		t.Errorf("timed event must not change, got %+v", got)
+	}
+}
+
+func TestEvent_WithDates(t *testing.T) {
+	e := Event{TimeZone: "Asia/Tokyo", AllDay: true}
+	got, err := e.WithDates("2026-11-04", "2026-11-06")
+	if err != nil {
+		t.Fatalf("WithDates failed: %v", err)
+	}
+	if got.StartTime.UTC() != time.Date(2026, 11, 3, 15, 0, 0, 0, time.UTC) || got.EndTime.Sub(got.StartTime) != 48*time.Hour {
+		t.Errorf("unexpected bounds [%v, %v)", got.StartTime, got.EndTime)
+	}
+	if start, end := got.Dates(); start != "2026-11-04" || end != "2026-11-06" {
+		t.Errorf("expected 2026-11-04..2026-11-06, got %s..%s", start, end)
+	}
+
+	if got, _ := e.WithDates("2026-11-04", ""); got.EndTime.Sub(got.StartTime) != 24*time.Hour {
+		t.Errorf("empty end date must mean one day, got [%v, %v)", got.StartTime, got.EndTime)
+	}
+	if _, err := e.WithDates("04.11.2026", ""); err == nil {
+		t.Error("expected error for malformed date")
+	}
+}
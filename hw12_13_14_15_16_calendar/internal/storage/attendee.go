@@ -0,0 +1,104 @@
+package storage
+
+import "fmt"
+
+// AttendeeRole - роль участника встречи.
+type AttendeeRole string
+
+const (
+	RoleRequired AttendeeRole = "required"
+	RoleOptional AttendeeRole = "optional"
+)
+
+// RSVPStatus - ответ участника на приглашение (PARTSTAT из RFC 5545).
+type RSVPStatus string
+
+const (
+	StatusNeedsAction RSVPStatus = "needs-action"
+	StatusAccepted    RSVPStatus = "accepted"
+	StatusDeclined    RSVPStatus = "declined"
+	StatusTentative   RSVPStatus = "tentative"
+)
+
+// IsResponse сообщает, может ли участник ответить на приглашение этим статусом.
+func (s RSVPStatus) IsResponse() bool {
+	return s == StatusAccepted || s == StatusDeclined || s == StatusTentative
+}
+
+// Attendee - приглашенный на событие пользователь. Владелец события участником не считается.
+type Attendee struct {
+	UserID string
+	Role   AttendeeRole
+	Status RSVPStatus
+}
+
+// Attendee возвращает участника userID, если он приглашен на событие.
+func (e Event) Attendee(userID string) (Attendee, bool) {
+	for _, a := range e.Attendees {
+		if a.UserID == userID {
+			return a, true
+		}
+	}
+	return Attendee{}, false
+}
+
+// ListedFor сообщает, попадает ли событие в списки пользователя userID:
+// оно принадлежит ему или он принял приглашение.
+func (e Event) ListedFor(userID string) bool {
+	if e.UserID == userID {
+		return true
+	}
+	a, ok := e.Attendee(userID)
+	return ok && a.Status == StatusAccepted
+}
+
+// VisibleTo сообщает, может ли пользователь userID просматривать событие: владельцу
+// и приглашенным оно доступно независимо от ответа.
+func (e Event) VisibleTo(userID string) bool {
+	_, invited := e.Attendee(userID)
+	return e.UserID == userID || invited
+}
+
+// Recipients возвращает пользователей, которым отправляются напоминания о событии:
+// владельца и участников, не отклонивших приглашение.
+func (e Event) Recipients() []string {
+	recipients := []string{e.UserID}
+	for _, a := range e.Attendees {
+		if a.Status != StatusDeclined {
+			recipients = append(recipients, a.UserID)
+		}
+	}
+	return recipients
+}
+
+// MergeAttendees готовит участников next к сохранению поверх события current (nil при создании).
+// Роль по умолчанию - RoleRequired. Участник, уже приглашенный на current, сохраняет свой ответ,
+// новый получает StatusNeedsAction: отвечает на приглашение только сам участник.
+func MergeAttendees(current *Event, next Event) ([]Attendee, error) {
+	if len(next.Attendees) == 0 {
+		return nil, nil
+	}
+	merged := make([]Attendee, 0, len(next.Attendees))
+	seen := make(map[string]bool, len(next.Attendees))
+	for _, a := range next.Attendees {
+		if a.UserID == "" || a.UserID == next.UserID || seen[a.UserID] {
+			return nil, fmt.Errorf("%w: attendee %q is empty, the owner or duplicated", ErrInvalidEvent, a.UserID)
+		}
+		seen[a.UserID] = true
+		switch a.Role {
+		case "":
+			a.Role = RoleRequired
+		case RoleRequired, RoleOptional:
+		default:
+			return nil, fmt.Errorf("%w: unknown attendee role %q", ErrInvalidEvent, a.Role)
+		}
+		a.Status = StatusNeedsAction
+		if current != nil {
+			if old, ok := current.Attendee(a.UserID); ok {
+				a.Status = old.Status
+			}
+		}
+		merged = append(merged, a)
+	}
+	return merged, nil
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestMergeAttendees(t *testing.T) {
+	current := Event{UserID: "owner", Attendees: []Attendee{
+		{UserID: "alice", Role: RoleRequired, Status: StatusAccepted},
+		{UserID: "bob", Role: RoleRequired, Status: StatusDeclined},
+	}}
+	next := Event{UserID: "owner", Attendees: []Attendee{
+		{UserID: "alice", Role: RoleOptional, Status: StatusDeclined},
+		{UserID: "carol", Status: StatusAccepted},
+	}}
+
+	merged, err := MergeAttendees(&current, next)
+	if err != nil {
+		t.Fatalf("MergeAttendees failed: %v", err)
+	}
+	want := []Attendee{
+		{UserID: "alice", Role: RoleOptional, Status: StatusAccepted},
+		{UserID: "carol", Role: RoleRequired, Status: StatusNeedsAction},
+	}
+	if fmt.Sprint(merged) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, merged)
+	}
+
+	for _, bad := range [][]Attendee{
+		{{UserID: ""}},
+		{{UserID: "owner"}},
+		{{UserID: "alice"}, {UserID: "alice"}},
+		{{UserID: "alice", Role: "chair"}},
+	} {
+		if _, err := MergeAttendees(nil, Event{UserID: "owner", Attendees: bad}); !errors.Is(err, ErrInvalidEvent) {
+			t.Errorf("expected ErrInvalidEvent for %v, got %v", bad, err)
+		}
+	}
+}
+
+func TestEvent_Visibility(t *testing.T) {
+	e := Event{UserID: "owner", Attendees: []Attendee{
+		{UserID: "alice", Status: StatusAccepted},
+		{UserID: "bob", Status: StatusDeclined},
+		{UserID: "carol", Status: StatusTentative},
+	}}
+
+	tests := []struct {
+		userID          string
+		listed, visible bool
+	}{
+		{"owner", true, true},
+		{"alice", true, true},
+		{"bob", false, true},
+		{"carol", false, true},
+		{"dave", false, false},
+	}
+	for _, tt := range tests {
+		if e.ListedFor(tt.userID) != tt.listed || e.VisibleTo(tt.userID) != tt.visible {
+			t.Errorf("%s: expected listed %v and visible %v", tt.userID, tt.listed, tt.visible)
+		}
+	}
+	if got := fmt.Sprint(e.Recipients()); got != "[owner alice carol]" {
+		t.Errorf("declined attendees must not be notified, got %s", got)
+	}
+}
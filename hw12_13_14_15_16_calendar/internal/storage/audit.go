@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuditAction - вид изменения события в журнале.
+type AuditAction string
+
+const (
+	AuditCreated  AuditAction = "created"
+	AuditUpdated  AuditAction = "updated"
+	AuditDeleted  AuditAction = "deleted"
+	AuditRestored AuditAction = "restored"
+)
+
+// FieldChange - изменение одного поля события. Old и New - значения в текстовом виде;
+// пустое значение означает, что поле не задано.
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// AuditEntry - запись журнала изменений события: кто (Actor), когда (At) и что изменил.
+type AuditEntry struct {
+	ID      string
+	EventID string
+	Actor   string
+	Action  AuditAction
+	At      time.Time
+	Changes []FieldChange
+}
+
+// auditFields - поля события, изменения которых попадают в журнал, в порядке вывода.
+// Version и DeletedAt - служебные поля: их изменение отражает само действие.
+var auditFields = []struct {
+	name   string
+	format func(e Event) string
+}{
+	{"title", func(e Event) string { return e.Title }},
+	{"startTime", func(e Event) string { return formatAuditTime(e.StartTime) }},
+	{"endTime", func(e Event) string { return formatAuditTime(e.EndTime) }},
+	{"description", func(e Event) string { return e.Description }},
+	{"userId", func(e Event) string { return e.UserID }},
+	{"timeZone", func(e Event) string { return e.TimeZone }},
+	{"allDay", func(e Event) string { return formatAuditFlag(e.AllDay) }},
+	{"transparency", func(e Event) string { return string(e.Transparency) }},
+	{"rrule", func(e Event) string {
+		if e.RRule == nil {
+			return ""
+		}
+		return e.RRule.String()
+	}},
+	{"exDates", func(e Event) string {
+		list := make([]string, 0, len(e.ExDates))
+		for _, d := range e.ExDates {
+			list = append(list, formatAuditTime(d))
+		}
+		return strings.Join(list, ", ")
+	}},
+	{"reminders", func(e Event) string {
+		list := make([]string, 0, len(e.Reminders))
+		for _, r := range e.Reminders {
+			if r.Channel == "" {
+				list = append(list, r.Offset.String())
+			} else {
+				list = append(list, r.Offset.String()+" "+r.Channel)
+			}
+		}
+		return strings.Join(list, ", ")
+	}},
+	{"attendees", func(e Event) string {
+		list := make([]string, 0, len(e.Attendees))
+		for _, a := range e.Attendees {
+			list = append(list, fmt.Sprintf("%s (%s, %s)", a.UserID, a.Role, a.Status))
+		}
+		return strings.Join(list, ", ")
+	}},
+}
+
+// DiffEvents возвращает изменения полей между версиями события before и after.
+// nil вместо before означает создание события, вместо after - удаление.
+func DiffEvents(before, after *Event) []FieldChange {
+	changes := []FieldChange{}
+	for _, f := range auditFields {
+		var old, updated string
+		if before != nil {
+			old = f.format(*before)
+		}
+		if after != nil {
+			updated = f.format(*after)
+		}
+		if old != updated {
+			changes = append(changes, FieldChange{Field: f.name, Old: old, New: updated})
+		}
+	}
+	return changes
+}
+
+func formatAuditTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatAuditFlag(v bool) string {
+	if !v {
+		return ""
+	}
+	return "true"
+}
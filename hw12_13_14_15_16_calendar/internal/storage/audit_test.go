@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDiffEvents(t *testing.T) {
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	before := Event{
+		ID: "1", Title: "Sync", StartTime: start, EndTime: start.Add(time.Hour), UserID: "owner",
+		Transparency: TransparencyBusy, Reminders: []Reminder{{ID: "r1", Offset: 15 * time.Minute}},
+		Attendees: []Attendee{{UserID: "alice", Role: RoleRequired, Status: StatusNeedsAction}},
+		Version:   1,
+	}
+	after := before
+	after.Title = "Weekly sync"
+	after.StartTime, after.EndTime = start.Add(time.Hour), start.Add(2*time.Hour)
+	after.RRule = &RecurrenceRule{Freq: FrequencyWeekly, Interval: 1}
+	after.Attendees = []Attendee{{UserID: "alice", Role: RoleRequired, Status: StatusAccepted}}
+	after.Version = 2
+
+	got := DiffEvents(&before, &after)
+	want := []FieldChange{
+		{Field: "title", Old: "Sync", New: "Weekly sync"},
+		{Field: "startTime", Old: "2026-10-19T10:00:00Z", New: "2026-10-19T11:00:00Z"},
+		{Field: "endTime", Old: "2026-10-19T11:00:00Z", New: "2026-10-19T12:00:00Z"},
+		{Field: "rrule", New: "FREQ=WEEKLY"},
+		{Field: "attendees", Old: "alice (required, needs-action)", New: "alice (required, accepted)"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if got := DiffEvents(&before, &before); len(got) != 0 {
+		t.Errorf("expected no changes for the same event, got %v", got)
+	}
+	created := DiffEvents(nil, &before)
+	if len(created) != 7 || created[0] != (FieldChange{Field: "title", New: "Sync"}) {
+		t.Errorf("expected every set field as new on create, got %v", created)
+	}
+	deleted := DiffEvents(&before, nil)
+	if len(deleted) != len(created) || deleted[6] != (FieldChange{Field: "attendees", Old: "alice (required, needs-action)"}) {
+		t.Errorf("expected every set field as old on delete, got %v", deleted)
+	}
+}
@@ -7,5 +7,29 @@ var (
 
 	ErrDateBusy = errors.New("date is busy")
 
+	// ErrAlreadyExists - событие с таким ID уже есть (в том числе в корзине или у другого пользователя).
+	ErrAlreadyExists = errors.New("event already exists")
+
 	ErrInvalidEvent = errors.New("invalid event")
+
+	// ErrForbidden - событие существует, но принадлежит другому пользователю
+	// (или пользователь не приглашен на него).
+	ErrForbidden = errors.New("access to event is forbidden")
+
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrVersionConflict - событие было изменено после того, как клиент получил его версию.
+	ErrVersionConflict = errors.New("event version conflict")
+
+	// ErrInvalidQuery - поисковый запрос не содержит ни одного слова.
+	ErrInvalidQuery = errors.New("invalid search query")
+
+	// ErrInvalidTimeZone - имя часового пояса не найдено в базе IANA.
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+
+	// ErrInvalidWeekStart - первый день недели не является названием дня недели.
+	ErrInvalidWeekStart = errors.New("invalid week start")
+
+	// ErrInvalidRange - интервал запроса занятости или параметры поиска слотов некорректны.
+	ErrInvalidRange = errors.New("invalid time range")
 )
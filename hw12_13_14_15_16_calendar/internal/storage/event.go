@@ -3,12 +3,37 @@ package storage
 import "time"
 
 type Event struct {
-	ID          string
+	ID string
+	// UID - идентификатор события iCalendar, уникальный среди событий владельца (включая корзину).
+	// Хранилище сохраняет пустой как ID; при изменении события UID не меняется.
+	UID         string
 	Title       string
 	StartTime   time.Time
 	EndTime     time.Time
 	Description string
 	UserID      string
-	NotifyAt    *time.Time
-	Notified    bool
+	// TimeZone - часовой пояс IANA, в котором задано событие; пустой - UTC.
+	// В нем повторения сохраняют время начала при переходе на летнее время.
+	TimeZone string
+	// AllDay - событие на весь день: начало и конец выровнены по полуночи
+	// в TimeZone, конец исключается (см. NormalizeAllDay).
+	AllDay bool
+	// Transparency - занимает ли событие время; хранилище сохраняет пустую как TransparencyBusy,
+	// а у события на весь день - как TransparencyFree.
+	Transparency Transparency
+
+	// RRule - правило повторения; для одиночного события nil.
+	RRule *RecurrenceRule
+	// ExDates - начала вхождений, исключенных из серии.
+	ExDates []time.Time
+	// Reminders - напоминания о событии (для серии - о каждом вхождении).
+	Reminders []Reminder
+	// Attendees - приглашенные участники. Ответы участников не меняют Version.
+	Attendees []Attendee
+
+	// Version - номер версии события, увеличивается при каждом изменении.
+	// В UpdateEvent - ожидаемая текущая версия; 0 отключает проверку.
+	Version int64
+	// DeletedAt - когда событие перенесено в корзину; nil - событие не удалено.
+	DeletedAt *time.Time
 }
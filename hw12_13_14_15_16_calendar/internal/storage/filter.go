@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPageLimit - размер страницы, если лимит не задан.
+	DefaultPageLimit = 50
+	// MaxPageLimit - максимальный размер страницы.
+	MaxPageLimit = 500
+)
+
+// EventFilter - параметры выборки ListEvents.
+// В выдачу попадают вхождения, пересекающиеся с [From, To), в том числе начавшиеся до From.
+// Нулевые From/To означают отсутствие соответствующей границы.
+type EventFilter struct {
+	UserID string
+	From   time.Time
+	To     time.Time
+	// Query - подстрока заголовка без учета регистра.
+	Query  string
+	Limit  int
+	Cursor string
+}
+
+// EventPage - страница результатов ListEvents. Пустой NextCursor означает последнюю страницу.
+type EventPage struct {
+	Events     []Event
+	NextCursor string
+}
+
+// Cursor - позиция в выдаче, упорядоченной по (StartTime, ID).
+type Cursor struct {
+	StartTime time.Time
+	ID        string
+}
+
+// IsZero сообщает, что курсор не задан (первая страница).
+func (c Cursor) IsZero() bool {
+	return c.ID == "" && c.StartTime.IsZero()
+}
+
+// String кодирует курсор в непрозрачную для клиента строку.
+func (c Cursor) String() string {
+	raw := strconv.FormatInt(c.StartTime.UnixNano(), 10) + ":" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor разбирает строку, полученную из Cursor.String. Пустая строка - нулевой курсор.
+func ParseCursor(s string) (Cursor, error) {
+	if s == "" {
+		return Cursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return Cursor{StartTime: time.Unix(0, n).UTC(), ID: id}, nil
+}
+
+// PageLimit возвращает размер страницы с учетом значения по умолчанию и максимума.
+func (f EventFilter) PageLimit() int {
+	switch {
+	case f.Limit <= 0:
+		return DefaultPageLimit
+	case f.Limit > MaxPageLimit:
+		return MaxPageLimit
+	}
+	return f.Limit
+}
+
+// MatchesQuery проверяет совпадение заголовка события с Query.
+func (f EventFilter) MatchesQuery(e Event) bool {
+	return f.Query == "" || strings.Contains(strings.ToLower(e.Title), strings.ToLower(f.Query))
+}
+
+// precedes сообщает, что вхождение e идет в выдаче строго после курсора.
+func (c Cursor) precedes(e Event) bool {
+	if c.IsZero() {
+		return true
+	}
+	if !e.StartTime.Equal(c.StartTime) {
+		return e.StartTime.After(c.StartTime)
+	}
+	return e.ID > c.ID
+}
+
+// BuildPage разворачивает события во вхождения, применяет фильтр и курсор и формирует страницу.
+// Хранилища передают сюда кандидатов: фильтрация по пользователю выполняется заранее.
+func BuildPage(events []Event, f EventFilter) (EventPage, error) {
+	cursor, err := ParseCursor(f.Cursor)
+	if err != nil {
+		return EventPage{}, err
+	}
+	limit := f.PageLimit()
+
+	var result []Event
+	for _, e := range events {
+		if !f.MatchesQuery(e) {
+			continue
+		}
+		// От каждой серии достаточно limit+1 вхождений после курсора.
+		// Вхождения, начавшиеся раньше курсора, уже выданы на предыдущих страницах.
+		taken := 0
+		e.eachOccurrenceOverlapping(f.From, f.To, cursor.StartTime, func(occ Event) bool {
+			if !cursor.precedes(occ) {
+				return true
+			}
+			result = append(result, occ)
+			taken++
+			return taken <= limit
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].StartTime.Equal(result[j].StartTime) {
+			return result[i].StartTime.Before(result[j].StartTime)
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	page := EventPage{Events: result}
+	if len(result) > limit {
+		page.Events = result[:limit]
+		last := page.Events[limit-1]
+		page.NextCursor = Cursor{StartTime: last.StartTime, ID: last.ID}.String()
+	}
+	if page.Events == nil {
+		page.Events = []Event{}
+	}
+	return page, nil
+}
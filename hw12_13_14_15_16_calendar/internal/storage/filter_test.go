@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := Cursor{StartTime: time.Date(2026, 10, 5, 10, 0, 0, 123, time.UTC), ID: "a:b"}
+	parsed, err := ParseCursor(c.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.StartTime.Equal(c.StartTime) || parsed.ID != c.ID {
+		t.Errorf("expected %+v, got %+v", c, parsed)
+	}
+
+	for _, s := range []string{"!!!", "bm9jb2xvbg", "eDox"} {
+		if _, err := ParseCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("ParseCursor(%q): expected ErrInvalidCursor, got %v", s, err)
+		}
+	}
+}
+
+func TestBuildPage(t *testing.T) {
+	start := time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)
+	events := []Event{
+		{ID: "single", Title: "Review", StartTime: start.Add(90 * time.Minute), EndTime: start.Add(2 * time.Hour)},
+		{
+			ID: "daily", Title: "Standup", StartTime: start, EndTime: start.Add(15 * time.Minute),
+			RRule: &RecurrenceRule{Freq: FrequencyDaily, Interval: 1},
+		},
+		{ID: "same-time", Title: "Standup prep", StartTime: start, EndTime: start.Add(10 * time.Minute)},
+	}
+
+	// Бесконечная серия без верхней границы: страницы должны идти без пропусков и повторов.
+	filter := EventFilter{From: start, Limit: 2}
+	var got []string
+	for i := 0; i < 3; i++ {
+		page, err := BuildPage(events, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Events) != 2 || page.NextCursor == "" {
+			t.Fatalf("page %d: got %d events, cursor %q", i, len(page.Events), page.NextCursor)
+		}
+		for _, e := range page.Events {
+			got = append(got, e.ID+"@"+e.StartTime.Format("02T15:04"))
+		}
+		filter.Cursor = page.NextCursor
+	}
+	want := []string{
+		"daily@05T09:00", "same-time@05T09:00",
+		"single@05T10:30", "daily@06T09:00",
+		"daily@07T09:00", "daily@08T09:00",
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	page, err := BuildPage(events, EventFilter{Query: "STANDUP", From: start, To: start.Add(24 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Events) != 2 || page.NextCursor != "" {
+		t.Errorf("expected 2 events on last page, got %d (cursor %q)", len(page.Events), page.NextCursor)
+	}
+
+	// Окно с 10:00: семинар начался в 9:00 и еще идет, поэтому попадает в выдачу,
+	// а стендапы к 10:00 уже закончились.
+	events = append(events, Event{ID: "workshop", StartTime: start, EndTime: start.Add(2 * time.Hour)})
+	filter = EventFilter{From: start.Add(time.Hour), To: start.Add(3 * time.Hour), Limit: 1}
+	got = nil
+	for {
+		page, err := BuildPage(events, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range page.Events {
+			got = append(got, e.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	if len(got) != 2 || got[0] != "workshop" || got[1] != "single" {
+		t.Errorf("expected in-progress workshop and review, got %v", got)
+	}
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// MaxFreeBusyRange ограничивает длину интервала запросов занятости и поиска свободного времени.
+	MaxFreeBusyRange = ConflictHorizon
+
+	// DefaultWorkStart и DefaultWorkEnd - рабочие часы по умолчанию при поиске свободного времени.
+	DefaultWorkStart = 9 * time.Hour
+	DefaultWorkEnd   = 18 * time.Hour
+)
+
+// Interval - полуоткрытый интервал времени [Start, End).
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// OccupiesTime сообщает, занимает ли событие время своих вхождений: такие события пересекаются
+// (см. Overlaps) и отмечаются в занятости. Время не занимают только свободные (TransparencyFree)
+// события; события на весь день свободны, если прозрачность не задана (см. NormalizeAllDay).
+func (e Event) OccupiesTime() bool {
+	return e.Transparency != TransparencyFree
+}
+
+// ValidateRange проверяет, что интервал [from, to) не пуст и не длиннее MaxFreeBusyRange.
+func ValidateRange(from, to time.Time) error {
+	if !to.After(from) || to.Sub(from) > MaxFreeBusyRange {
+		return fmt.Errorf("%w: want from < to within %v", ErrInvalidRange, MaxFreeBusyRange)
+	}
+	return nil
+}
+
+// BusyIntervals возвращает занятость по вхождениям occurrences (например, результату
+// ListEventsBetween), обрезанную границами [from, to), в виде объединенных интервалов.
+func BusyIntervals(occurrences []Event, from, to time.Time) []Interval {
+	var busy []Interval
+	for _, occ := range occurrences {
+		if !occ.OccupiesTime() {
+			continue
+		}
+		start, end := occ.StartTime, occ.EndTime
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			busy = append(busy, Interval{Start: start, End: end})
+		}
+	}
+	return MergeIntervals(busy)
+}
+
+// MergeIntervals упорядочивает интервалы и объединяет пересекающиеся и соприкасающиеся.
+func MergeIntervals(list []Interval) []Interval {
+	if len(list) == 0 {
+		return []Interval{}
+	}
+	sorted := append([]Interval(nil), list...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	merged := []Interval{sorted[0]}
+	for _, iv := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if iv.Start.After(last.End) {
+			merged = append(merged, iv)
+			continue
+		}
+		if iv.End.After(last.End) {
+			last.End = iv.End
+		}
+	}
+	return merged
+}
+
+// SlotQuery - параметры поиска свободного времени.
+type SlotQuery struct {
+	From time.Time
+	To   time.Time
+	// Duration - длительность искомого слота.
+	Duration time.Duration
+	// Count - сколько слотов предложить.
+	Count int
+	// WorkStart и WorkEnd - начало и конец рабочего дня как смещение от полуночи в Location.
+	WorkStart time.Duration
+	WorkEnd   time.Duration
+	// Weekends разрешает предлагать слоты в субботу и воскресенье.
+	Weekends bool
+	// Location - часовой пояс рабочих часов; nil - UTC.
+	Location *time.Location
+}
+
+// Validate проверяет интервал поиска, длительность, число слотов и рабочие часы.
+func (q SlotQuery) Validate() error {
+	if err := ValidateRange(q.From, q.To); err != nil {
+		return err
+	}
+	if q.Duration <= 0 || q.Count <= 0 {
+		return fmt.Errorf("%w: duration and count must be positive", ErrInvalidRange)
+	}
+	if q.WorkStart < 0 || q.WorkEnd > 24*time.Hour || q.WorkEnd-q.WorkStart < q.Duration {
+		return fmt.Errorf("%w: working hours must fit the slot duration", ErrInvalidRange)
+	}
+	return nil
+}
+
+// FreeSlots возвращает до q.Count самых ранних слотов длительностью q.Duration в рабочие часы,
+// не пересекающихся с объединенной занятостью busy (см. MergeIntervals). Внутри свободного
+// промежутка слоты идут подряд от его начала.
+func FreeSlots(busy []Interval, q SlotQuery) []Interval {
+	loc := q.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	slots := []Interval{}
+	add := func(start, end time.Time) {
+		for ; !start.Add(q.Duration).After(end) && len(slots) < q.Count; start = start.Add(q.Duration) {
+			slots = append(slots, Interval{Start: start, End: start.Add(q.Duration)})
+		}
+	}
+
+	day, _ := DayBounds(q.From.In(loc))
+	for ; day.Before(q.To) && len(slots) < q.Count; day = day.AddDate(0, 0, 1) {
+		if !q.Weekends && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+		// time.Date нормализует минуты, поэтому рабочие часы - это время на часах даже в дни перевода.
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, int(q.WorkStart/time.Minute), 0, 0, loc)
+		end := time.Date(day.Year(), day.Month(), day.Day(), 0, int(q.WorkEnd/time.Minute), 0, 0, loc)
+		if start.Before(q.From) {
+			start = q.From
+		}
+		if end.After(q.To) {
+			end = q.To
+		}
+		for _, b := range busy {
+			if !b.End.After(start) {
+				continue
+			}
+			if !b.Start.Before(end) {
+				break
+			}
+			add(start, b.Start)
+			start = b.End
+		}
+		add(start, end)
+	}
+	return slots
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMergeIntervals(t *testing.T) {
+	at := func(h int) time.Time { return time.Date(2026, 10, 19, h, 0, 0, 0, time.UTC) }
+	got := MergeIntervals([]Interval{
+		{at(13), at(14)},
+		{at(9), at(10)},
+		{at(10), at(11)},
+		{at(9), at(10)},
+		{at(15), at(17)},
+		{at(16), at(17)},
+	})
+	want := []Interval{{at(9), at(11)}, {at(13), at(14)}, {at(15), at(17)}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if !got[i].Start.Equal(want[i].Start) || !got[i].End.Equal(want[i].End) {
+			t.Errorf("interval %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestBusyIntervals(t *testing.T) {
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	occurrences := []Event{
+		{StartTime: day.Add(-2 * time.Hour), EndTime: day.Add(time.Hour)},
+		{StartTime: day.Add(9 * time.Hour), EndTime: day.Add(10 * time.Hour)},
+		{StartTime: day.Add(9 * time.Hour), EndTime: day.Add(11 * time.Hour)},
+		{StartTime: day, EndTime: day.AddDate(0, 0, 1), AllDay: true, Transparency: TransparencyFree},
+	}
+	got := BusyIntervals(occurrences, day, day.AddDate(0, 0, 1))
+	if len(got) != 2 {
+		t.Fatalf("expected 2 intervals, got %v", got)
+	}
+	if !got[0].Start.Equal(day) || !got[0].End.Equal(day.Add(time.Hour)) {
+		t.Errorf("expected the first interval clipped to the range, got %v", got[0])
+	}
+	if !got[1].Start.Equal(day.Add(9*time.Hour)) || !got[1].End.Equal(day.Add(11*time.Hour)) {
+		t.Errorf("expected overlapping meetings merged, got %v", got[1])
+	}
+}
+
+func TestFreeSlots(t *testing.T) {
+	msk, _ := time.LoadLocation("Europe/Moscow")
+	// 2026-10-23 - пятница.
+	friday := time.Date(2026, 10, 23, 0, 0, 0, 0, msk)
+	query := SlotQuery{
+		From:      friday,
+		To:        friday.AddDate(0, 0, 7),
+		Duration:  time.Hour,
+		Count:     3,
+		WorkStart: 9 * time.Hour,
+		WorkEnd:   12 * time.Hour,
+		Location:  msk,
+	}
+	busy := []Interval{
+		{friday.Add(9 * time.Hour), friday.Add(10*time.Hour + 30*time.Minute)},
+		{friday.Add(11*time.Hour + 30*time.Minute), friday.Add(15 * time.Hour)},
+	}
+
+	got := FreeSlots(busy, query)
+	monday := friday.AddDate(0, 0, 3)
+	want := []time.Time{friday.Add(10*time.Hour + 30*time.Minute), monday.Add(9 * time.Hour), monday.Add(10 * time.Hour)}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d slots, got %v", len(want), got)
+	}
+	for i := range want {
+		if !got[i].Start.Equal(want[i]) || got[i].End.Sub(got[i].Start) != time.Hour {
+			t.Errorf("slot %d: expected an hour from %v, got %v", i, want[i], got[i])
+		}
+	}
+
+	query.Weekends = true
+	if got := FreeSlots(busy, query); !got[1].Start.Equal(friday.AddDate(0, 0, 1).Add(9 * time.Hour)) {
+		t.Errorf("expected a Saturday slot with weekends, got %v", got)
+	}
+
+	query.From = friday.Add(10*time.Hour + 45*time.Minute)
+	query.To = friday.Add(12 * time.Hour)
+	if got := FreeSlots(busy, query); len(got) != 0 {
+		t.Errorf("expected no slots in a 45-minute gap, got %v", got)
+	}
+}
+
+func TestFreeSlots_BusyAllDay(t *testing.T) {
+	msk, _ := time.LoadLocation("Europe/Moscow")
+	// 2026-10-19 - понедельник; конференция занимает понедельник-среду.
+	monday := time.Date(2026, 10, 19, 0, 0, 0, 0, msk)
+	conference := NormalizeAllDay(Event{
+		StartTime: monday.Add(9 * time.Hour), EndTime: monday.AddDate(0, 0, 2).Add(18 * time.Hour), TimeZone: "Europe/Moscow",
+		AllDay: true, Transparency: TransparencyBusy,
+	})
+	holiday := NormalizeAllDay(Event{
+		StartTime: monday.AddDate(0, 0, 3), EndTime: monday.AddDate(0, 0, 3), TimeZone: "Europe/Moscow", AllDay: true,
+	})
+	from, to := monday, monday.AddDate(0, 0, 5)
+	query := SlotQuery{
+		From: from, To: to, Duration: time.Hour, Count: 1,
+		WorkStart: DefaultWorkStart, WorkEnd: DefaultWorkEnd, Location: msk,
+	}
+
+	got := FreeSlots(BusyIntervals([]Event{conference, holiday}, from, to), query)
+	// Свободный по умолчанию праздник в четверг не мешает, а конференция - мешает.
+	thursday := monday.AddDate(0, 0, 3)
+	if len(got) != 1 || !got[0].Start.Equal(thursday.Add(DefaultWorkStart)) {
+		t.Errorf("expected the first slot on Thursday after the conference, got %v", got)
+	}
+}
+
+func TestSlotQuery_Validate(t *testing.T) {
+	from := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	valid := SlotQuery{
+		From: from, To: from.AddDate(0, 0, 7), Duration: time.Hour, Count: 1,
+		WorkStart: DefaultWorkStart, WorkEnd: DefaultWorkEnd,
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("expected valid query, got %v", err)
+	}
+
+	tests := map[string]func(q *SlotQuery){
+		"EmptyRange":     func(q *SlotQuery) { q.To = q.From },
+		"TooLongRange":   func(q *SlotQuery) { q.To = q.From.Add(MaxFreeBusyRange + time.Hour) },
+		"ZeroDuration":   func(q *SlotQuery) { q.Duration = 0 },
+		"ZeroCount":      func(q *SlotQuery) { q.Count = 0 },
+		"ShortWorkday":   func(q *SlotQuery) { q.Duration = 10 * time.Hour },
+		"WorkEndPastDay": func(q *SlotQuery) { q.WorkEnd = 25 * time.Hour },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := valid
+			mutate(&q)
+			if err := q.Validate(); !errors.Is(err, ErrInvalidRange) {
+				t.Errorf("expected ErrInvalidRange, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// NewID генерирует идентификатор события - случайный UUID версии 4 (RFC 4122).
+func NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("storage: failed to generate id: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,68 @@
+package memorystorage
+
+import "github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+
+// searchIndex - инвертированный индекс: слово -> ID события -> взвешенная частота слова.
+type searchIndex struct {
+	postings map[string]map[string]float64
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{postings: make(map[string]map[string]float64)}
+}
+
+// weights возвращает взвешенные частоты слов заголовка и описания события.
+func weights(e storage.Event) map[string]float64 {
+	w := make(map[string]float64)
+	for _, t := range storage.Tokenize(e.Title) {
+		w[t] += storage.TitleWeight
+	}
+	for _, t := range storage.Tokenize(e.Description) {
+		w[t] += storage.DescriptionWeight
+	}
+	return w
+}
+
+func (idx *searchIndex) add(e storage.Event) {
+	for term, weight := range weights(e) {
+		docs, ok := idx.postings[term]
+		if !ok {
+			docs = make(map[string]float64)
+			idx.postings[term] = docs
+		}
+		docs[e.ID] = weight
+	}
+}
+
+func (idx *searchIndex) remove(e storage.Event) {
+	for term := range weights(e) {
+		delete(idx.postings[term], e.ID)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+}
+
+// search возвращает ID событий, содержащих все слова terms, с их релевантностью.
+func (idx *searchIndex) search(terms []string) map[string]float64 {
+	var result map[string]float64
+	for _, term := range terms {
+		docs := idx.postings[term]
+		if result == nil {
+			result = make(map[string]float64, len(docs))
+			for id, w := range docs {
+				result[id] = w
+			}
+			continue
+		}
+		for id := range result {
+			w, ok := docs[id]
+			if !ok {
+				delete(result, id)
+				continue
+			}
+			result[id] += w
+		}
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package memorystorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// claim - захват напоминания экземпляром планировщика owner до момента until.
+type claim struct {
+	owner string
+	until time.Time
+}
+
+func (c claim) heldByOther(owner string, now time.Time) bool {
+	return c.owner != owner && now.Before(c.until)
+}
+
+func (s *Storage) ClaimEventsToNotify(
+	_ context.Context, owner string, lease time.Duration,
+) ([]storage.DueReminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []storage.DueReminder
+	now := time.Now()
+	for _, event := range s.events {
+		for _, d := range event.DueReminders(now) {
+			if c, ok := s.claims[d.Reminder.ID]; ok && c.heldByOther(owner, now) {
+				continue
+			}
+			s.claims[d.Reminder.ID] = claim{owner: owner, until: now.Add(lease)}
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+func (s *Storage) ClaimOutbox(
+	_ context.Context, owner string, lease time.Duration, limit int,
+) ([]storage.OutboxMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []storage.OutboxMessage{}
+	now := time.Now()
+	for _, m := range s.outbox {
+		if len(result) == limit {
+			break
+		}
+		if c, ok := s.outboxClaims[m.ID]; ok && c.heldByOther(owner, now) {
+			continue
+		}
+		s.outboxClaims[m.ID] = claim{owner: owner, until: now.Add(lease)}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// Atomic выполняет fn, пока другие вызовы Atomic ждут. Изменения в памяти не откатываются:
+// запись в журнал, которая идет после изменения события, здесь не завершается ошибкой.
+func (s *Storage) Atomic(_ context.Context, fn func(tx storage.Storage) error) error {
+	s.atomic.Lock()
+	defer s.atomic.Unlock()
+	return fn(s)
+}
+
+// LockEvents ничего не делает: вызовы Atomic и так выполняются по одному.
+func (s *Storage) LockEvents(_ context.Context, _ string) error {
+	return nil
+}
+
+func (s *Storage) RunExclusive(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	s.mu.Lock()
+	if s.locks[name] {
+		s.mu.Unlock()
+		return false, nil
+	}
+	s.locks[name] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.locks, name)
+		s.mu.Unlock()
+	}()
+	return true, fn(ctx)
+}
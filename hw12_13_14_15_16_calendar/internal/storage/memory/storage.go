@@ -2,6 +2,8 @@ package memorystorage
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -9,66 +11,172 @@ import (
 )
 
 type Storage struct {
-	mu     sync.RWMutex
+	mu sync.RWMutex
+	// atomic выполняет вызовы Atomic по одному.
+	atomic sync.Mutex
 	events map[string]storage.Event
+	// trash - удаленные события по их ID; в списках, поиске и напоминаниях они не участвуют.
+	trash  map[string]storage.Event
+	index  *searchIndex
+	outbox []storage.OutboxMessage
+	// claims - захваты напоминаний по их ID, outboxClaims - сообщений outbox по их ID,
+	// locks - задачи, выполняемые через RunExclusive.
+	claims       map[string]claim
+	outboxClaims map[string]claim
+	locks        map[string]bool
+	// settings - настройки пользователей по их ID.
+	settings map[string]storage.UserSettings
+	// audit - журналы изменений по ID события.
+	audit map[string][]storage.AuditEntry
 }
 
 func New() *Storage {
 	return &Storage{
-		events: make(map[string]storage.Event),
+		events:       make(map[string]storage.Event),
+		trash:        make(map[string]storage.Event),
+		index:        newSearchIndex(),
+		claims:       make(map[string]claim),
+		outboxClaims: make(map[string]claim),
+		locks:        make(map[string]bool),
+
+		settings: make(map[string]storage.UserSettings),
+		audit:    make(map[string][]storage.AuditEntry),
 	}
 }
 
-func (s *Storage) CreateEvent(_ context.Context, event storage.Event) error {
-	if event.ID == "" || event.Title == "" {
-		return storage.ErrInvalidEvent
+func (s *Storage) CreateEvent(_ context.Context, event storage.Event) (storage.Event, error) {
+	if event.Title == "" || event.UserID == "" {
+		return storage.Event{}, storage.ErrInvalidEvent
+	}
+	if event.RRule != nil && event.RRule.Validate() != nil || !event.Transparency.Valid() {
+		return storage.Event{}, storage.ErrInvalidEvent
+	}
+	if _, err := storage.LoadLocation(event.TimeZone); err != nil {
+		return storage.Event{}, fmt.Errorf("%w: %w", storage.ErrInvalidEvent, err)
+	}
+	event = storage.NormalizeAllDay(event)
+	event.Transparency = event.Transparency.OrBusy()
+	if event.ID == "" {
+		event.ID = storage.NewID()
+	}
+	if event.UID == "" {
+		event.UID = event.ID
+	}
+	reminders, err := storage.MergeReminders(nil, event)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	event.Reminders = reminders
+	if event.Attendees, err = storage.MergeAttendees(nil, event); err != nil {
+		return storage.Event{}, err
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if s.isTimeBusyLocked(event.ID, event.UserID, event.StartTime, event.EndTime) {
-		return storage.ErrDateBusy
+	if s.existsLocked(event) {
+		return storage.Event{}, storage.ErrAlreadyExists
 	}
 
-	s.events[event.ID] = event
-	return nil
+	event.Version = 1
+	s.putLocked(event)
+	return event, nil
 }
 
-func (s *Storage) UpdateEvent(_ context.Context, id string, event storage.Event) error {
+func (s *Storage) UpdateEvent(_ context.Context, userID, id string, event storage.Event) error {
 	if event.Title == "" {
 		return storage.ErrInvalidEvent
 	}
+	if event.RRule != nil && event.RRule.Validate() != nil || !event.Transparency.Valid() {
+		return storage.ErrInvalidEvent
+	}
+	if _, err := storage.LoadLocation(event.TimeZone); err != nil {
+		return fmt.Errorf("%w: %w", storage.ErrInvalidEvent, err)
+	}
+	event = storage.NormalizeAllDay(event)
+	event.Transparency = event.Transparency.OrBusy()
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.events[id]; !exists {
-		return storage.ErrEventNotFound
+	current, err := s.ownedLocked(userID, id)
+	if err != nil {
+		return err
 	}
-
-	if s.isTimeBusyLocked(id, event.UserID, event.StartTime, event.EndTime) {
-		return storage.ErrDateBusy
+	if event.Version != 0 && event.Version != current.Version {
+		return storage.ErrVersionConflict
 	}
 
 	event.ID = id
-	s.events[id] = event
+	event.UserID = userID
+	event.UID = current.UID
+	if event.Reminders, err = storage.MergeReminders(&current, event); err != nil {
+		return err
+	}
+	if event.Attendees, err = storage.MergeAttendees(&current, event); err != nil {
+		return err
+	}
+
+	event.Version = current.Version + 1
+	s.putLocked(event)
+	return nil
+}
+
+func (s *Storage) DeleteEvent(_ context.Context, userID, id string, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.ownedLocked(userID, id)
+	if err != nil {
+		return err
+	}
+	if version != 0 && version != current.Version {
+		return storage.ErrVersionConflict
+	}
+
+	s.trashLocked(current, time.Now())
 	return nil
 }
 
-func (s *Storage) DeleteEvent(_ context.Context, id string) error {
+func (s *Storage) ListTrash(_ context.Context, userID string) ([]storage.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []storage.Event{}
+	for _, event := range s.trash {
+		if event.UserID == userID {
+			result = append(result, event)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].DeletedAt.Equal(*result[j].DeletedAt) {
+			return result[i].DeletedAt.After(*result[j].DeletedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (s *Storage) RestoreEvent(_ context.Context, userID, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.events[id]; !exists {
+	event, exists := s.trash[id]
+	if !exists {
 		return storage.ErrEventNotFound
 	}
+	if event.UserID != userID {
+		return storage.ErrForbidden
+	}
 
-	delete(s.events, id)
+	delete(s.trash, id)
+	event.DeletedAt = nil
+	event.Version++
+	s.putLocked(event)
 	return nil
 }
 
-func (s *Storage) GetEventByID(_ context.Context, id string) (*storage.Event, error) {
+func (s *Storage) GetEventByID(_ context.Context, userID, id string) (*storage.Event, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -76,61 +184,217 @@ func (s *Storage) GetEventByID(_ context.Context, id string) (*storage.Event, er
 	if !exists {
 		return nil, storage.ErrEventNotFound
 	}
+	if !event.VisibleTo(userID) {
+		return nil, storage.ErrForbidden
+	}
 
 	return &event, nil
 }
 
-func (s *Storage) ListEventsForDay(_ context.Context, date time.Time) ([]storage.Event, error) {
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
+func (s *Storage) SharesEvent(_ context.Context, userID, otherID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, event := range s.events {
+		if event.VisibleTo(userID) && event.VisibleTo(otherID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Storage) RespondToInvitation(_ context.Context, userID, id string, status storage.RSVPStatus) error {
+	if !status.IsResponse() {
+		return fmt.Errorf("%w: unknown response %q", storage.ErrInvalidEvent, status)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, exists := s.events[id]
+	if !exists {
+		return storage.ErrEventNotFound
+	}
+	// Копия: срез участников разделяют события, уже отданные вызывающим.
+	attendees := append([]storage.Attendee(nil), event.Attendees...)
+	for i := range attendees {
+		if attendees[i].UserID == userID {
+			attendees[i].Status = status
+			event.Attendees = attendees
+			s.events[id] = event
+			return nil
+		}
+	}
+	return storage.ErrForbidden
+}
+
+// putLocked сохраняет событие и обновляет поисковый индекс.
+func (s *Storage) putLocked(event storage.Event) {
+	if old, ok := s.events[event.ID]; ok {
+		s.index.remove(old)
+	}
+	s.events[event.ID] = event
+	s.index.add(event)
+}
+
+// deleteLocked удаляет событие вместе с его записями в поисковом индексе.
+func (s *Storage) deleteLocked(id string) {
+	if old, ok := s.events[id]; ok {
+		s.index.remove(old)
+		delete(s.events, id)
+		for _, r := range old.Reminders {
+			delete(s.claims, r.ID)
+		}
+	}
+}
+
+// existsLocked сообщает, занят ли ID события или UID у его владельца, в том числе событием в корзине.
+func (s *Storage) existsLocked(event storage.Event) bool {
+	if _, ok := s.events[event.ID]; ok {
+		return true
+	}
+	if _, ok := s.trash[event.ID]; ok {
+		return true
+	}
+	for _, set := range []map[string]storage.Event{s.events, s.trash} {
+		for _, other := range set {
+			if other.UserID == event.UserID && other.UID == event.UID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// trashLocked переносит событие в корзину с отметкой времени удаления now.
+func (s *Storage) trashLocked(event storage.Event, now time.Time) {
+	s.deleteLocked(event.ID)
+	event.DeletedAt = &now
+	event.Version++
+	s.trash[event.ID] = event
+}
+
+// ownedLocked возвращает событие id, если оно принадлежит пользователю userID.
+func (s *Storage) ownedLocked(userID, id string) (storage.Event, error) {
+	event, exists := s.events[id]
+	if !exists {
+		return storage.Event{}, storage.ErrEventNotFound
+	}
+	if event.UserID != userID {
+		return storage.Event{}, storage.ErrForbidden
+	}
+	return event, nil
+}
+
+func (s *Storage) ListEventsForDay(_ context.Context, userID string, date time.Time) ([]storage.Event, error) {
+	startOfDay, endOfDay := storage.DayBounds(date)
+
+	return s.listEventsBetween(userID, startOfDay, endOfDay), nil
+}
+
+func (s *Storage) ListEventsForWeek(_ context.Context, userID string, startDate time.Time) ([]storage.Event, error) {
+	startOfWeek, endOfWeek := storage.WeekBounds(startDate)
+
+	return s.listEventsBetween(userID, startOfWeek, endOfWeek), nil
+}
+
+func (s *Storage) ListEventsForMonth(_ context.Context, userID string, startDate time.Time) ([]storage.Event, error) {
+	startOfMonth, endOfMonth := storage.MonthBounds(startDate)
+
+	return s.listEventsBetween(userID, startOfMonth, endOfMonth), nil
+}
 
-	return s.listEventsBetween(startOfDay, endOfDay), nil
+func (s *Storage) ListEventsBetween(_ context.Context, userID string, start, end time.Time) ([]storage.Event, error) {
+	return s.listEventsBetween(userID, start, end), nil
 }
 
-func (s *Storage) ListEventsForWeek(_ context.Context, startDate time.Time) ([]storage.Event, error) {
-	startOfWeek := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, startDate.Location())
-	endOfWeek := startOfWeek.Add(7 * 24 * time.Hour)
+func (s *Storage) ListEvents(_ context.Context, filter storage.EventFilter) (storage.EventPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var candidates []storage.Event
+	for _, event := range s.events {
+		if event.ListedFor(filter.UserID) {
+			candidates = append(candidates, event)
+		}
+	}
 
-	return s.listEventsBetween(startOfWeek, endOfWeek), nil
+	return storage.BuildPage(candidates, filter)
 }
 
-func (s *Storage) ListEventsForMonth(_ context.Context, startDate time.Time) ([]storage.Event, error) {
-	startOfMonth := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, startDate.Location())
-	endOfMonth := startOfMonth.AddDate(0, 1, 0)
+func (s *Storage) SearchEvents(_ context.Context, query storage.SearchQuery) (storage.SearchPage, error) {
+	terms, err := query.Terms()
+	if err != nil {
+		return storage.SearchPage{}, err
+	}
+	offset, err := query.Offset()
+	if err != nil {
+		return storage.SearchPage{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	return s.listEventsBetween(startOfMonth, endOfMonth), nil
+	var results []storage.SearchResult
+	for id, rank := range s.index.search(terms) {
+		event := s.events[id]
+		if event.ListedFor(query.UserID) {
+			results = append(results, storage.SearchResult{Event: event, Rank: rank})
+		}
+	}
+	storage.SortSearchResults(results)
+
+	page := storage.SearchPage{Results: []storage.SearchResult{}}
+	if offset >= len(results) {
+		return page, nil
+	}
+	end := offset + query.PageLimit()
+	if end < len(results) {
+		page.NextCursor = storage.SearchCursor(end)
+	} else {
+		end = len(results)
+	}
+	page.Results = results[offset:end]
+	return page, nil
 }
 
-func (s *Storage) listEventsBetween(start, end time.Time) []storage.Event {
+func (s *Storage) listEventsBetween(userID string, start, end time.Time) []storage.Event {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	var result []storage.Event
 	for _, event := range s.events {
-		if (event.StartTime.Equal(start) || event.StartTime.After(start)) &&
-			event.StartTime.Before(end) {
-			result = append(result, event)
+		if !event.ListedFor(userID) {
+			continue
 		}
+		result = append(result, event.OccurrencesOverlapping(start, end)...)
 	}
+	// Порядок как в SQL-хранилище: по времени начала.
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].StartTime.Equal(result[j].StartTime) {
+			return result[i].StartTime.Before(result[j].StartTime)
+		}
+		return result[i].ID < result[j].ID
+	})
 
 	return result
 }
 
-func (s *Storage) GetEventsToNotify(_ context.Context) ([]storage.Event, error) {
+func (s *Storage) GetEventsToNotify(_ context.Context) ([]storage.DueReminder, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var result []storage.Event
+	var result []storage.DueReminder
 	now := time.Now()
 	for _, event := range s.events {
-		if !event.Notified && event.NotifyAt != nil && (event.NotifyAt.Before(now) || event.NotifyAt.Equal(now)) {
-			result = append(result, event)
-		}
+		result = append(result, event.DueReminders(now)...)
 	}
 	return result, nil
 }
 
-func (s *Storage) MarkEventNotified(_ context.Context, id string) error {
+func (s *Storage) MarkEventNotified(
+	_ context.Context, id, reminderID string, occurrence time.Time, msgs ...storage.OutboxMessage,
+) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -138,8 +402,60 @@ func (s *Storage) MarkEventNotified(_ context.Context, id string) error {
 	if !exists {
 		return storage.ErrEventNotFound
 	}
-	event.Notified = true
-	s.events[id] = event
+	// Копия: срез напоминаний разделяют события, уже отданные вызывающим.
+	reminders := append([]storage.Reminder(nil), event.Reminders...)
+	for i := range reminders {
+		if reminders[i].ID != reminderID {
+			continue
+		}
+		if sent := reminders[i].SentFor; sent != nil && !sent.Before(occurrence) {
+			return nil
+		}
+		sentAt := time.Now()
+		reminders[i].SentAt, reminders[i].SentFor = &sentAt, &occurrence
+		delete(s.claims, reminderID)
+		event.Reminders = reminders
+		s.events[id] = event
+		for _, msg := range msgs {
+			s.enqueueLocked(msg, sentAt)
+		}
+		return nil
+	}
+	return storage.ErrEventNotFound
+}
+
+// enqueueLocked добавляет сообщение в outbox, если сообщения с таким ключом там еще нет.
+func (s *Storage) enqueueLocked(msg storage.OutboxMessage, now time.Time) {
+	for _, m := range s.outbox {
+		if m.ID == msg.ID {
+			return
+		}
+	}
+	msg.CreatedAt = now
+	s.outbox = append(s.outbox, msg)
+}
+
+func (s *Storage) PendingOutbox(_ context.Context, limit int) ([]storage.OutboxMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if limit > len(s.outbox) {
+		limit = len(s.outbox)
+	}
+	return append([]storage.OutboxMessage(nil), s.outbox[:limit]...), nil
+}
+
+func (s *Storage) DeleteOutbox(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, m := range s.outbox {
+		if m.ID == id {
+			s.outbox = append(s.outbox[:i], s.outbox[i+1:]...)
+			delete(s.outboxClaims, id)
+			return nil
+		}
+	}
 	return nil
 }
 
@@ -147,26 +463,92 @@ func (s *Storage) DeleteOldEvents(_ context.Context, olderThan time.Time) error
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for id, event := range s.events {
+	now := time.Now()
+	for _, event := range s.events {
+		// Серия удаляется только после окончания последнего вхождения.
+		if event.IsRecurring() && !event.SeriesEnd(olderThan).Before(olderThan) {
+			continue
+		}
 		if event.StartTime.Before(olderThan) {
-			delete(s.events, id)
+			s.trashLocked(event, now)
 		}
 	}
 	return nil
 }
 
-func (s *Storage) isTimeBusyLocked(excludeID, userID string, start, end time.Time) bool {
-	for _, event := range s.events {
-		if event.ID == excludeID {
-			continue
+func (s *Storage) PurgeTrash(_ context.Context, deletedBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, event := range s.trash {
+		if event.DeletedAt.Before(deletedBefore) {
+			delete(s.trash, id)
 		}
-		if event.UserID != userID {
+	}
+	return nil
+}
+
+func (s *Storage) AddAuditEntry(_ context.Context, entry storage.AuditEntry) error {
+	if entry.ID == "" {
+		entry.ID = storage.NewID()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.audit[entry.EventID] = append(s.audit[entry.EventID], entry)
+	return nil
+}
+
+func (s *Storage) ListAuditEntries(_ context.Context, id string) ([]storage.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := append([]storage.AuditEntry{}, s.audit[id]...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].At.Before(entries[j].At) })
+	return entries, nil
+}
+
+func (s *Storage) GetUserSettings(_ context.Context, userID string) (storage.UserSettings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if settings, ok := s.settings[userID]; ok {
+		return settings, nil
+	}
+	return storage.UserSettings{UserID: userID}, nil
+}
+
+func (s *Storage) SaveUserSettings(_ context.Context, settings storage.UserSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.settings[settings.UserID] = settings
+	return nil
+}
+
+func (s *Storage) FindConflicts(_ context.Context, event storage.Event) ([]string, error) {
+	if event.RRule != nil && event.RRule.Validate() != nil || !event.Transparency.Valid() {
+		return nil, storage.ErrInvalidEvent
+	}
+	event = storage.NormalizeAllDay(event)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var conflicts []string
+	for _, other := range s.events {
+		if other.ID == event.ID || other.UserID != event.UserID {
 			continue
 		}
-
-		if start.Before(event.EndTime) && end.After(event.StartTime) {
-			return true
+		if storage.Overlaps(event, other) {
+			conflicts = append(conflicts, other.ID)
 		}
 	}
-	return false
+	sort.Strings(conflicts)
+	return conflicts, nil
 }
@@ -3,6 +3,7 @@ package memorystorage
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
@@ -22,13 +23,13 @@ func TestStorage_CreateEvent(t *testing.T) {
 		UserID:    "user1",
 	}
 
-	err := s.CreateEvent(ctx, event)
+	_, err := s.CreateEvent(ctx, event)
 	if err != nil {
 		t.Fatalf("CreateEvent failed: %v", err)
 	}
 
 	// проверяем, что событие создано
-	retrieved, err := s.GetEventByID(ctx, "1")
+	retrieved, err := s.GetEventByID(ctx, "user1", "1")
 	if err != nil {
 		t.Fatalf("GetEventByID failed: %v", err)
 	}
@@ -47,13 +48,13 @@ func TestStorage_CreateEvent_InvalidEvent(t *testing.T) {
 		Title: "Test Event",
 	}
 
-	err := s.CreateEvent(ctx, event)
+	_, err := s.CreateEvent(ctx, event)
 	if !errors.Is(err, storage.ErrInvalidEvent) {
 		t.Errorf("Expected ErrInvalidEvent, got %v", err)
 	}
 }
 
-func TestStorage_CreateEvent_DateBusy(t *testing.T) {
+func TestStorage_FindConflicts(t *testing.T) {
 	s := New()
 	ctx := context.Background()
 
@@ -68,12 +69,12 @@ func TestStorage_CreateEvent_DateBusy(t *testing.T) {
 		UserID:    "user1",
 	}
 
-	err := s.CreateEvent(ctx, event1)
+	_, err := s.CreateEvent(ctx, event1)
 	if err != nil {
 		t.Fatalf("CreateEvent failed: %v", err)
 	}
 
-	// пытаемся создать пересекающееся событие
+	// пересекающееся событие
 	event2 := storage.Event{
 		ID:        "2",
 		Title:     "Event 2",
@@ -82,12 +83,25 @@ func TestStorage_CreateEvent_DateBusy(t *testing.T) {
 		UserID:    "user1",
 	}
 
-	err = s.CreateEvent(ctx, event2)
-	if !errors.Is(err, storage.ErrDateBusy) {
-		t.Errorf("Expected ErrDateBusy, got %v", err)
+	conflicts, err := s.FindConflicts(ctx, event2)
+	if err != nil || len(conflicts) != 1 || conflicts[0] != "1" {
+		t.Errorf("Expected conflict with event 1, got %v, %v", conflicts, err)
 	}
 
-	// событие для другого пользователя должно создаться
+	// свободное событие ни с чем не пересекается и не занимает время
+	event2.Transparency = storage.TransparencyFree
+	if conflicts, _ := s.FindConflicts(ctx, event2); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts for a free event, got %v", conflicts)
+	}
+	if _, err := s.CreateEvent(ctx, event2); err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	event4 := storage.Event{ID: "4", Title: "Event 4", StartTime: end, EndTime: end.Add(time.Hour), UserID: "user1"}
+	if conflicts, _ := s.FindConflicts(ctx, event4); len(conflicts) != 0 {
+		t.Errorf("Expected free event 2 not to conflict, got %v", conflicts)
+	}
+
+	// события другого пользователя не мешают
 	event3 := storage.Event{
 		ID:        "3",
 		Title:     "Event 3",
@@ -96,9 +110,12 @@ func TestStorage_CreateEvent_DateBusy(t *testing.T) {
 		UserID:    "user2",
 	}
 
-	err = s.CreateEvent(ctx, event3)
-	if err != nil {
-		t.Errorf("CreateEvent for different user should succeed: %v", err)
+	if conflicts, _ := s.FindConflicts(ctx, event3); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts for a different user, got %v", conflicts)
+	}
+	_, err = s.CreateEvent(ctx, storage.Event{Title: "Bad", UserID: "user1", Transparency: "opaque"})
+	if !errors.Is(err, storage.ErrInvalidEvent) {
+		t.Errorf("Expected ErrInvalidEvent for unknown transparency, got %v", err)
 	}
 }
 
@@ -114,18 +131,18 @@ func TestStorage_UpdateEvent(t *testing.T) {
 		UserID:    "user1",
 	}
 
-	_ = s.CreateEvent(ctx, event)
+	_, _ = s.CreateEvent(ctx, event)
 
 	// обновляем событие
 	updatedEvent := event
 	updatedEvent.Title = "Updated Title"
 
-	err := s.UpdateEvent(ctx, "1", updatedEvent)
+	err := s.UpdateEvent(ctx, "user1", "1", updatedEvent)
 	if err != nil {
 		t.Fatalf("UpdateEvent failed: %v", err)
 	}
 
-	retrieved, _ := s.GetEventByID(ctx, "1")
+	retrieved, _ := s.GetEventByID(ctx, "user1", "1")
 	if retrieved.Title != "Updated Title" {
 		t.Errorf("Expected title 'Updated Title', got %s", retrieved.Title)
 	}
@@ -143,7 +160,7 @@ func TestStorage_UpdateEvent_NotFound(t *testing.T) {
 		UserID:    "user1",
 	}
 
-	err := s.UpdateEvent(ctx, "999", event)
+	err := s.UpdateEvent(ctx, "user1", "999", event)
 	if !errors.Is(err, storage.ErrEventNotFound) {
 		t.Errorf("Expected ErrEventNotFound, got %v", err)
 	}
@@ -161,14 +178,14 @@ func TestStorage_DeleteEvent(t *testing.T) {
 		UserID:    "user1",
 	}
 
-	_ = s.CreateEvent(ctx, event)
+	_, _ = s.CreateEvent(ctx, event)
 
-	err := s.DeleteEvent(ctx, "1")
+	err := s.DeleteEvent(ctx, "user1", "1", 0)
 	if err != nil {
 		t.Fatalf("DeleteEvent failed: %v", err)
 	}
 
-	_, err = s.GetEventByID(ctx, "1")
+	_, err = s.GetEventByID(ctx, "user1", "1")
 	if !errors.Is(err, storage.ErrEventNotFound) {
 		t.Errorf("Expected ErrEventNotFound after deletion, got %v", err)
 	}
@@ -198,10 +215,10 @@ func TestStorage_ListEventsForDay(t *testing.T) {
 		UserID:    "user1",
 	}
 
-	_ = s.CreateEvent(ctx, event1)
-	_ = s.CreateEvent(ctx, event2)
+	_, _ = s.CreateEvent(ctx, event1)
+	_, _ = s.CreateEvent(ctx, event2)
 
-	events, err := s.ListEventsForDay(ctx, today)
+	events, err := s.ListEventsForDay(ctx, "user1", today)
 	if err != nil {
 		t.Fatalf("ListEventsForDay failed: %v", err)
 	}
@@ -237,7 +254,7 @@ func TestStorage_ConcurrentAccess(t *testing.T) {
 				UserID:    "user1",
 			}
 
-			_ = s.CreateEvent(ctx, event)
+			_, _ = s.CreateEvent(ctx, event)
 		}(i)
 	}
 
@@ -248,9 +265,1019 @@ func TestStorage_ConcurrentAccess(t *testing.T) {
 		wg.Add(1)
 		go func(id int) {
 			defer wg.Done()
-			_, _ = s.GetEventByID(ctx, string(rune('A'+id)))
+			_, _ = s.GetEventByID(ctx, "user1", string(rune('A'+id)))
 		}(i)
 	}
 
 	wg.Wait()
 }
+
+func TestStorage_RecurringEvent(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	rule, err := storage.ParseRRule("FREQ=DAILY;COUNT=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	standup := storage.Event{
+		ID:        "1",
+		Title:     "Standup",
+		StartTime: start,
+		EndTime:   start.Add(15 * time.Minute),
+		UserID:    "user1",
+		RRule:     rule,
+	}
+	if _, err := s.CreateEvent(ctx, standup); err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+
+	// вхождение серии видно в листинге другого дня
+	events, err := s.ListEventsForDay(ctx, "user1", start.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatalf("ListEventsForDay failed: %v", err)
+	}
+	if len(events) != 1 || !events[0].StartTime.Equal(start.AddDate(0, 0, 3)) {
+		t.Errorf("expected occurrence on day 4, got %+v", events)
+	}
+
+	events, _ = s.ListEventsForWeek(ctx, "user1", start)
+	if len(events) != 7 {
+		t.Errorf("expected 7 occurrences in a week, got %d", len(events))
+	}
+
+	// пересечение с одним из будущих вхождений
+	conflicting := storage.Event{
+		ID:        "2",
+		Title:     "Conflict",
+		StartTime: start.AddDate(0, 0, 5).Add(5 * time.Minute),
+		EndTime:   start.AddDate(0, 0, 5).Add(time.Hour),
+		UserID:    "user1",
+	}
+	if conflicts, _ := s.FindConflicts(ctx, conflicting); len(conflicts) != 1 {
+		t.Errorf("Expected conflict with the series, got %v", conflicts)
+	}
+
+	// после окончания серии время свободно
+	conflicting.StartTime = start.AddDate(0, 0, 10)
+	conflicting.EndTime = conflicting.StartTime.Add(time.Hour)
+	if conflicts, _ := s.FindConflicts(ctx, conflicting); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts after the series, got %v", conflicts)
+	}
+	if _, err := s.CreateEvent(ctx, conflicting); err != nil {
+		t.Errorf("CreateEvent after series end failed: %v", err)
+	}
+}
+
+func TestStorage_RecurringNotifications(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	start := time.Now().Add(-48 * time.Hour).Truncate(time.Minute)
+	rule, _ := storage.ParseRRule("FREQ=DAILY")
+
+	_, _ = s.CreateEvent(ctx, storage.Event{
+		ID:        "1",
+		Title:     "Daily",
+		StartTime: start,
+		EndTime:   start.Add(time.Minute),
+		UserID:    "user1",
+		Reminders: []storage.Reminder{{Offset: time.Hour}},
+		RRule:     rule,
+	})
+
+	due, err := s.GetEventsToNotify(ctx)
+	if err != nil {
+		t.Fatalf("GetEventsToNotify failed: %v", err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("Expected 1 occurrence to notify, got %d", len(due))
+	}
+
+	if err := s.MarkEventNotified(ctx, "1", due[0].Reminder.ID, due[0].Event.StartTime, outboxMessage(due[0])); err != nil {
+		t.Fatalf("MarkEventNotified failed: %v", err)
+	}
+
+	due, _ = s.GetEventsToNotify(ctx)
+	if len(due) != 0 {
+		t.Errorf("Expected no occurrences after marking, got %d", len(due))
+	}
+}
+
+func TestStorage_Reminders(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	start := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	event, err := s.CreateEvent(ctx, storage.Event{
+		Title:     "Meeting",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		UserID:    "user1",
+		Reminders: []storage.Reminder{
+			{Offset: 24 * time.Hour, Channel: "email"},
+			{Offset: 15 * time.Minute},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	if len(event.Reminders) != 2 || event.Reminders[0].ID == "" || event.Reminders[0].ID == event.Reminders[1].ID {
+		t.Fatalf("expected 2 reminders with distinct ids, got %+v", event.Reminders)
+	}
+
+	due, _ := s.GetEventsToNotify(ctx)
+	if len(due) != 2 {
+		t.Fatalf("expected both reminders to be due, got %d", len(due))
+	}
+	for _, d := range due {
+		if err := s.MarkEventNotified(ctx, event.ID, d.Reminder.ID, d.Event.StartTime, outboxMessage(d)); err != nil {
+			t.Fatalf("MarkEventNotified failed: %v", err)
+		}
+	}
+	if due, _ := s.GetEventsToNotify(ctx); len(due) != 0 {
+		t.Fatalf("expected no due reminders after marking, got %d", len(due))
+	}
+	msg := storage.OutboxMessage{ID: "unknown"}
+	if err := s.MarkEventNotified(ctx, event.ID, "unknown", start, msg); !errors.Is(err, storage.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound for unknown reminder, got %v", err)
+	}
+
+	// Перенос начала на два дня: суточное напоминание еще не подошло, 15-минутное - тем более.
+	moved := event
+	moved.StartTime = start.AddDate(0, 0, 2)
+	moved.EndTime = moved.StartTime.Add(time.Hour)
+	if err := s.UpdateEvent(ctx, "user1", event.ID, moved); err != nil {
+		t.Fatalf("UpdateEvent failed: %v", err)
+	}
+	got, _ := s.GetEventByID(ctx, "user1", event.ID)
+	for i, r := range got.Reminders {
+		if r.ID != event.Reminders[i].ID || r.SentFor != nil {
+			t.Errorf("expected reminder %s to be rescheduled, got %+v", event.Reminders[i].ID, r)
+		}
+	}
+	if due, _ := s.GetEventsToNotify(ctx); len(due) != 0 {
+		t.Errorf("expected no due reminders after moving the event, got %d", len(due))
+	}
+}
+
+func TestStorage_UserScoping(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	start := time.Now()
+	event := storage.Event{
+		ID:        "1",
+		Title:     "Private",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		UserID:    "user1",
+	}
+	_, _ = s.CreateEvent(ctx, event)
+
+	if _, err := s.GetEventByID(ctx, "user2", "1"); !errors.Is(err, storage.ErrForbidden) {
+		t.Errorf("Expected ErrForbidden on get, got %v", err)
+	}
+	if err := s.UpdateEvent(ctx, "user2", "1", event); !errors.Is(err, storage.ErrForbidden) {
+		t.Errorf("Expected ErrForbidden on update, got %v", err)
+	}
+	if err := s.DeleteEvent(ctx, "user2", "1", 0); !errors.Is(err, storage.ErrForbidden) {
+		t.Errorf("Expected ErrForbidden on delete, got %v", err)
+	}
+	if _, err := s.GetEventByID(ctx, "user2", "missing"); !errors.Is(err, storage.ErrEventNotFound) {
+		t.Errorf("Expected ErrEventNotFound, got %v", err)
+	}
+
+	events, _ := s.ListEventsForDay(ctx, "user2", start)
+	if len(events) != 0 {
+		t.Errorf("Expected no events for another user, got %d", len(events))
+	}
+	events, _ = s.ListEventsForDay(ctx, "user1", start)
+	if len(events) != 1 {
+		t.Errorf("Expected 1 event for owner, got %d", len(events))
+	}
+}
+
+func TestStorage_ListEvents(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		at := start.Add(time.Duration(i) * time.Hour)
+		if _, err := s.CreateEvent(ctx, storage.Event{
+			ID: string(rune('a' + i)), Title: "Event", StartTime: at, EndTime: at.Add(30 * time.Minute), UserID: "user1",
+		}); err != nil {
+			t.Fatalf("CreateEvent failed: %v", err)
+		}
+	}
+	_, _ = s.CreateEvent(ctx, storage.Event{
+		ID: "foreign", Title: "Event", StartTime: start, EndTime: start.Add(time.Hour), UserID: "user2",
+	})
+
+	filter := storage.EventFilter{UserID: "user1", Limit: 2}
+	var ids []string
+	for {
+		page, err := s.ListEvents(ctx, filter)
+		if err != nil {
+			t.Fatalf("ListEvents failed: %v", err)
+		}
+		for _, e := range page.Events {
+			ids = append(ids, e.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	if len(ids) != 5 || ids[0] != "a" || ids[4] != "e" {
+		t.Errorf("Expected events a..e in order, got %v", ids)
+	}
+
+	if _, err := s.ListEvents(ctx, storage.EventFilter{UserID: "user1", Cursor: "%%%"}); !errors.Is(err, storage.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestStorage_ListEvents_InProgress(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	start := time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)
+	events := []storage.Event{
+		{ID: "workshop", Title: "Workshop", StartTime: start, EndTime: start.Add(3 * time.Hour), UserID: "user1"},
+		{ID: "standup", Title: "Standup", StartTime: start, EndTime: start.Add(15 * time.Minute), UserID: "user1"},
+		{ID: "review", Title: "Review", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(4 * time.Hour), UserID: "user1"},
+	}
+	for _, e := range events {
+		if _, err := s.CreateEvent(ctx, e); err != nil {
+			t.Fatalf("CreateEvent failed: %v", err)
+		}
+	}
+
+	// Семинар начался до окна, но еще идет; стендап к началу окна закончился.
+	filter := storage.EventFilter{UserID: "user1", From: start.Add(time.Hour), To: start.Add(5 * time.Hour), Limit: 1}
+	var ids []string
+	for {
+		page, err := s.ListEvents(ctx, filter)
+		if err != nil {
+			t.Fatalf("ListEvents failed: %v", err)
+		}
+		for _, e := range page.Events {
+			ids = append(ids, e.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	if len(ids) != 2 || ids[0] != "workshop" || ids[1] != "review" {
+		t.Errorf("Expected workshop and review, got %v", ids)
+	}
+}
+
+func TestStorage_SearchEvents(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	events := []storage.Event{
+		{ID: "1", Title: "Q3 budget review", Description: "Discuss the budget"},
+		{ID: "2", Title: "Planning", Description: "Q3 budget draft"},
+		{ID: "3", Title: "Budget", Description: "Q4"},
+		{ID: "4", Title: "Q3 budget", UserID: "user2"},
+	}
+	for i, e := range events {
+		e.StartTime = start.Add(time.Duration(i) * time.Hour)
+		e.EndTime = e.StartTime.Add(30 * time.Minute)
+		if e.UserID == "" {
+			e.UserID = "user1"
+		}
+		if _, err := s.CreateEvent(ctx, e); err != nil {
+			t.Fatalf("CreateEvent failed: %v", err)
+		}
+	}
+
+	page, err := s.SearchEvents(ctx, storage.SearchQuery{UserID: "user1", Query: "q3 BUDGET", Limit: 1})
+	if err != nil {
+		t.Fatalf("SearchEvents failed: %v", err)
+	}
+	if len(page.Results) != 1 || page.Results[0].Event.ID != "1" || page.NextCursor == "" {
+		t.Fatalf("Expected event 1 first with next page, got %+v", page)
+	}
+	page, err = s.SearchEvents(ctx, storage.SearchQuery{UserID: "user1", Query: "q3 budget", Limit: 1, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatalf("SearchEvents failed: %v", err)
+	}
+	if len(page.Results) != 1 || page.Results[0].Event.ID != "2" || page.NextCursor != "" {
+		t.Fatalf("Expected event 2 on last page, got %+v", page)
+	}
+
+	// После изменения и удаления индекс не должен находить старые слова.
+	updated := events[1]
+	updated.Title, updated.Description = "Planning", "Roadmap"
+	updated.StartTime, updated.EndTime = start.Add(time.Hour), start.Add(90*time.Minute)
+	if err := s.UpdateEvent(ctx, "user1", "2", updated); err != nil {
+		t.Fatalf("UpdateEvent failed: %v", err)
+	}
+	if err := s.DeleteEvent(ctx, "user1", "1", 0); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+	page, _ = s.SearchEvents(ctx, storage.SearchQuery{UserID: "user1", Query: "q3"})
+	if len(page.Results) != 0 {
+		t.Errorf("Expected no results, got %+v", page.Results)
+	}
+
+	if _, err := s.SearchEvents(ctx, storage.SearchQuery{UserID: "user1", Query: " , "}); !errors.Is(err, storage.ErrInvalidQuery) {
+		t.Errorf("Expected ErrInvalidQuery, got %v", err)
+	}
+}
+
+func TestStorage_EventVersions(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	event := storage.Event{ID: "1", Title: "Meeting", StartTime: start, EndTime: start.Add(time.Hour), UserID: "user1"}
+	if _, err := s.CreateEvent(ctx, event); err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	created, _ := s.GetEventByID(ctx, "user1", "1")
+	if created.Version != 1 {
+		t.Fatalf("Expected version 1, got %d", created.Version)
+	}
+
+	// Два клиента прочитали версию 1; второе изменение должно получить конфликт.
+	first, second := *created, *created
+	first.Title = "First"
+	second.Title = "Second"
+	if err := s.UpdateEvent(ctx, "user1", "1", first); err != nil {
+		t.Fatalf("UpdateEvent failed: %v", err)
+	}
+	if err := s.UpdateEvent(ctx, "user1", "1", second); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("Expected ErrVersionConflict, got %v", err)
+	}
+	if err := s.DeleteEvent(ctx, "user1", "1", 1); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Fatalf("Expected ErrVersionConflict, got %v", err)
+	}
+
+	updated, _ := s.GetEventByID(ctx, "user1", "1")
+	if updated.Title != "First" || updated.Version != 2 {
+		t.Errorf("Expected First at version 2, got %s at %d", updated.Title, updated.Version)
+	}
+	if err := s.DeleteEvent(ctx, "user1", "1", 2); err != nil {
+		t.Errorf("DeleteEvent failed: %v", err)
+	}
+}
+
+func TestStorage_CreateEvent_GeneratesID(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	created, err := s.CreateEvent(ctx, storage.Event{
+		Title: "No ID", StartTime: start, EndTime: start.Add(time.Hour), UserID: "user1",
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	if created.ID == "" || created.Version != 1 {
+		t.Fatalf("Expected generated ID and version 1, got %+v", created)
+	}
+	if _, err := s.GetEventByID(ctx, "user1", created.ID); err != nil {
+		t.Errorf("GetEventByID failed: %v", err)
+	}
+}
+
+func outboxMessage(d storage.DueReminder) storage.OutboxMessage {
+	return storage.OutboxMessage{
+		ID:      storage.NotificationKey(d.Event.ID, d.Reminder.ID, d.Event.StartTime),
+		Payload: []byte(`{}`),
+	}
+}
+
+func TestStorage_Outbox(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	start := time.Now().Add(10 * time.Minute)
+	_, _ = s.CreateEvent(ctx, storage.Event{
+		ID:        "1",
+		Title:     "Meeting",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		UserID:    "user1",
+		Reminders: []storage.Reminder{{Offset: time.Hour}},
+	})
+
+	due, _ := s.GetEventsToNotify(ctx)
+	if len(due) != 1 {
+		t.Fatalf("expected 1 due reminder, got %d", len(due))
+	}
+	msg := outboxMessage(due[0])
+	// Повторная отметка того же вхождения не должна ставить уведомление в outbox второй раз.
+	for i := 0; i < 2; i++ {
+		if err := s.MarkEventNotified(ctx, "1", due[0].Reminder.ID, due[0].Event.StartTime, msg); err != nil {
+			t.Fatalf("MarkEventNotified failed: %v", err)
+		}
+	}
+
+	pending, err := s.PendingOutbox(ctx, 10)
+	if err != nil {
+		t.Fatalf("PendingOutbox failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != msg.ID {
+		t.Fatalf("expected single outbox message %s, got %+v", msg.ID, pending)
+	}
+
+	claimed, err := s.ClaimOutbox(ctx, "a", time.Minute, 10)
+	if err != nil {
+		t.Fatalf("ClaimOutbox failed: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != msg.ID {
+		t.Fatalf("expected outbox message to be claimed, got %+v", claimed)
+	}
+	if other, _ := s.ClaimOutbox(ctx, "b", time.Minute, 10); len(other) != 0 {
+		t.Errorf("expected message claimed by a to be skipped for b, got %d", len(other))
+	}
+	if again, _ := s.ClaimOutbox(ctx, "a", time.Minute, 10); len(again) != 1 {
+		t.Errorf("expected owner to reclaim its message, got %d", len(again))
+	}
+
+	if err := s.DeleteOutbox(ctx, msg.ID); err != nil {
+		t.Fatalf("DeleteOutbox failed: %v", err)
+	}
+	if pending, _ := s.PendingOutbox(ctx, 10); len(pending) != 0 {
+		t.Errorf("expected empty outbox after delete, got %d", len(pending))
+	}
+}
+
+func TestStorage_UpdateEventKeepsReminderState(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	start := time.Now().UTC().Add(10 * time.Minute).Truncate(time.Second)
+	event := storage.Event{
+		ID: "1", Title: "Meeting", StartTime: start, EndTime: start.Add(time.Hour), UserID: "user1",
+		Reminders: []storage.Reminder{{Offset: time.Hour}},
+	}
+	if _, err := s.CreateEvent(ctx, event); err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	due, err := s.ClaimEventsToNotify(ctx, "a", time.Minute)
+	if err != nil || len(due) != 1 {
+		t.Fatalf("expected 1 claimed reminder, got %d, %v", len(due), err)
+	}
+
+	// Переименование не меняет напоминание: его захват и отметка об отправке сохраняются.
+	event.Title = "Planning"
+	if err := s.UpdateEvent(ctx, "user1", "1", event); err != nil {
+		t.Fatalf("UpdateEvent failed: %v", err)
+	}
+	if other, _ := s.ClaimEventsToNotify(ctx, "b", time.Minute); len(other) != 0 {
+		t.Errorf("expected the claim to survive the update, got %d reminders for b", len(other))
+	}
+	if err := s.MarkEventNotified(ctx, "1", due[0].Reminder.ID, due[0].Event.StartTime, outboxMessage(due[0])); err != nil {
+		t.Fatalf("MarkEventNotified failed: %v", err)
+	}
+	event.Title = "Retro"
+	if err := s.UpdateEvent(ctx, "user1", "1", event); err != nil {
+		t.Fatalf("UpdateEvent failed: %v", err)
+	}
+	if due, _ := s.GetEventsToNotify(ctx); len(due) != 0 {
+		t.Errorf("expected the sent reminder to stay sent after the update, got %+v", due)
+	}
+}
+
+func TestStorage_ClaimEventsToNotify(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	start := time.Now().Add(10 * time.Minute)
+	_, _ = s.CreateEvent(ctx, storage.Event{
+		ID:        "1",
+		Title:     "Meeting",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		UserID:    "user1",
+		Reminders: []storage.Reminder{{Offset: time.Hour}},
+	})
+
+	due, err := s.ClaimEventsToNotify(ctx, "a", time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimEventsToNotify failed: %v", err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("expected 1 claimed reminder, got %d", len(due))
+	}
+	if other, _ := s.ClaimEventsToNotify(ctx, "b", time.Minute); len(other) != 0 {
+		t.Errorf("expected reminder claimed by a to be skipped for b, got %d", len(other))
+	}
+	if again, _ := s.ClaimEventsToNotify(ctx, "a", time.Minute); len(again) != 1 {
+		t.Errorf("expected owner to reclaim its reminder, got %d", len(again))
+	}
+
+	// Истекший захват забирает другой экземпляр.
+	_, _ = s.ClaimEventsToNotify(ctx, "a", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	taken, _ := s.ClaimEventsToNotify(ctx, "b", time.Minute)
+	if len(taken) != 1 {
+		t.Fatalf("expected expired claim to be taken over, got %d", len(taken))
+	}
+	if err := s.MarkEventNotified(ctx, "1", taken[0].Reminder.ID, taken[0].Event.StartTime, outboxMessage(taken[0])); err != nil {
+		t.Fatalf("MarkEventNotified failed: %v", err)
+	}
+	if due, _ := s.ClaimEventsToNotify(ctx, "a", time.Minute); len(due) != 0 {
+		t.Errorf("expected no reminders after marking, got %d", len(due))
+	}
+}
+
+func TestStorage_ClaimEventsToNotifyConcurrent(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	const events = 100
+	start := time.Now().Add(10 * time.Minute)
+	for i := 0; i < events; i++ {
+		_, _ = s.CreateEvent(ctx, storage.Event{
+			Title:     "Event",
+			StartTime: start.Add(time.Duration(i) * time.Hour),
+			EndTime:   start.Add(time.Duration(i)*time.Hour + time.Minute),
+			UserID:    "user1",
+			Reminders: []storage.Reminder{{Offset: 24 * 7 * time.Hour}},
+		})
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		claimed = make(map[string]int)
+	)
+	for i := 0; i < 8; i++ {
+		owner := string(rune('a' + i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			due, err := s.ClaimEventsToNotify(ctx, owner, time.Minute)
+			if err != nil {
+				t.Errorf("ClaimEventsToNotify failed: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, d := range due {
+				claimed[d.Reminder.ID]++
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(claimed) != events {
+		t.Fatalf("expected %d claimed reminders, got %d", events, len(claimed))
+	}
+	for id, n := range claimed {
+		if n != 1 {
+			t.Errorf("expected reminder %s to be claimed once, got %d", id, n)
+		}
+	}
+}
+
+func TestStorage_RunExclusive(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	ran, err := s.RunExclusive(ctx, "cleanup", func(ctx context.Context) error {
+		nested, err := s.RunExclusive(ctx, "cleanup", func(context.Context) error { return nil })
+		if err != nil || nested {
+			t.Errorf("expected nested run to be rejected, got %v, %v", nested, err)
+		}
+		return nil
+	})
+	if err != nil || !ran {
+		t.Fatalf("expected task to run, got %v, %v", ran, err)
+	}
+	if ran, _ := s.RunExclusive(ctx, "cleanup", func(context.Context) error { return nil }); !ran {
+		t.Error("expected lock to be released after the task")
+	}
+}
+
+func TestStorage_TimeZone(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	ny, _ := time.LoadLocation("America/New_York")
+
+	_, err := s.CreateEvent(ctx, storage.Event{
+		Title: "Nowhere", StartTime: time.Now(), EndTime: time.Now(), UserID: "user1", TimeZone: "Mars/Olympus",
+	})
+	if !errors.Is(err, storage.ErrInvalidEvent) {
+		t.Errorf("expected ErrInvalidEvent for unknown time zone, got %v", err)
+	}
+
+	// 8 марта 2026 в Нью-Йорке длится 23 часа: 00:30 следующего дня в него не входит.
+	late := time.Date(2026, 3, 8, 23, 30, 0, 0, ny)
+	next := time.Date(2026, 3, 9, 0, 30, 0, 0, ny)
+	for i, start := range []time.Time{late, next} {
+		_, err := s.CreateEvent(ctx, storage.Event{
+			ID: fmt.Sprintf("%d", i+1), Title: "Call", StartTime: start, EndTime: start.Add(15 * time.Minute),
+			UserID: "user1", TimeZone: "America/New_York",
+		})
+		if err != nil {
+			t.Fatalf("CreateEvent failed: %v", err)
+		}
+	}
+	events, err := s.ListEventsForDay(ctx, "user1", time.Date(2026, 3, 8, 0, 0, 0, 0, ny))
+	if err != nil {
+		t.Fatalf("ListEventsForDay failed: %v", err)
+	}
+	if len(events) != 1 || events[0].ID != "1" || events[0].TimeZone != "America/New_York" {
+		t.Errorf("expected only event 1 on the 23-hour day, got %+v", events)
+	}
+
+	settings, err := s.GetUserSettings(ctx, "user1")
+	if err != nil || settings.UserID != "user1" || settings.TimeZone != "" {
+		t.Errorf("expected empty settings, got %+v, %v", settings, err)
+	}
+	if err := s.SaveUserSettings(ctx, storage.UserSettings{UserID: "user1", TimeZone: "Mars/Olympus"}); !errors.Is(
+		err, storage.ErrInvalidTimeZone) {
+		t.Errorf("expected ErrInvalidTimeZone, got %v", err)
+	}
+	if err := s.SaveUserSettings(ctx, storage.UserSettings{UserID: "user1", WeekStart: "someday"}); !errors.Is(
+		err, storage.ErrInvalidWeekStart) {
+		t.Errorf("expected ErrInvalidWeekStart, got %v", err)
+	}
+	for _, tz := range []string{"Europe/Moscow", "Asia/Tokyo"} {
+		err := s.SaveUserSettings(ctx, storage.UserSettings{
+			UserID: "user1", TimeZone: tz, Locale: "ja-JP", WeekStart: "monday",
+		})
+		if err != nil {
+			t.Fatalf("SaveUserSettings failed: %v", err)
+		}
+	}
+	want := storage.UserSettings{UserID: "user1", TimeZone: "Asia/Tokyo", Locale: "ja-JP", WeekStart: "monday"}
+	if settings, _ := s.GetUserSettings(ctx, "user1"); settings != want {
+		t.Errorf("expected %+v, got %+v", want, settings)
+	}
+}
+
+func TestStorage_AllDay(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	msk, _ := time.LoadLocation("Europe/Moscow")
+	today := time.Date(2026, 10, 17, 0, 0, 0, 0, msk)
+
+	// Трехдневная конференция началась вчера: она пересекается с сегодняшним днем.
+	conf, err := s.CreateEvent(ctx, storage.Event{
+		ID: "conf", Title: "Conference", StartTime: today.AddDate(0, 0, -1).Add(9 * time.Hour),
+		EndTime: today.AddDate(0, 0, 1).Add(18 * time.Hour), UserID: "user1", TimeZone: "Europe/Moscow", AllDay: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	if !conf.StartTime.Equal(today.AddDate(0, 0, -1)) || !conf.EndTime.Equal(today.AddDate(0, 0, 2)) {
+		t.Errorf("expected bounds aligned to midnight, got [%v, %v)", conf.StartTime, conf.EndTime)
+	}
+	// Событие на весь день не занимает время.
+	_, err = s.CreateEvent(ctx, storage.Event{
+		ID: "talk", Title: "Talk", StartTime: today.Add(23 * time.Hour), EndTime: today.Add(25 * time.Hour),
+		UserID: "user1",
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+
+	events, err := s.ListEventsForDay(ctx, "user1", today)
+	if err != nil {
+		t.Fatalf("ListEventsForDay failed: %v", err)
+	}
+	if len(events) != 2 || events[0].ID != "conf" || !events[0].AllDay || events[1].ID != "talk" {
+		t.Errorf("expected conference and talk, got %+v", events)
+	}
+	// Ночной доклад заканчивается на следующий день и тоже попадает в его список.
+	events, _ = s.ListEventsForDay(ctx, "user1", today.AddDate(0, 0, 1))
+	if len(events) != 2 {
+		t.Errorf("expected 2 events tomorrow, got %+v", events)
+	}
+	if events, _ := s.ListEventsForDay(ctx, "user1", today.AddDate(0, 0, 2)); len(events) != 0 {
+		t.Errorf("conference end is exclusive, got %+v", events)
+	}
+	if conf.Transparency != storage.TransparencyFree {
+		t.Errorf("expected all-day event to be free by default, got %q", conf.Transparency)
+	}
+
+	// Выездная встреча на весь день явно занимает время и пересекается с событиями этого дня.
+	offsiteDay := today.AddDate(0, 0, 5)
+	_, err = s.CreateEvent(ctx, storage.Event{
+		ID: "offsite", Title: "Offsite", StartTime: offsiteDay, EndTime: offsiteDay, UserID: "user1",
+		TimeZone: "Europe/Moscow", AllDay: true, Transparency: storage.TransparencyBusy,
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	standup := storage.Event{
+		ID: "standup", UserID: "user1", StartTime: offsiteDay.Add(10 * time.Hour), EndTime: offsiteDay.Add(11 * time.Hour),
+	}
+	conflicts, err := s.FindConflicts(ctx, standup)
+	if err != nil || len(conflicts) != 1 || conflicts[0] != "offsite" {
+		t.Errorf("expected busy all-day event to conflict, got %v, %v", conflicts, err)
+	}
+}
+
+func TestStorage_Attendees(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	start := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+
+	created, err := s.CreateEvent(ctx, storage.Event{
+		ID: "1", Title: "Sync", StartTime: start, EndTime: start.Add(time.Hour), UserID: "owner",
+		Attendees: []storage.Attendee{{UserID: "alice", Status: storage.StatusAccepted}, {UserID: "bob"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	for _, a := range created.Attendees {
+		if a.Status != storage.StatusNeedsAction || a.Role != storage.RoleRequired {
+			t.Errorf("expected required attendee without response, got %+v", a)
+		}
+	}
+
+	if _, err := s.GetEventByID(ctx, "alice", "1"); err != nil {
+		t.Errorf("expected invited user to see the event, got %v", err)
+	}
+	if _, err := s.GetEventByID(ctx, "carol", "1"); !errors.Is(err, storage.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for a stranger, got %v", err)
+	}
+	if err := s.RespondToInvitation(ctx, "carol", "1", storage.StatusAccepted); !errors.Is(err, storage.ErrForbidden) {
+		t.Errorf("expected ErrForbidden without invitation, got %v", err)
+	}
+	if err := s.RespondToInvitation(ctx, "alice", "2", storage.StatusAccepted); !errors.Is(err, storage.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound, got %v", err)
+	}
+	if err := s.RespondToInvitation(ctx, "alice", "1", storage.StatusNeedsAction); !errors.Is(err, storage.ErrInvalidEvent) {
+		t.Errorf("expected ErrInvalidEvent for a non-response status, got %v", err)
+	}
+
+	if events, _ := s.ListEventsForDay(ctx, "alice", start); len(events) != 0 {
+		t.Errorf("pending invitation must not be listed, got %+v", events)
+	}
+	if page, _ := s.ListEvents(ctx, storage.EventFilter{UserID: "alice"}); len(page.Events) != 0 {
+		t.Errorf("pending invitation must not be in ListEvents, got %+v", page.Events)
+	}
+	if page, _ := s.SearchEvents(ctx, storage.SearchQuery{UserID: "alice", Query: "sync"}); len(page.Results) != 0 {
+		t.Errorf("pending invitation must not be found, got %+v", page.Results)
+	}
+	if err := s.RespondToInvitation(ctx, "alice", "1", storage.StatusAccepted); err != nil {
+		t.Fatalf("RespondToInvitation failed: %v", err)
+	}
+	events, _ := s.ListEventsForDay(ctx, "alice", start)
+	if len(events) != 1 || events[0].ID != "1" {
+		t.Errorf("expected accepted event in alice's listing, got %+v", events)
+	}
+	page, err := s.ListEvents(ctx, storage.EventFilter{UserID: "alice", From: start})
+	if err != nil || len(page.Events) != 1 || page.Events[0].ID != "1" {
+		t.Errorf("expected accepted event in ListEvents, got %+v, %v", page.Events, err)
+	}
+	found, err := s.SearchEvents(ctx, storage.SearchQuery{UserID: "alice", Query: "sync"})
+	if err != nil || len(found.Results) != 1 || found.Results[0].Event.ID != "1" {
+		t.Errorf("expected accepted event in SearchEvents, got %+v, %v", found.Results, err)
+	}
+	if err := s.UpdateEvent(ctx, "alice", "1", created); !errors.Is(err, storage.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for an attendee update, got %v", err)
+	}
+
+	// Владелец убирает bob и приглашает carol; ответ alice сохраняется.
+	update := created
+	update.Title = "Sync (moved)"
+	update.Attendees = []storage.Attendee{{UserID: "alice"}, {UserID: "carol", Role: storage.RoleOptional}}
+	if err := s.UpdateEvent(ctx, "owner", "1", update); err != nil {
+		t.Fatalf("UpdateEvent failed: %v", err)
+	}
+	got, _ := s.GetEventByID(ctx, "owner", "1")
+	want := []storage.Attendee{
+		{UserID: "alice", Role: storage.RoleRequired, Status: storage.StatusAccepted},
+		{UserID: "carol", Role: storage.RoleOptional, Status: storage.StatusNeedsAction},
+	}
+	if fmt.Sprint(got.Attendees) != fmt.Sprint(want) || got.Version != 2 {
+		t.Errorf("expected %v at version 2, got %v at version %d", want, got.Attendees, got.Version)
+	}
+	if _, err := s.GetEventByID(ctx, "bob", "1"); !errors.Is(err, storage.ErrForbidden) {
+		t.Errorf("expected removed attendee to lose access, got %v", err)
+	}
+
+	// Общее событие есть у владельца с участниками и у участников между собой.
+	for _, pair := range [][2]string{{"owner", "carol"}, {"alice", "carol"}} {
+		if shares, err := s.SharesEvent(ctx, pair[0], pair[1]); err != nil || !shares {
+			t.Errorf("expected %s and %s to share an event, got %v, %v", pair[0], pair[1], shares, err)
+		}
+	}
+	if shares, _ := s.SharesEvent(ctx, "owner", "bob"); shares {
+		t.Error("removed attendee must not share the event")
+	}
+	if err := s.DeleteEvent(ctx, "owner", "1", 0); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+	if shares, _ := s.SharesEvent(ctx, "owner", "alice"); shares {
+		t.Error("deleted event must not be shared")
+	}
+}
+
+func TestStorage_Trash(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	old := start.AddDate(-2, 0, 0)
+	for _, e := range []storage.Event{
+		{ID: "1", Title: "Planning", StartTime: start, EndTime: start.Add(time.Hour), UserID: "user1"},
+		{ID: "2", Title: "Retro", StartTime: old, EndTime: old.Add(time.Hour), UserID: "user1"},
+	} {
+		if _, err := s.CreateEvent(ctx, e); err != nil {
+			t.Fatalf("CreateEvent failed: %v", err)
+		}
+	}
+	if err := s.DeleteEvent(ctx, "user1", "1", 0); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+
+	if events, _ := s.ListEventsForDay(ctx, "user1", start); len(events) != 0 {
+		t.Errorf("trashed event must not be listed, got %+v", events)
+	}
+	if page, _ := s.SearchEvents(ctx, storage.SearchQuery{UserID: "user1", Query: "planning"}); len(page.Results) != 0 {
+		t.Errorf("trashed event must not be found, got %+v", page.Results)
+	}
+	conflicts, _ := s.FindConflicts(ctx, storage.Event{
+		ID: "3", Title: "Overlap", StartTime: start, EndTime: start.Add(time.Hour), UserID: "user1",
+	})
+	if len(conflicts) != 0 {
+		t.Errorf("trashed event must not occupy time, got %v", conflicts)
+	}
+	if err := s.DeleteEvent(ctx, "user1", "1", 0); !errors.Is(err, storage.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound for a trashed event, got %v", err)
+	}
+
+	trash, _ := s.ListTrash(ctx, "user1")
+	if len(trash) != 1 || trash[0].ID != "1" || trash[0].DeletedAt == nil {
+		t.Fatalf("expected event 1 in trash, got %+v", trash)
+	}
+	if err := s.RestoreEvent(ctx, "user2", "1"); !errors.Is(err, storage.ErrForbidden) {
+		t.Errorf("expected ErrForbidden for another user, got %v", err)
+	}
+	if err := s.RestoreEvent(ctx, "user1", "2"); !errors.Is(err, storage.ErrEventNotFound) {
+		t.Errorf("expected ErrEventNotFound for an event outside trash, got %v", err)
+	}
+	if err := s.RestoreEvent(ctx, "user1", "1"); err != nil {
+		t.Fatalf("RestoreEvent failed: %v", err)
+	}
+	got, err := s.GetEventByID(ctx, "user1", "1")
+	if err != nil || got.DeletedAt != nil {
+		t.Fatalf("expected restored event, got %+v, %v", got, err)
+	}
+	// Удаление и восстановление меняют версию: правка по версии до удаления отклоняется.
+	if got.Version != 3 {
+		t.Errorf("expected version 3 after delete and restore, got %d", got.Version)
+	}
+	stale := *got
+	stale.Version = 1
+	if err := s.UpdateEvent(ctx, "user1", "1", stale); !errors.Is(err, storage.ErrVersionConflict) {
+		t.Errorf("expected ErrVersionConflict for the pre-delete version, got %v", err)
+	}
+
+	if err := s.DeleteOldEvents(ctx, start.AddDate(-1, 0, 0)); err != nil {
+		t.Fatalf("DeleteOldEvents failed: %v", err)
+	}
+	if trash, _ := s.ListTrash(ctx, "user1"); len(trash) != 1 || trash[0].ID != "2" {
+		t.Fatalf("expected the old event moved to trash, got %+v", trash)
+	}
+	if err := s.PurgeTrash(ctx, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("PurgeTrash failed: %v", err)
+	}
+	if trash, _ := s.ListTrash(ctx, "user1"); len(trash) != 1 {
+		t.Errorf("recently trashed event must survive purge, got %+v", trash)
+	}
+	if err := s.PurgeTrash(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("PurgeTrash failed: %v", err)
+	}
+	if trash, _ := s.ListTrash(ctx, "user1"); len(trash) != 0 {
+		t.Errorf("expected trash to be purged, got %+v", trash)
+	}
+	if err := s.RestoreEvent(ctx, "user1", "2"); !errors.Is(err, storage.ErrEventNotFound) {
+		t.Errorf("expected purged event to be gone, got %v", err)
+	}
+}
+
+func TestStorage_AuditEntries(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	event, err := s.CreateEvent(ctx, storage.Event{
+		ID: "1", Title: "Sync", StartTime: start, EndTime: start.Add(time.Hour), UserID: "user1",
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	entries := []storage.AuditEntry{
+		{EventID: "1", Actor: "user1", Action: storage.AuditCreated, At: start, Changes: storage.DiffEvents(nil, &event)},
+		{
+			EventID: "1", Actor: "user1", Action: storage.AuditUpdated, At: start.Add(time.Minute),
+			Changes: []storage.FieldChange{{Field: "title", Old: "Sync", New: "Planning"}},
+		},
+	}
+	for _, e := range entries {
+		if err := s.AddAuditEntry(ctx, e); err != nil {
+			t.Fatalf("AddAuditEntry failed: %v", err)
+		}
+	}
+
+	got, err := s.ListAuditEntries(ctx, "1")
+	if err != nil || len(got) != 2 {
+		t.Fatalf("expected 2 entries, got %+v, %v", got, err)
+	}
+	if got[0].ID == "" || got[0].Action != storage.AuditCreated || !got[1].At.Equal(start.Add(time.Minute)) {
+		t.Errorf("expected entries with generated IDs in order, got %+v", got)
+	}
+	if fmt.Sprint(got[1].Changes) != fmt.Sprint(entries[1].Changes) {
+		t.Errorf("expected %v, got %v", entries[1].Changes, got[1].Changes)
+	}
+	if got, _ := s.ListAuditEntries(ctx, "2"); len(got) != 0 {
+		t.Errorf("expected empty history for an unknown event, got %+v", got)
+	}
+
+	if err := s.DeleteEvent(ctx, "user1", "1", 0); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+	if got, _ := s.ListAuditEntries(ctx, "1"); len(got) != 2 {
+		t.Errorf("expected history to survive deletion, got %+v", got)
+	}
+	if err := s.PurgeTrash(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("PurgeTrash failed: %v", err)
+	}
+	if got, _ := s.ListAuditEntries(ctx, "1"); len(got) != 2 {
+		t.Errorf("expected history to outlive the purged event, got %+v", got)
+	}
+}
+
+func TestStorage_CreateEvent_DuplicateID(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	event := storage.Event{ID: "x", Title: "Alice's", StartTime: start, EndTime: start.Add(time.Hour), UserID: "alice"}
+	if _, err := s.CreateEvent(ctx, event); err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	stolen := event
+	stolen.Title, stolen.UserID = "Mallory's", "mallory"
+	if _, err := s.CreateEvent(ctx, stolen); !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists for another user's ID, got %v", err)
+	}
+	got, err := s.GetEventByID(ctx, "alice", "x")
+	if err != nil || got.Title != "Alice's" {
+		t.Errorf("expected alice's event to be intact, got %+v, %v", got, err)
+	}
+
+	if err := s.DeleteEvent(ctx, "alice", "x", 0); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+	if _, err := s.CreateEvent(ctx, event); !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists for a trashed event's ID, got %v", err)
+	}
+}
+
+func TestStorage_CreateEvent_DuplicateUID(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+	start := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+
+	event := storage.Event{UID: "ics-1", Title: "Imported", StartTime: start, EndTime: start.Add(time.Hour), UserID: "alice"}
+	created, err := s.CreateEvent(ctx, event)
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	if created.ID == "" || created.ID == "ics-1" || created.UID != "ics-1" {
+		t.Errorf("expected generated ID and kept UID, got %+v", created)
+	}
+	if _, err := s.CreateEvent(ctx, event); !errors.Is(err, storage.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists for a repeated UID, got %v", err)
+	}
+
+	other := event
+	other.UserID = "bob"
+	if _, err := s.CreateEvent(ctx, other); err != nil {
+		t.Errorf("expected another user to reuse the UID, got %v", err)
+	}
+
+	created.Title = "Renamed"
+	created.UID = "changed"
+	if err := s.UpdateEvent(ctx, "alice", created.ID, created); err != nil {
+		t.Fatalf("UpdateEvent failed: %v", err)
+	}
+	updated, err := s.GetEventByID(ctx, "alice", created.ID)
+	if err != nil {
+		t.Fatalf("GetEventByID failed: %v", err)
+	}
+	if updated.UID != "ics-1" {
+		t.Errorf("expected UID to survive update, got %q", updated.UID)
+	}
+}
@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// OutboxMessage - уведомление, ожидающее публикации в брокер.
+// Записывается в одной транзакции с отметкой напоминания и удаляется после подтверждения брокера.
+type OutboxMessage struct {
+	// ID - ключ идемпотентности: одинаков для всех попыток доставки одного напоминания.
+	ID        string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// NotificationKey возвращает ключ идемпотентности напоминания reminderID о вхождении,
+// начинающемся в occurrence.
+func NotificationKey(eventID, reminderID string, occurrence time.Time) string {
+	return fmt.Sprintf("%s/%s/%d", eventID, reminderID, occurrence.UnixNano())
+}
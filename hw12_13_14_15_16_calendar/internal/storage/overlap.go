@@ -0,0 +1,50 @@
+package storage
+
+import "fmt"
+
+// Transparency - занимает ли событие время владельца (TRANSP из RFC 5545).
+type Transparency string
+
+const (
+	// TransparencyBusy - событие занимает время; так считаются события без прозрачности.
+	TransparencyBusy Transparency = "busy"
+	// TransparencyFree - событие время не занимает: предварительная бронь, запись-напоминание.
+	TransparencyFree Transparency = "free"
+)
+
+// Valid сообщает, известна ли прозрачность; пустая допустима и означает TransparencyBusy,
+// а для события на весь день - TransparencyFree.
+func (t Transparency) Valid() bool {
+	return t == "" || t == TransparencyBusy || t == TransparencyFree
+}
+
+// OrBusy возвращает прозрачность, заменяя пустую на TransparencyBusy.
+func (t Transparency) OrBusy() Transparency {
+	if t == "" {
+		return TransparencyBusy
+	}
+	return t
+}
+
+// OverlapPolicy - что делать при сохранении события, пересекающегося с занятым временем владельца.
+type OverlapPolicy string
+
+const (
+	// OverlapReject - отклонить сохранение с ErrDateBusy.
+	OverlapReject OverlapPolicy = "reject"
+	// OverlapWarn - сохранить событие и сообщить ID пересекающихся событий.
+	OverlapWarn OverlapPolicy = "warn"
+	// OverlapAllow - сохранить событие без проверки пересечений.
+	OverlapAllow OverlapPolicy = "allow"
+)
+
+// ParseOverlapPolicy разбирает политику пересечений; пустая строка - OverlapReject.
+func ParseOverlapPolicy(s string) (OverlapPolicy, error) {
+	switch p := OverlapPolicy(s); p {
+	case "":
+		return OverlapReject, nil
+	case OverlapReject, OverlapWarn, OverlapAllow:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown overlap policy %q, want reject, warn or allow", s)
+}
@@ -0,0 +1,460 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency - частота повторения события (FREQ в RRULE).
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+)
+
+// ConflictHorizon ограничивает глубину проверки пересечений для бесконечных повторений.
+const ConflictHorizon = 366 * 24 * time.Hour
+
+// icalTimeLayout - формат даты-времени в UTC из RFC 5545 (UNTIL, EXDATE).
+const icalTimeLayout = "20060102T150405Z"
+
+var ErrInvalidRRule = errors.New("invalid recurrence rule")
+
+// WeekdayNum - элемент BYDAY: день недели с необязательным порядковым номером (1MO, -1FR).
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// RecurrenceRule - поддерживаемое подмножество RRULE из RFC 5545.
+type RecurrenceRule struct {
+	Freq     Frequency
+	Interval int
+	Count    int
+	Until    *time.Time
+	ByDay    []WeekdayNum
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+func weekdayCode(d time.Weekday) string {
+	for code, wd := range weekdayCodes {
+		if wd == d {
+			return code
+		}
+	}
+	return ""
+}
+
+// ParseRRule разбирает строку вида "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10".
+func ParseRRule(s string) (*RecurrenceRule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRRule)
+	}
+
+	rule := &RecurrenceRule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRRule, part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(value))
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: bad INTERVAL %q", ErrInvalidRRule, value)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: bad COUNT %q", ErrInvalidRRule, value)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseICalTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: bad UNTIL %q", ErrInvalidRRule, value)
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, item := range strings.Split(value, ",") {
+				wn, err := parseWeekdayNum(item)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, wn)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %q", ErrInvalidRRule, key)
+		}
+	}
+
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Validate проверяет согласованность правила.
+func (r *RecurrenceRule) Validate() error {
+	switch r.Freq {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+	default:
+		return fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRRule, r.Freq)
+	}
+	if r.Interval < 1 {
+		return fmt.Errorf("%w: INTERVAL must be positive", ErrInvalidRRule)
+	}
+	if r.Count > 0 && r.Until != nil {
+		return fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRRule)
+	}
+	for _, wn := range r.ByDay {
+		if wn.N != 0 && r.Freq != FrequencyMonthly {
+			return fmt.Errorf("%w: ordinal BYDAY is allowed only for MONTHLY", ErrInvalidRRule)
+		}
+	}
+	return nil
+}
+
+// String возвращает правило в формате RRULE (без префикса "RRULE:").
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(icalTimeLayout))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, wn := range r.ByDay {
+			code := weekdayCode(wn.Weekday)
+			if wn.N != 0 {
+				code = strconv.Itoa(wn.N) + code
+			}
+			days = append(days, code)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("%w: bad BYDAY %q", ErrInvalidRRule, s)
+	}
+	wd, ok := weekdayCodes[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("%w: bad BYDAY %q", ErrInvalidRRule, s)
+	}
+	wn := WeekdayNum{Weekday: wd}
+	if prefix := s[:len(s)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("%w: bad BYDAY %q", ErrInvalidRRule, s)
+		}
+		wn.N = n
+	}
+	return wn, nil
+}
+
+func parseICalTime(s string) (time.Time, error) {
+	if t, err := time.Parse(icalTimeLayout, s); err == nil {
+		return t, nil
+	}
+	// UNTIL может быть задан датой без времени - тогда включаем весь день.
+	t, err := time.Parse("20060102", s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(24*time.Hour - time.Second), nil
+}
+
+// FormatExDates сериализует даты исключений в список EXDATE через запятую.
+func FormatExDates(dates []time.Time) string {
+	items := make([]string, 0, len(dates))
+	for _, d := range dates {
+		items = append(items, d.UTC().Format(icalTimeLayout))
+	}
+	return strings.Join(items, ",")
+}
+
+// ParseExDates разбирает список, сформированный FormatExDates.
+func ParseExDates(s string) ([]time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	items := strings.Split(s, ",")
+	dates := make([]time.Time, 0, len(items))
+	for _, item := range items {
+		d, err := time.Parse(icalTimeLayout, item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse exdate %q: %w", item, err)
+		}
+		dates = append(dates, d)
+	}
+	return dates, nil
+}
+
+// IsRecurring сообщает, задано ли для события правило повторения.
+func (e Event) IsRecurring() bool {
+	return e.RRule != nil
+}
+
+// Occurrences возвращает вхождения события, начинающиеся в интервале [from, to).
+// Для неповторяющегося события это само событие, если оно попадает в интервал.
+func (e Event) Occurrences(from, to time.Time) []Event {
+	var result []Event
+	e.eachOccurrence(from, to, func(occ Event) bool {
+		result = append(result, occ)
+		return true
+	})
+	return result
+}
+
+// OccurrencesOverlapping возвращает вхождения события, пересекающиеся с интервалом [from, to):
+// в отличие от Occurrences сюда попадают и вхождения, начавшиеся до from, но еще не закончившиеся.
+// Вхождение нулевой длительности попадает, если начинается в интервале.
+func (e Event) OccurrencesOverlapping(from, to time.Time) []Event {
+	var result []Event
+	e.eachOccurrenceOverlapping(from, to, time.Time{}, func(occ Event) bool {
+		result = append(result, occ)
+		return true
+	})
+	return result
+}
+
+// eachOccurrenceOverlapping вызывает fn для вхождений, пересекающихся с [from, to)
+// и начинающихся не раньше notBefore, пока fn возвращает true.
+func (e Event) eachOccurrenceOverlapping(from, to, notBefore time.Time, fn func(Event) bool) {
+	start := from.Add(-max(e.EndTime.Sub(e.StartTime), 0))
+	if notBefore.After(start) {
+		start = notBefore
+	}
+	e.eachOccurrence(start, to, func(occ Event) bool {
+		if !occ.EndTime.After(from) && occ.StartTime.Before(from) {
+			return true
+		}
+		return fn(occ)
+	})
+}
+
+// eachOccurrence вызывает fn для вхождений, начинающихся в [from, to), пока fn возвращает true.
+// Нулевой to означает отсутствие верхней границы.
+func (e Event) eachOccurrence(from, to time.Time, fn func(Event) bool) {
+	if !e.IsRecurring() {
+		if !e.StartTime.Before(from) && (to.IsZero() || e.StartTime.Before(to)) {
+			fn(e)
+		}
+		return
+	}
+
+	duration := e.EndTime.Sub(e.StartTime)
+	e.RRule.iterate(e.StartTime.In(e.Location()), to, func(start time.Time) bool {
+		if start.Before(from) || e.isExcluded(start) {
+			return true
+		}
+		occ := e
+		occ.StartTime = start
+		occ.EndTime = start.Add(duration)
+		return fn(occ)
+	})
+}
+
+// SeriesEnd возвращает момент окончания последнего вхождения, но не позже horizon.
+func (e Event) SeriesEnd(horizon time.Time) time.Time {
+	if !e.IsRecurring() {
+		return e.EndTime
+	}
+	if e.RRule.Count == 0 && e.RRule.Until == nil {
+		return horizon
+	}
+	occurrences := e.Occurrences(e.StartTime, horizon)
+	if len(occurrences) == 0 {
+		return e.EndTime
+	}
+	return occurrences[len(occurrences)-1].EndTime
+}
+
+// Overlaps сообщает, пересекаются ли по времени вхождения двух событий.
+// Бесконечные повторения проверяются в пределах ConflictHorizon.
+// События, не занимающие время (см. OccupiesTime), ни с чем не пересекаются.
+func Overlaps(a, b Event) bool {
+	if !a.OccupiesTime() || !b.OccupiesTime() {
+		return false
+	}
+	from := a.StartTime
+	if b.StartTime.Before(from) {
+		from = b.StartTime
+	}
+	latest := a.StartTime
+	if b.StartTime.After(latest) {
+		latest = b.StartTime
+	}
+	to := latest.Add(ConflictHorizon)
+
+	occA := a.Occurrences(from, to)
+	occB := b.Occurrences(from, to)
+	i, j := 0, 0
+	for i < len(occA) && j < len(occB) {
+		if occA[i].StartTime.Before(occB[j].EndTime) && occB[j].StartTime.Before(occA[i].EndTime) {
+			return true
+		}
+		if occA[i].EndTime.Before(occB[j].EndTime) {
+			i++
+		} else {
+			j++
+		}
+	}
+	return false
+}
+
+func (e Event) isExcluded(start time.Time) bool {
+	for _, d := range e.ExDates {
+		if d.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// iterate вызывает fn для каждого вхождения правила, начиная с dtstart и до to (не включая),
+// с учетом COUNT и UNTIL. Перебор прекращается, если fn вернула false; нулевой to и отсутствие
+// COUNT/UNTIL дают бесконечную серию, поэтому в таком случае fn обязана остановить перебор.
+func (r *RecurrenceRule) iterate(dtstart, to time.Time, fn func(time.Time) bool) {
+	count := 0
+	emit := func(t time.Time) bool {
+		if (!to.IsZero() && !t.Before(to)) || (r.Until != nil && t.After(*r.Until)) ||
+			(r.Count > 0 && count >= r.Count) {
+			return false
+		}
+		count++
+		return fn(t)
+	}
+
+	// DTSTART всегда является первым вхождением.
+	if !emit(dtstart) {
+		return
+	}
+
+	for period := 0; ; period++ {
+		candidates, periodStart := r.candidates(dtstart, period)
+		if !to.IsZero() && !periodStart.Before(to) {
+			return
+		}
+		for _, c := range candidates {
+			if !c.After(dtstart) {
+				continue
+			}
+			if !emit(c) {
+				return
+			}
+		}
+	}
+}
+
+// candidates возвращает отсортированных кандидатов в вхождения для периода с номером period
+// и момент начала этого периода.
+func (r *RecurrenceRule) candidates(dtstart time.Time, period int) ([]time.Time, time.Time) {
+	step := period * r.Interval
+	switch r.Freq {
+	case FrequencyDaily:
+		day := dtstart.AddDate(0, 0, step)
+		if len(r.ByDay) > 0 && !r.matchesWeekday(day.Weekday()) {
+			return nil, day
+		}
+		return []time.Time{day}, day
+	case FrequencyWeekly:
+		// Неделя начинается с понедельника (WKST=MO по умолчанию).
+		offset := (int(dtstart.Weekday()) + 6) % 7
+		weekStart := dtstart.AddDate(0, 0, step*7-offset)
+		if len(r.ByDay) == 0 {
+			return []time.Time{dtstart.AddDate(0, 0, step*7)}, weekStart
+		}
+		res := make([]time.Time, 0, len(r.ByDay))
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			if r.matchesWeekday(day.Weekday()) {
+				res = append(res, day)
+			}
+		}
+		return res, weekStart
+	case FrequencyMonthly:
+		monthStart := time.Date(dtstart.Year(), dtstart.Month()+time.Month(step), 1,
+			dtstart.Hour(), dtstart.Minute(), dtstart.Second(), dtstart.Nanosecond(), dtstart.Location())
+		if len(r.ByDay) == 0 {
+			day := monthStart.AddDate(0, 0, dtstart.Day()-1)
+			if day.Month() != monthStart.Month() {
+				// Несуществующие даты (например, 31 февраля) пропускаются.
+				return nil, monthStart
+			}
+			return []time.Time{day}, monthStart
+		}
+		return r.monthlyByDay(monthStart), monthStart
+	}
+	return nil, dtstart
+}
+
+func (r *RecurrenceRule) monthlyByDay(monthStart time.Time) []time.Time {
+	var days []time.Time
+	for d := monthStart; d.Month() == monthStart.Month(); d = d.AddDate(0, 0, 1) {
+		days = append(days, d)
+	}
+
+	seen := make(map[int]bool)
+	var res []time.Time
+	for _, wn := range r.ByDay {
+		var matching []int
+		for i, d := range days {
+			if d.Weekday() == wn.Weekday {
+				matching = append(matching, i)
+			}
+		}
+		switch {
+		case wn.N == 0:
+			for _, i := range matching {
+				seen[i] = true
+			}
+		case wn.N > 0 && wn.N <= len(matching):
+			seen[matching[wn.N-1]] = true
+		case wn.N < 0 && -wn.N <= len(matching):
+			seen[matching[len(matching)+wn.N]] = true
+		}
+	}
+	for i := range seen {
+		res = append(res, days[i])
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Before(res[j]) })
+	return res
+}
+
+func (r *RecurrenceRule) matchesWeekday(d time.Weekday) bool {
+	for _, wn := range r.ByDay {
+		if wn.Weekday == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRRule(t *testing.T) {
+	rule, err := ParseRRule("RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=5;BYDAY=MO,WE")
+	if err != nil {
+		t.Fatalf("ParseRRule failed: %v", err)
+	}
+	if rule.Freq != FrequencyWeekly || rule.Interval != 2 || rule.Count != 5 || len(rule.ByDay) != 2 {
+		t.Errorf("unexpected rule: %+v", rule)
+	}
+	if rule.String() != "FREQ=WEEKLY;INTERVAL=2;COUNT=5;BYDAY=MO,WE" {
+		t.Errorf("unexpected string form: %s", rule.String())
+	}
+
+	for _, bad := range []string{"", "FREQ=YEARLY", "FREQ=DAILY;INTERVAL=0", "FREQ=DAILY;BYDAY=XX",
+		"FREQ=DAILY;COUNT=2;UNTIL=20260101T000000Z", "FREQ=WEEKLY;BYDAY=1MO"} {
+		if _, err := ParseRRule(bad); !errors.Is(err, ErrInvalidRRule) {
+			t.Errorf("ParseRRule(%q): expected ErrInvalidRRule, got %v", bad, err)
+		}
+	}
+}
+
+func TestEvent_Occurrences(t *testing.T) {
+	// понедельник
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		rrule string
+		want  []string
+	}{
+		{
+			name:  "daily with count",
+			rrule: "FREQ=DAILY;COUNT=3",
+			want:  []string{"2026-10-05", "2026-10-06", "2026-10-07"},
+		},
+		{
+			name:  "weekly by day",
+			rrule: "FREQ=WEEKLY;BYDAY=MO,FR;UNTIL=20261013T000000Z",
+			want:  []string{"2026-10-05", "2026-10-09", "2026-10-12"},
+		},
+		{
+			name:  "weekly every other week",
+			rrule: "FREQ=WEEKLY;INTERVAL=2;COUNT=3",
+			want:  []string{"2026-10-05", "2026-10-19", "2026-11-02"},
+		},
+		{
+			name:  "monthly last friday",
+			rrule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			want:  []string{"2026-10-05", "2026-10-30", "2026-11-27"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := ParseRRule(tc.rrule)
+			if err != nil {
+				t.Fatalf("ParseRRule failed: %v", err)
+			}
+			e := Event{ID: "1", StartTime: start, EndTime: start.Add(time.Hour), RRule: rule}
+
+			occ := e.Occurrences(start, start.AddDate(1, 0, 0))
+			if len(occ) != len(tc.want) {
+				t.Fatalf("expected %d occurrences, got %d", len(tc.want), len(occ))
+			}
+			for i, o := range occ {
+				if got := o.StartTime.Format("2006-01-02"); got != tc.want[i] {
+					t.Errorf("occurrence %d: expected %s, got %s", i, tc.want[i], got)
+				}
+				if o.EndTime.Sub(o.StartTime) != time.Hour {
+					t.Errorf("occurrence %d: duration is not preserved", i)
+				}
+			}
+		})
+	}
+}
+
+func TestEvent_OccurrencesExDates(t *testing.T) {
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	rule, _ := ParseRRule("FREQ=DAILY;COUNT=3")
+	e := Event{
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		RRule:     rule,
+		ExDates:   []time.Time{start.AddDate(0, 0, 1)},
+	}
+
+	occ := e.Occurrences(start, start.AddDate(0, 1, 0))
+	if len(occ) != 2 {
+		t.Fatalf("expected 2 occurrences, got %d", len(occ))
+	}
+	if !occ[1].StartTime.Equal(start.AddDate(0, 0, 2)) {
+		t.Errorf("excluded date must be skipped, got %v", occ[1].StartTime)
+	}
+}
+
+func TestEvent_OccurrencesOverlapping(t *testing.T) {
+	start := time.Date(2026, 10, 5, 22, 0, 0, 0, time.UTC)
+	daily, _ := ParseRRule("FREQ=DAILY;COUNT=3")
+	e := Event{StartTime: start, EndTime: start.Add(4 * time.Hour), RRule: daily}
+
+	// Окно 6 октября: вхождение 5-го заканчивается в нем, вхождение 6-го в нем начинается.
+	from := time.Date(2026, 10, 6, 0, 0, 0, 0, time.UTC)
+	occ := e.OccurrencesOverlapping(from, from.AddDate(0, 0, 1))
+	if len(occ) != 2 || !occ[0].StartTime.Equal(start) || !occ[1].StartTime.Equal(start.AddDate(0, 0, 1)) {
+		t.Errorf("expected occurrences of 5 and 6 October, got %+v", occ)
+	}
+	if len(e.Occurrences(from, from.AddDate(0, 0, 1))) != 1 {
+		t.Error("Occurrences must keep start-based semantics")
+	}
+
+	// Событие, закончившееся ровно в начале окна, в него не попадает.
+	single := Event{StartTime: start, EndTime: from}
+	if occ := single.OccurrencesOverlapping(from, from.AddDate(0, 0, 1)); len(occ) != 0 {
+		t.Errorf("expected no occurrences, got %+v", occ)
+	}
+	point := Event{StartTime: from, EndTime: from}
+	if occ := point.OccurrencesOverlapping(from, from.AddDate(0, 0, 1)); len(occ) != 1 {
+		t.Errorf("expected zero-length event at window start, got %+v", occ)
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	weekly, _ := ParseRRule("FREQ=WEEKLY")
+	series := Event{StartTime: start, EndTime: start.Add(time.Hour), RRule: weekly}
+
+	nextWeek := Event{StartTime: start.AddDate(0, 0, 7).Add(30 * time.Minute), EndTime: start.AddDate(0, 0, 7).Add(2 * time.Hour)}
+	if !Overlaps(series, nextWeek) {
+		t.Error("expected conflict with second occurrence")
+	}
+
+	nextDay := Event{StartTime: start.AddDate(0, 0, 1), EndTime: start.AddDate(0, 0, 1).Add(time.Hour)}
+	if Overlaps(series, nextDay) {
+		t.Error("unexpected conflict on a free day")
+	}
+
+	holiday := NormalizeAllDay(Event{StartTime: start.Add(-10 * time.Hour), EndTime: start.Add(14 * time.Hour), AllDay: true})
+	if Overlaps(series, holiday) {
+		t.Error("all-day event without transparency must not conflict with timed events")
+	}
+	vacation := holiday
+	vacation.Transparency = TransparencyBusy
+	if !Overlaps(series, vacation) {
+		t.Error("expected busy all-day event to conflict with timed events")
+	}
+}
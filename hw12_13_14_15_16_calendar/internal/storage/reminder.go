@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+// Reminder - напоминание о событии за Offset до его начала.
+// Для серии напоминание срабатывает для каждого вхождения.
+type Reminder struct {
+	ID string
+	// Offset - за сколько до StartTime отправить напоминание.
+	Offset time.Duration
+	// Channel - канал доставки; пустой означает канал по умолчанию.
+	Channel string
+	// SentAt - момент отправки последнего напоминания.
+	SentAt *time.Time
+	// SentFor - начало вхождения, о котором напомнили последним.
+	SentFor *time.Time
+}
+
+// NotifyAt возвращает момент напоминания о вхождении, начинающемся в start.
+func (r Reminder) NotifyAt(start time.Time) time.Time {
+	return start.Add(-r.Offset)
+}
+
+// DueReminder - напоминание, которое пора отправить, и вхождение события, к которому оно относится.
+type DueReminder struct {
+	Event    Event
+	Reminder Reminder
+}
+
+// DueReminders возвращает напоминания события, которые пора отправить на момент now.
+// Для серии по каждому напоминанию берется последнее подошедшее вхождение.
+func (e Event) DueReminders(now time.Time) []DueReminder {
+	var due []DueReminder
+	for _, r := range e.Reminders {
+		from := e.StartTime
+		if r.SentFor != nil {
+			from = r.SentFor.Add(time.Nanosecond)
+		}
+		occurrences := e.Occurrences(from, now.Add(r.Offset).Add(time.Nanosecond))
+		if len(occurrences) == 0 {
+			continue
+		}
+		due = append(due, DueReminder{Event: occurrences[len(occurrences)-1], Reminder: r})
+	}
+	return due
+}
+
+// MergeReminders готовит напоминания next к сохранению поверх события current (nil при создании).
+// ID назначает хранилище. Напоминание, совпавшее с прежним по ID или по смещению
+// и каналу, сохраняет состояние отправки, если не изменились ни начало события, ни смещение;
+// иначе оно будет отправлено заново для нового времени. Результат упорядочен от самого раннего
+// напоминания к самому позднему.
+func MergeReminders(current *Event, next Event) ([]Reminder, error) {
+	if len(next.Reminders) == 0 {
+		return nil, nil
+	}
+	merged := make([]Reminder, 0, len(next.Reminders))
+	used := make(map[string]bool, len(next.Reminders))
+	for _, r := range next.Reminders {
+		if r.Offset < 0 {
+			return nil, ErrInvalidEvent
+		}
+		old, ok := Reminder{}, false
+		if current != nil {
+			old, ok = current.findReminder(r)
+			ok = ok && !used[old.ID]
+		}
+		r.ID, r.SentAt, r.SentFor = NewID(), nil, nil
+		if ok {
+			r.ID = old.ID
+			if current.StartTime.Equal(next.StartTime) && old.Offset == r.Offset {
+				r.SentAt, r.SentFor = old.SentAt, old.SentFor
+			}
+		}
+		used[r.ID] = true
+		merged = append(merged, r)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Offset > merged[j].Offset })
+	return merged, nil
+}
+
+func (e Event) findReminder(r Reminder) (Reminder, bool) {
+	for _, old := range e.Reminders {
+		if r.ID != "" && old.ID == r.ID {
+			return old, true
+		}
+	}
+	if r.ID != "" {
+		return Reminder{}, false
+	}
+	for _, old := range e.Reminders {
+		if old.Offset == r.Offset && old.Channel == r.Channel {
+			return old, true
+		}
+	}
+	return Reminder{}, false
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEvent_DueReminders(t *testing.T) {
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	daily, _ := ParseRRule("FREQ=DAILY")
+	e := Event{
+		ID: "1", StartTime: start, EndTime: start.Add(time.Hour), RRule: daily,
+		Reminders: []Reminder{
+			{ID: "day", Offset: 24 * time.Hour},
+			{ID: "quarter", Offset: 15 * time.Minute},
+		},
+	}
+
+	now := start.AddDate(0, 0, 2).Add(-10 * time.Minute)
+	due := e.DueReminders(now)
+	if len(due) != 2 {
+		t.Fatalf("expected 2 due reminders, got %d", len(due))
+	}
+	// За сутки уже пора напомнить о вхождении послезавтра, за 15 минут - тоже о нем.
+	for _, d := range due {
+		if !d.Event.StartTime.Equal(start.AddDate(0, 0, 2)) {
+			t.Errorf("reminder %s: expected the latest occurrence, got %v", d.Reminder.ID, d.Event.StartTime)
+		}
+	}
+
+	sent := start.AddDate(0, 0, 2)
+	e.Reminders[1].SentFor = &sent
+	due = e.DueReminders(now)
+	if len(due) != 1 || due[0].Reminder.ID != "day" {
+		t.Errorf("occurrence must not be notified twice by the same reminder, got %+v", due)
+	}
+
+	single := Event{ID: "2", StartTime: start, EndTime: start.Add(time.Hour), Reminders: []Reminder{{ID: "r", Offset: time.Hour}}}
+	if due := single.DueReminders(start.Add(-2 * time.Hour)); len(due) != 0 {
+		t.Errorf("reminder is not due yet, got %+v", due)
+	}
+	if due := single.DueReminders(start.Add(-time.Hour)); len(due) != 1 {
+		t.Errorf("expected reminder at notify time, got %+v", due)
+	}
+}
+
+func TestMergeReminders(t *testing.T) {
+	start := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	sentAt := start.Add(-time.Hour)
+	current := Event{
+		StartTime: start,
+		Reminders: []Reminder{
+			{ID: "a", Offset: time.Hour, SentAt: &sentAt, SentFor: &start},
+			{ID: "b", Offset: 24 * time.Hour, Channel: "email", SentAt: &sentAt, SentFor: &start},
+		},
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		got, err := MergeReminders(nil, Event{Reminders: []Reminder{{ID: "client", Offset: time.Minute, SentFor: &start}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[0].ID == "" || got[0].ID == "client" || got[0].SentFor != nil {
+			t.Errorf("expected fresh reminder, got %+v", got[0])
+		}
+	})
+
+	t.Run("KeepsSentState", func(t *testing.T) {
+		next := Event{StartTime: start, Reminders: []Reminder{{ID: "a", Offset: time.Hour}, {Offset: 24 * time.Hour, Channel: "email"}}}
+		got, err := MergeReminders(&current, next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Суточное напоминание раньше часового.
+		for i, id := range []string{"b", "a"} {
+			if got[i].ID != id || got[i].SentFor == nil {
+				t.Errorf("expected reminder %s to keep sent state, got %+v", id, got[i])
+			}
+		}
+	})
+
+	t.Run("StartTimeMoved", func(t *testing.T) {
+		next := Event{StartTime: start.Add(time.Hour), Reminders: []Reminder{{ID: "a", Offset: time.Hour}}}
+		got, err := MergeReminders(&current, next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[0].ID != "a" || got[0].SentFor != nil || got[0].SentAt != nil {
+			t.Errorf("expected reminder a to be rescheduled, got %+v", got[0])
+		}
+	})
+
+	t.Run("NegativeOffset", func(t *testing.T) {
+		_, err := MergeReminders(&current, Event{Reminders: []Reminder{{Offset: -time.Minute}}})
+		if !errors.Is(err, ErrInvalidEvent) {
+			t.Errorf("expected ErrInvalidEvent, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Веса полей при ранжировании; совпадают с весами A и B функции ts_rank в PostgreSQL.
+const (
+	TitleWeight       = 1.0
+	DescriptionWeight = 0.4
+)
+
+// SearchQuery - параметры полнотекстового поиска SearchEvents.
+// Событие подходит, если заголовок или описание содержат все слова Query.
+type SearchQuery struct {
+	UserID string
+	Query  string
+	Limit  int
+	Cursor string
+}
+
+// SearchResult - найденное событие и его релевантность.
+type SearchResult struct {
+	Event Event
+	Rank  float64
+}
+
+// SearchPage - страница результатов поиска, упорядоченных по убыванию Rank.
+// Пустой NextCursor означает последнюю страницу.
+type SearchPage struct {
+	Results    []SearchResult
+	NextCursor string
+}
+
+// PageLimit возвращает размер страницы с учетом значения по умолчанию и максимума.
+func (q SearchQuery) PageLimit() int {
+	return EventFilter{Limit: q.Limit}.PageLimit()
+}
+
+// Terms возвращает слова запроса без повторов. Пустой результат - ErrInvalidQuery.
+func (q SearchQuery) Terms() ([]string, error) {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, t := range Tokenize(q.Query) {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, ErrInvalidQuery
+	}
+	return terms, nil
+}
+
+// Offset разбирает курсор поиска - смещение от начала выдачи.
+func (q SearchQuery) Offset() (int, error) {
+	if q.Cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(string(raw), "offset:"))
+	if err != nil || n < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return n, nil
+}
+
+// SearchCursor кодирует смещение следующей страницы поиска.
+func SearchCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+// Tokenize разбивает текст на слова в нижнем регистре, как конфигурация 'simple' в PostgreSQL.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SortSearchResults упорядочивает результаты по убыванию Rank, затем по (StartTime, ID).
+func SortSearchResults(results []SearchResult) {
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if !a.Event.StartTime.Equal(b.Event.StartTime) {
+			return a.Event.StartTime.Before(b.Event.StartTime)
+		}
+		return a.Event.ID < b.Event.ID
+	})
+}
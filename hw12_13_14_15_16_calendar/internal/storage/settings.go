@@ -0,0 +1,37 @@
+package storage
+
+import "time"
+
+// UserSettings - настройки пользователя.
+type UserSettings struct {
+	UserID string
+	// TimeZone - часовой пояс IANA, в котором считаются дни, недели и месяцы,
+	// если запрос не задает пояс явно; пустой - UTC.
+	TimeZone string
+	// Locale - локаль пользователя вида ru-RU; по ее региону выбирается первый день недели.
+	Locale string
+	// WeekStart - первый день недели ("monday", "sunday"); пустой - по локали.
+	WeekStart string
+}
+
+// FirstDayOfWeek возвращает первый день недели пользователя: WeekStart, если он задан,
+// иначе день, принятый в его локали.
+func (s UserSettings) FirstDayOfWeek() time.Weekday {
+	if d, err := ParseWeekday(s.WeekStart); err == nil {
+		return d
+	}
+	return WeekStartForLocale(s.Locale)
+}
+
+// Validate проверяет часовой пояс и первый день недели.
+func (s UserSettings) Validate() error {
+	if _, err := LoadLocation(s.TimeZone); err != nil {
+		return err
+	}
+	if s.WeekStart != "" {
+		if _, err := ParseWeekday(s.WeekStart); err != nil {
+			return err
+		}
+	}
+	return nil
+}
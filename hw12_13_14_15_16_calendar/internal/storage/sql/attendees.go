@@ -0,0 +1,114 @@
+package sqlstorage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// attendeeRow - представление строки таблицы event_attendees.
+type attendeeRow struct {
+	EventID string `db:"event_id"`
+	UserID  string `db:"user_id"`
+	Role    string `db:"role"`
+	Status  string `db:"status"`
+}
+
+// insertAttendees сохраняет участников события. Для уже приглашенного участника
+// обновляется только роль: ответ, данный им после чтения события, не теряется.
+func insertAttendees(ctx context.Context, tx *sqlx.Tx, event storage.Event) error {
+	if len(event.Attendees) == 0 {
+		return nil
+	}
+	rows := make([]attendeeRow, 0, len(event.Attendees))
+	for _, a := range event.Attendees {
+		rows = append(rows, attendeeRow{
+			EventID: event.ID, UserID: a.UserID, Role: string(a.Role), Status: string(a.Status),
+		})
+	}
+	_, err := tx.NamedExecContext(ctx, `
+		INSERT INTO event_attendees (event_id, user_id, role, status)
+		VALUES (:event_id, :user_id, :role, :status)
+		ON CONFLICT (event_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`, rows)
+	if err != nil {
+		return fmt.Errorf("failed to save attendees: %w", err)
+	}
+	return nil
+}
+
+// replaceAttendees удаляет участников, исключенных из события, и сохраняет оставшихся.
+func replaceAttendees(ctx context.Context, tx *sqlx.Tx, event storage.Event) error {
+	userIDs := make([]string, 0, len(event.Attendees))
+	for _, a := range event.Attendees {
+		userIDs = append(userIDs, a.UserID)
+	}
+	_, err := tx.ExecContext(ctx,
+		"DELETE FROM event_attendees WHERE event_id = $1 AND NOT (user_id = ANY($2))", event.ID, pq.Array(userIDs))
+	if err != nil {
+		return fmt.Errorf("failed to update attendees: %w", err)
+	}
+	return insertAttendees(ctx, tx, event)
+}
+
+// attachAttendees загружает участников событий одним запросом.
+func (s *Storage) attachAttendees(ctx context.Context, events []storage.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+
+	var rows []attendeeRow
+	err := s.querier().SelectContext(ctx, &rows, `
+		SELECT event_id, user_id, role, status FROM event_attendees
+		WHERE event_id = ANY($1)
+		ORDER BY position
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load attendees: %w", err)
+	}
+
+	byEvent := make(map[string][]storage.Attendee)
+	for _, r := range rows {
+		byEvent[r.EventID] = append(byEvent[r.EventID], storage.Attendee{
+			UserID: r.UserID,
+			Role:   storage.AttendeeRole(r.Role),
+			Status: storage.RSVPStatus(r.Status),
+		})
+	}
+	for i := range events {
+		events[i].Attendees = byEvent[events[i].ID]
+	}
+	return nil
+}
+
+func (s *Storage) RespondToInvitation(ctx context.Context, userID, id string, status storage.RSVPStatus) error {
+	if !status.IsResponse() {
+		return fmt.Errorf("%w: unknown response %q", storage.ErrInvalidEvent, status)
+	}
+
+	result, err := s.querier().ExecContext(ctx,
+		`UPDATE event_attendees SET status = $3
+		WHERE event_id = $1 AND user_id = $2
+		AND event_id IN (SELECT id FROM events WHERE deleted_at IS NULL)`, id, userID, string(status))
+	if err != nil {
+		return fmt.Errorf("failed to respond to invitation: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows > 0 {
+		return nil
+	}
+	if _, err := s.GetEventByID(ctx, userID, id); err != nil {
+		return err
+	}
+	return storage.ErrForbidden
+}
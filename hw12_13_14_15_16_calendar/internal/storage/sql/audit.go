@@ -0,0 +1,84 @@
+package sqlstorage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// auditRow - представление строки таблицы event_audit.
+type auditRow struct {
+	ID        string    `db:"id"`
+	EventID   string    `db:"event_id"`
+	Actor     string    `db:"actor"`
+	Action    string    `db:"action"`
+	ChangedAt time.Time `db:"changed_at"`
+	Changes   []byte    `db:"changes"`
+}
+
+// fieldChangeJSON - элемент колонки changes.
+type fieldChangeJSON struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+func (s *Storage) AddAuditEntry(ctx context.Context, entry storage.AuditEntry) error {
+	if entry.ID == "" {
+		entry.ID = storage.NewID()
+	}
+	changes := make([]fieldChangeJSON, 0, len(entry.Changes))
+	for _, c := range entry.Changes {
+		changes = append(changes, fieldChangeJSON{Field: c.Field, Old: c.Old, New: c.New})
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	// changes передается строкой: []byte драйвер отправил бы как bytea.
+	_, err = s.querier().ExecContext(ctx, `
+		INSERT INTO event_audit (id, event_id, actor, action, changed_at, changes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, entry.ID, entry.EventID, entry.Actor, string(entry.Action), entry.At, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save audit entry: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ListAuditEntries(ctx context.Context, id string) ([]storage.AuditEntry, error) {
+	var rows []auditRow
+	err := s.querier().SelectContext(ctx, &rows, `
+		SELECT id, event_id, actor, action, changed_at, changes FROM event_audit
+		WHERE event_id = $1
+		ORDER BY changed_at, id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	entries := make([]storage.AuditEntry, 0, len(rows))
+	for _, r := range rows {
+		var changes []fieldChangeJSON
+		if err := json.Unmarshal(r.Changes, &changes); err != nil {
+			return nil, fmt.Errorf("audit entry %s: %w", r.ID, err)
+		}
+		entry := storage.AuditEntry{
+			ID:      r.ID,
+			EventID: r.EventID,
+			Actor:   r.Actor,
+			Action:  storage.AuditAction(r.Action),
+			At:      r.ChangedAt,
+			Changes: make([]storage.FieldChange, 0, len(changes)),
+		}
+		for _, c := range changes {
+			entry.Changes = append(entry.Changes, storage.FieldChange{Field: c.Field, Old: c.Old, New: c.New})
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
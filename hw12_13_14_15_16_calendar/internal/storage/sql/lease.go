@@ -0,0 +1,156 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// claimRow - напоминание, заблокированное для захвата.
+type claimRow struct {
+	ID      string `db:"id"`
+	EventID string `db:"event_id"`
+}
+
+func (s *Storage) ClaimEventsToNotify(
+	ctx context.Context, owner string, lease time.Duration,
+) ([]storage.DueReminder, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// SKIP LOCKED пропускает строки, которые в этот момент захватывает параллельное сканирование,
+	// условие на claimed_until - захваченные ранее и еще не отмеченные другим экземпляром.
+	var candidates []claimRow
+	err = tx.SelectContext(ctx, &candidates, `
+		SELECT r.id, r.event_id
+		FROM reminders r
+		JOIN events e ON e.id = r.event_id
+		WHERE e.deleted_at IS NULL
+		AND e.start_time - r.offset_seconds * INTERVAL '1 second' <= NOW()
+		AND (e.rrule <> '' OR r.sent_for IS NULL)
+		AND (r.claimed_until IS NULL OR r.claimed_until <= NOW() OR r.claimed_by = $1)
+		FOR UPDATE OF r SKIP LOCKED
+	`, owner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim events to notify: %w", err)
+	}
+	if len(candidates) == 0 {
+		return []storage.DueReminder{}, nil
+	}
+
+	locked := make(map[string]bool, len(candidates))
+	eventIDs := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		locked[c.ID] = true
+		eventIDs = append(eventIDs, c.EventID)
+	}
+
+	var rows []eventRow
+	err = tx.SelectContext(ctx, &rows, `SELECT `+eventColumns+` FROM events WHERE id = ANY($1)`, pq.Array(eventIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim events to notify: %w", err)
+	}
+	events, err := toEvents(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim events to notify: %w", err)
+	}
+	if err := s.attachDetails(ctx, events); err != nil {
+		return nil, err
+	}
+
+	// Захватываются только действительно подошедшие напоминания: для серии отбор
+	// в запросе грубее, и остальные ее напоминания должны остаться свободными.
+	now := time.Now()
+	due := []storage.DueReminder{}
+	var claimed []string
+	for _, e := range events {
+		for _, d := range e.DueReminders(now) {
+			if locked[d.Reminder.ID] {
+				due = append(due, d)
+				claimed = append(claimed, d.Reminder.ID)
+			}
+		}
+	}
+	if len(claimed) > 0 {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE reminders SET claimed_by = $1, claimed_until = NOW() + $2 * INTERVAL '1 second'
+			WHERE id = ANY($3)
+		`, owner, lease.Seconds(), pq.Array(claimed))
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim events to notify: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return due, nil
+}
+
+func (s *Storage) ClaimOutbox(
+	ctx context.Context, owner string, lease time.Duration, limit int,
+) ([]storage.OutboxMessage, error) {
+	// Как в ClaimEventsToNotify: SKIP LOCKED разводит параллельные захваты,
+	// условие на claimed_until пропускает сообщения, уже захваченные другим экземпляром.
+	var rows []outboxRow
+	err := s.querier().SelectContext(ctx, &rows, `
+		UPDATE outbox SET claimed_by = $1, claimed_until = NOW() + $2 * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE claimed_until IS NULL OR claimed_until <= NOW() OR claimed_by = $1
+			ORDER BY created_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, payload, created_at
+	`, owner, lease.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+	// RETURNING не сохраняет порядок подзапроса.
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].CreatedAt.Equal(rows[j].CreatedAt) {
+			return rows[i].CreatedAt.Before(rows[j].CreatedAt)
+		}
+		return rows[i].ID < rows[j].ID
+	})
+	messages := make([]storage.OutboxMessage, 0, len(rows))
+	for _, r := range rows {
+		messages = append(messages, storage.OutboxMessage{ID: r.ID, Payload: r.Payload, CreatedAt: r.CreatedAt})
+	}
+	return messages, nil
+}
+
+// RunExclusive выполняет fn под advisory-блокировкой Postgres с ключом hashtext(name).
+// Блокировка уровня сессии, поэтому держится на выделенном соединении до завершения fn.
+func (s *Storage) RunExclusive(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	conn, err := s.db.Connx(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var acquired bool
+	if err := conn.GetContext(ctx, &acquired, `SELECT pg_try_advisory_lock(hashtext($1))`, name); err != nil {
+		return false, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+	if !acquired {
+		return false, nil
+	}
+	defer func() {
+		_, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock(hashtext($1))`, name)
+		if err != nil {
+			// Соединение с неснятой блокировкой нельзя возвращать в пул: закрываем его.
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+	return true, fn(ctx)
+}
@@ -0,0 +1,146 @@
+package sqlstorage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// eventColumns - список колонок таблицы events в порядке, ожидаемом eventRow.
+const eventColumns = `id, uid, title, start_time, end_time, COALESCE(description, '') AS description, user_id,
+	time_zone, all_day, transparency, rrule, exdates, version, deleted_at`
+
+// eventRow - представление строки таблицы events.
+type eventRow struct {
+	ID           string       `db:"id"`
+	UID          string       `db:"uid"`
+	Title        string       `db:"title"`
+	StartTime    time.Time    `db:"start_time"`
+	EndTime      time.Time    `db:"end_time"`
+	Description  string       `db:"description"`
+	UserID       string       `db:"user_id"`
+	TimeZone     string       `db:"time_zone"`
+	AllDay       bool         `db:"all_day"`
+	Transparency string       `db:"transparency"`
+	RRule        string       `db:"rrule"`
+	ExDates      string       `db:"exdates"`
+	Version      int64        `db:"version"`
+	DeletedAt    sql.NullTime `db:"deleted_at"`
+}
+
+func toRow(e storage.Event) eventRow {
+	row := eventRow{
+		ID:           e.ID,
+		UID:          e.UID,
+		Title:        e.Title,
+		StartTime:    e.StartTime,
+		EndTime:      e.EndTime,
+		Description:  e.Description,
+		UserID:       e.UserID,
+		TimeZone:     e.TimeZone,
+		AllDay:       e.AllDay,
+		Transparency: string(e.Transparency),
+		ExDates:      storage.FormatExDates(e.ExDates),
+		Version:      e.Version,
+	}
+	if e.RRule != nil {
+		row.RRule = e.RRule.String()
+	}
+	return row
+}
+
+func (r eventRow) toEvent() (storage.Event, error) {
+	e := storage.Event{
+		ID:           r.ID,
+		UID:          r.UID,
+		Title:        r.Title,
+		StartTime:    r.StartTime,
+		EndTime:      r.EndTime,
+		Description:  r.Description,
+		UserID:       r.UserID,
+		TimeZone:     r.TimeZone,
+		AllDay:       r.AllDay,
+		Transparency: storage.Transparency(r.Transparency),
+		Version:      r.Version,
+	}
+	if r.DeletedAt.Valid {
+		e.DeletedAt = &r.DeletedAt.Time
+	}
+	if r.RRule != "" {
+		rule, err := storage.ParseRRule(r.RRule)
+		if err != nil {
+			return storage.Event{}, fmt.Errorf("event %s: %w", r.ID, err)
+		}
+		e.RRule = rule
+	}
+	exDates, err := storage.ParseExDates(r.ExDates)
+	if err != nil {
+		return storage.Event{}, fmt.Errorf("event %s: %w", r.ID, err)
+	}
+	e.ExDates = exDates
+	return e, nil
+}
+
+func toEvents(rows []eventRow) ([]storage.Event, error) {
+	events := make([]storage.Event, 0, len(rows))
+	for _, r := range rows {
+		e, err := r.toEvent()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// reminderColumns - список колонок таблицы reminders в порядке, ожидаемом reminderRow.
+const reminderColumns = `id, event_id, offset_seconds, channel, sent_at, sent_for`
+
+// reminderRow - представление строки таблицы reminders. Смещение хранится с точностью до секунды.
+type reminderRow struct {
+	ID            string       `db:"id"`
+	EventID       string       `db:"event_id"`
+	OffsetSeconds int64        `db:"offset_seconds"`
+	Channel       string       `db:"channel"`
+	SentAt        sql.NullTime `db:"sent_at"`
+	SentFor       sql.NullTime `db:"sent_for"`
+}
+
+func toReminderRows(e storage.Event) []reminderRow {
+	rows := make([]reminderRow, 0, len(e.Reminders))
+	for _, r := range e.Reminders {
+		row := reminderRow{
+			ID:            r.ID,
+			EventID:       e.ID,
+			OffsetSeconds: int64(r.Offset / time.Second),
+			Channel:       r.Channel,
+		}
+		if r.SentAt != nil {
+			row.SentAt = sql.NullTime{Time: *r.SentAt, Valid: true}
+		}
+		if r.SentFor != nil {
+			row.SentFor = sql.NullTime{Time: *r.SentFor, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (r reminderRow) toReminder() storage.Reminder {
+	reminder := storage.Reminder{
+		ID:      r.ID,
+		Offset:  time.Duration(r.OffsetSeconds) * time.Second,
+		Channel: r.Channel,
+	}
+	if r.SentAt.Valid {
+		sentAt := r.SentAt.Time
+		reminder.SentAt = &sentAt
+	}
+	if r.SentFor.Valid {
+		sentFor := r.SentFor.Time
+		reminder.SentFor = &sentFor
+	}
+	return reminder
+}
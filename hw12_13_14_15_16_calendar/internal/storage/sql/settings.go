@@ -0,0 +1,36 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+func (s *Storage) GetUserSettings(ctx context.Context, userID string) (storage.UserSettings, error) {
+	settings := storage.UserSettings{UserID: userID}
+	err := s.querier().QueryRowxContext(ctx,
+		"SELECT time_zone, locale, week_start FROM user_settings WHERE user_id = $1", userID,
+	).Scan(&settings.TimeZone, &settings.Locale, &settings.WeekStart)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return storage.UserSettings{}, fmt.Errorf("failed to get user settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (s *Storage) SaveUserSettings(ctx context.Context, settings storage.UserSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	_, err := s.querier().ExecContext(ctx, `
+		INSERT INTO user_settings (user_id, time_zone, locale, week_start) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET time_zone = EXCLUDED.time_zone, locale = EXCLUDED.locale, week_start = EXCLUDED.week_start
+	`, settings.UserID, settings.TimeZone, settings.Locale, settings.WeekStart)
+	if err != nil {
+		return fmt.Errorf("failed to save user settings: %w", err)
+	}
+	return nil
+}
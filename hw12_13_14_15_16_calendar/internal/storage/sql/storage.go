@@ -5,16 +5,20 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // register postgres driver for database/sql
+	"github.com/lib/pq" // register postgres driver for database/sql
 	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
 )
 
 type Storage struct {
 	dsn string
 	db  *sqlx.DB
+	// tx - транзакция Atomic, к которой привязано хранилище; nil вне Atomic.
+	tx *sqlx.Tx
 }
 
 func New(dsn string) *Storage {
@@ -40,88 +44,253 @@ func (s *Storage) Close(_ context.Context) error {
 	return nil
 }
 
-func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) error {
-	if event.ID == "" || event.Title == "" {
-		return storage.ErrInvalidEvent
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (storage.Event, error) {
+	if event.Title == "" || event.UserID == "" {
+		return storage.Event{}, storage.ErrInvalidEvent
 	}
-
-	busy, err := s.isTimeBusy(ctx, "", event.UserID, event.StartTime, event.EndTime)
+	if event.RRule != nil && event.RRule.Validate() != nil || !event.Transparency.Valid() {
+		return storage.Event{}, storage.ErrInvalidEvent
+	}
+	if _, err := storage.LoadLocation(event.TimeZone); err != nil {
+		return storage.Event{}, fmt.Errorf("%w: %w", storage.ErrInvalidEvent, err)
+	}
+	event = storage.NormalizeAllDay(event)
+	event.Transparency = event.Transparency.OrBusy()
+	if event.ID == "" {
+		event.ID = storage.NewID()
+	}
+	if event.UID == "" {
+		event.UID = event.ID
+	}
+	reminders, err := storage.MergeReminders(nil, event)
 	if err != nil {
-		return fmt.Errorf("failed to check if time is busy: %w", err)
+		return storage.Event{}, err
+	}
+	event.Reminders = reminders
+	if event.Attendees, err = storage.MergeAttendees(nil, event); err != nil {
+		return storage.Event{}, err
 	}
-	if busy {
-		return storage.ErrDateBusy
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return storage.Event{}, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer func() { _ = tx.Rollback() }()
 
 	query := `
-		INSERT INTO events (id, title, start_time, end_time, description, user_id, notify_at, notified)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO events (
+			id, uid, title, start_time, end_time, description, user_id, time_zone, all_day, transparency,
+			rrule, exdates
+		) VALUES (
+			:id, :uid, :title, :start_time, :end_time, :description, :user_id, :time_zone, :all_day, :transparency,
+			:rrule, :exdates
+		)
 	`
 
-	_, err = s.db.ExecContext(ctx, query,
-		event.ID,
-		event.Title,
-		event.StartTime,
-		event.EndTime,
-		event.Description,
-		event.UserID,
-		event.NotifyAt,
-		event.Notified,
-	)
+	_, err = tx.NamedExecContext(ctx, query, toRow(event))
+	if isUniqueViolation(err) {
+		return storage.Event{}, storage.ErrAlreadyExists
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create event: %w", err)
+		return storage.Event{}, fmt.Errorf("failed to create event: %w", err)
+	}
+	if err := insertReminders(ctx, tx.Tx, event); err != nil {
+		return storage.Event{}, err
+	}
+	if err := insertAttendees(ctx, tx.Tx, event); err != nil {
+		return storage.Event{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return storage.Event{}, fmt.Errorf("failed to create event: %w", err)
 	}
 
+	event.Version = 1
+	return event, nil
+}
+
+// uniqueViolation - код ошибки PostgreSQL при нарушении уникальности.
+const uniqueViolation = "23505"
+
+// isUniqueViolation сообщает, нарушает ли запрос ограничение уникальности.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
+// insertReminders сохраняет напоминания события.
+func insertReminders(ctx context.Context, tx *sqlx.Tx, event storage.Event) error {
+	rows := toReminderRows(event)
+	if len(rows) == 0 {
+		return nil
+	}
+	_, err := tx.NamedExecContext(ctx, `
+		INSERT INTO reminders (`+reminderColumns+`)
+		VALUES (:id, :event_id, :offset_seconds, :channel, :sent_at, :sent_for)
+	`, rows)
+	if err != nil {
+		return fmt.Errorf("failed to save reminders: %w", err)
+	}
 	return nil
 }
 
-func (s *Storage) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
+// replaceReminders заменяет напоминания события current напоминаниями event (см. MergeReminders).
+// Сохранившиеся напоминания обновляются на месте, поэтому их захват планировщиком не теряется.
+func replaceReminders(ctx context.Context, tx *sqlx.Tx, current, event storage.Event) error {
+	kept := make([]string, 0, len(event.Reminders))
+	existing := make(map[string]bool, len(current.Reminders))
+	for _, r := range current.Reminders {
+		existing[r.ID] = true
+	}
+	added := storage.Event{ID: event.ID}
+	for i, row := range toReminderRows(event) {
+		if !existing[row.ID] {
+			added.Reminders = append(added.Reminders, event.Reminders[i])
+			continue
+		}
+		kept = append(kept, row.ID)
+		_, err := tx.NamedExecContext(ctx, `
+			UPDATE reminders
+			SET offset_seconds = :offset_seconds, channel = :channel, sent_at = :sent_at, sent_for = :sent_for
+			WHERE id = :id AND event_id = :event_id
+		`, row)
+		if err != nil {
+			return fmt.Errorf("failed to update reminders: %w", err)
+		}
+	}
+	_, err := tx.ExecContext(ctx, "DELETE FROM reminders WHERE event_id = $1 AND NOT (id = ANY($2))",
+		event.ID, pq.Array(kept))
+	if err != nil {
+		return fmt.Errorf("failed to update reminders: %w", err)
+	}
+	return insertReminders(ctx, tx, added)
+}
+
+// attachDetails загружает напоминания и участников событий.
+func (s *Storage) attachDetails(ctx context.Context, events []storage.Event) error {
+	if err := s.attachReminders(ctx, events); err != nil {
+		return err
+	}
+	return s.attachAttendees(ctx, events)
+}
+
+// attachReminders загружает напоминания событий одним запросом.
+func (s *Storage) attachReminders(ctx context.Context, events []storage.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+
+	var rows []reminderRow
+	err := s.querier().SelectContext(ctx, &rows, `
+		SELECT `+reminderColumns+` FROM reminders
+		WHERE event_id = ANY($1)
+		ORDER BY offset_seconds DESC, id
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to load reminders: %w", err)
+	}
+
+	byEvent := make(map[string][]storage.Reminder)
+	for _, r := range rows {
+		byEvent[r.EventID] = append(byEvent[r.EventID], r.toReminder())
+	}
+	for i := range events {
+		events[i].Reminders = byEvent[events[i].ID]
+	}
+	return nil
+}
+
+func (s *Storage) UpdateEvent(ctx context.Context, userID, id string, event storage.Event) error {
 	if event.Title == "" {
 		return storage.ErrInvalidEvent
 	}
+	if event.RRule != nil && event.RRule.Validate() != nil || !event.Transparency.Valid() {
+		return storage.ErrInvalidEvent
+	}
+	if _, err := storage.LoadLocation(event.TimeZone); err != nil {
+		return fmt.Errorf("%w: %w", storage.ErrInvalidEvent, err)
+	}
+	event = storage.NormalizeAllDay(event)
+	event.Transparency = event.Transparency.OrBusy()
 
-	var exists bool
-	err := s.db.GetContext(ctx, &exists, "SELECT EXISTS(SELECT 1 FROM events WHERE id = $1)", id)
+	tx, err := s.begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to check event existence: %w", err)
-	}
-	if !exists {
-		return storage.ErrEventNotFound
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	busy, err := s.isTimeBusy(ctx, id, event.UserID, event.StartTime, event.EndTime)
+	// Напоминания блокируются до чтения события: параллельная отметка об отправке
+	// (MarkEventNotified) или захват не будут потеряны при их слиянии.
+	if _, err := tx.ExecContext(ctx, "SELECT id FROM reminders WHERE event_id = $1 FOR UPDATE", id); err != nil {
+		return fmt.Errorf("failed to lock reminders: %w", err)
+	}
+	current, err := (&Storage{dsn: s.dsn, db: s.db, tx: tx.Tx}).GetEventByID(ctx, userID, id)
 	if err != nil {
-		return fmt.Errorf("failed to check if time is busy: %w", err)
+		return err
+	}
+	if current.UserID != userID {
+		return storage.ErrForbidden
+	}
+
+	event.ID = id
+	event.UserID = userID
+	event.UID = current.UID
+	if event.Reminders, err = storage.MergeReminders(current, event); err != nil {
+		return err
 	}
-	if busy {
-		return storage.ErrDateBusy
+	if event.Attendees, err = storage.MergeAttendees(current, event); err != nil {
+		return err
 	}
 
 	query := `
 		UPDATE events
-		SET title = $2, start_time = $3, end_time = $4, description = $5, user_id = $6, notify_at = $7, notified = $8
-		WHERE id = $1
+		SET title = :title, start_time = :start_time, end_time = :end_time, description = :description,
+			user_id = :user_id, time_zone = :time_zone, all_day = :all_day,
+			transparency = :transparency, rrule = :rrule, exdates = :exdates,
+			version = version + 1
+		WHERE id = :id AND user_id = :user_id AND deleted_at IS NULL
+		AND (CAST(:version AS BIGINT) = 0 OR version = :version)
 	`
 
-	_, err = s.db.ExecContext(ctx, query,
-		id,
-		event.Title,
-		event.StartTime,
-		event.EndTime,
-		event.Description,
-		event.UserID,
-		event.NotifyAt,
-		event.Notified,
-	)
+	result, err := tx.NamedExecContext(ctx, query, toRow(event))
 	if err != nil {
 		return fmt.Errorf("failed to update event: %w", err)
 	}
 
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	// Владелец уже проверен, значит строку успели изменить или удалить.
+	if rows == 0 {
+		return storage.ErrVersionConflict
+	}
+
+	if err := replaceReminders(ctx, tx.Tx, *current, event); err != nil {
+		return err
+	}
+	if err := replaceAttendees(ctx, tx.Tx, event); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update event: %w", err)
+	}
 	return nil
 }
 
-func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
-	result, err := s.db.ExecContext(ctx, "DELETE FROM events WHERE id = $1", id)
+func (s *Storage) DeleteEvent(ctx context.Context, userID, id string, version int64) error {
+	if err := s.checkOwner(ctx, userID, id); err != nil {
+		return err
+	}
+
+	result, err := s.querier().ExecContext(ctx, `
+		UPDATE events SET deleted_at = NOW(), version = version + 1
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL AND ($3::BIGINT = 0 OR version = $3)
+	`, id, userID, version)
 	if err != nil {
 		return fmt.Errorf("failed to delete event: %w", err)
 	}
@@ -132,22 +301,66 @@ func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
 	}
 
 	if rows == 0 {
-		return storage.ErrEventNotFound
+		return storage.ErrVersionConflict
 	}
 
 	return nil
 }
 
-func (s *Storage) GetEventByID(ctx context.Context, id string) (*storage.Event, error) {
-	var event storage.Event
+func (s *Storage) ListTrash(ctx context.Context, userID string) ([]storage.Event, error) {
+	var rows []eventRow
 
 	query := `
-		SELECT id, title, start_time, end_time, description, user_id, notify_at, notified
-		FROM events
-		WHERE id = $1
+		SELECT ` + eventColumns + ` FROM events
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id
 	`
+	if err := s.querier().SelectContext(ctx, &rows, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+	events, err := toEvents(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+	if err := s.attachDetails(ctx, events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
 
-	err := s.db.GetContext(ctx, &event, query, id)
+func (s *Storage) RestoreEvent(ctx context.Context, userID, id string) error {
+	result, err := s.querier().ExecContext(ctx, `
+		UPDATE events SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+	`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to restore event: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows > 0 {
+		return nil
+	}
+
+	var owner string
+	err = s.querier().GetContext(ctx, &owner, "SELECT user_id FROM events WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrEventNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore event: %w", err)
+	}
+	return storage.ErrForbidden
+}
+
+func (s *Storage) GetEventByID(ctx context.Context, userID, id string) (*storage.Event, error) {
+	var row eventRow
+
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id = $1 AND deleted_at IS NULL`
+
+	err := s.querier().GetContext(ctx, &row, query, id)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, storage.ErrEventNotFound
 	}
@@ -155,107 +368,440 @@ func (s *Storage) GetEventByID(ctx context.Context, id string) (*storage.Event,
 		return nil, fmt.Errorf("failed to get event: %w", err)
 	}
 
-	return &event, nil
+	event, err := row.toEvent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	events := []storage.Event{event}
+	if err := s.attachDetails(ctx, events); err != nil {
+		return nil, err
+	}
+	if !events[0].VisibleTo(userID) {
+		return nil, storage.ErrForbidden
+	}
+
+	return &events[0], nil
+}
+
+func (s *Storage) SharesEvent(ctx context.Context, userID, otherID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM events e
+			WHERE e.deleted_at IS NULL
+			AND (e.user_id = $1 OR EXISTS (
+				SELECT 1 FROM event_attendees a WHERE a.event_id = e.id AND a.user_id = $1))
+			AND (e.user_id = $2 OR EXISTS (
+				SELECT 1 FROM event_attendees a WHERE a.event_id = e.id AND a.user_id = $2))
+		)
+	`
+
+	var shares bool
+	if err := s.querier().GetContext(ctx, &shares, query, userID, otherID); err != nil {
+		return false, fmt.Errorf("failed to check shared events: %w", err)
+	}
+	return shares, nil
+}
+
+// checkOwner проверяет, что событие id существует и принадлежит пользователю userID.
+func (s *Storage) checkOwner(ctx context.Context, userID, id string) error {
+	var owner string
+	err := s.querier().GetContext(ctx, &owner, "SELECT user_id FROM events WHERE id = $1 AND deleted_at IS NULL", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrEventNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check event owner: %w", err)
+	}
+	if owner != userID {
+		return storage.ErrForbidden
+	}
+	return nil
+}
+
+func (s *Storage) ListEventsForDay(ctx context.Context, userID string, date time.Time) ([]storage.Event, error) {
+	startOfDay, endOfDay := storage.DayBounds(date)
+
+	return s.listEventsBetween(ctx, userID, startOfDay, endOfDay)
 }
 
-func (s *Storage) ListEventsForDay(ctx context.Context, date time.Time) ([]storage.Event, error) {
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
+func (s *Storage) ListEventsForWeek(ctx context.Context, userID string, startDate time.Time) ([]storage.Event, error) {
+	startOfWeek, endOfWeek := storage.WeekBounds(startDate)
 
-	return s.listEventsBetween(ctx, startOfDay, endOfDay)
+	return s.listEventsBetween(ctx, userID, startOfWeek, endOfWeek)
 }
 
-func (s *Storage) ListEventsForWeek(ctx context.Context, startDate time.Time) ([]storage.Event, error) {
-	startOfWeek := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, startDate.Location())
-	endOfWeek := startOfWeek.Add(7 * 24 * time.Hour)
+func (s *Storage) ListEventsForMonth(ctx context.Context, userID string, startDate time.Time) ([]storage.Event, error) {
+	startOfMonth, endOfMonth := storage.MonthBounds(startDate)
 
-	return s.listEventsBetween(ctx, startOfWeek, endOfWeek)
+	return s.listEventsBetween(ctx, userID, startOfMonth, endOfMonth)
 }
 
-func (s *Storage) ListEventsForMonth(ctx context.Context, startDate time.Time) ([]storage.Event, error) {
-	startOfMonth := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, startDate.Location())
-	endOfMonth := startOfMonth.AddDate(0, 1, 0)
+func (s *Storage) ListEventsBetween(ctx context.Context, userID string, start, end time.Time) ([]storage.Event, error) {
+	return s.listEventsBetween(ctx, userID, start, end)
+}
 
-	return s.listEventsBetween(ctx, startOfMonth, endOfMonth)
+// listedForUser - условие на события пользователя $1: его собственные и принятые им приглашения
+// (см. storage.Event.ListedFor).
+const listedForUser = `(user_id = $1 OR id IN (
+	SELECT event_id FROM event_attendees WHERE user_id = $1 AND status = 'accepted'
+))`
+
+// ListEvents выбирает одиночные события keyset-пагинацией по (start_time, id), а серии -
+// целиком, после чего объединяет их в одну страницу. События, начавшиеся до From,
+// попадают в выдачу, если еще не закончились; порядок (start_time, id) от этого не меняется.
+func (s *Storage) ListEvents(ctx context.Context, filter storage.EventFilter) (storage.EventPage, error) {
+	cursor, err := storage.ParseCursor(filter.Cursor)
+	if err != nil {
+		return storage.EventPage{}, err
+	}
+
+	args := []interface{}{filter.UserID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var common []string
+	if !filter.To.IsZero() {
+		common = append(common, "start_time < "+arg(filter.To))
+	}
+	if filter.Query != "" {
+		common = append(common, "title ILIKE "+arg("%"+escapeLike(filter.Query)+"%"))
+	}
+	single := append([]string{"rrule = ''"}, common...)
+	if !filter.From.IsZero() {
+		// Как в listEventsBetween: событие пересекается с окном или начинается на его границе.
+		from := arg(filter.From)
+		single = append(single, "(end_time > "+from+" OR start_time >= "+from+")")
+	}
+	if !cursor.IsZero() {
+		single = append(single, fmt.Sprintf("(start_time, id) > (%s, %s)", arg(cursor.StartTime), arg(cursor.ID)))
+	}
+	series := append([]string{"rrule <> ''"}, common...)
+
+	query := `
+		(SELECT ` + eventColumns + ` FROM events
+		WHERE ` + listedForUser + ` AND deleted_at IS NULL AND ` + strings.Join(single, " AND ") + `
+		ORDER BY start_time, id
+		LIMIT ` + arg(filter.PageLimit()+1) + `)
+		UNION ALL
+		(SELECT ` + eventColumns + ` FROM events
+		WHERE ` + listedForUser + ` AND deleted_at IS NULL AND ` + strings.Join(series, " AND ") + `)
+	`
+
+	var rows []eventRow
+	if err := s.querier().SelectContext(ctx, &rows, query, args...); err != nil {
+		return storage.EventPage{}, fmt.Errorf("failed to list events: %w", err)
+	}
+	events, err := toEvents(rows)
+	if err != nil {
+		return storage.EventPage{}, fmt.Errorf("failed to list events: %w", err)
+	}
+	if err := s.attachDetails(ctx, events); err != nil {
+		return storage.EventPage{}, err
+	}
+
+	return storage.BuildPage(events, filter)
+}
+
+// searchRow - строка результата поиска с релевантностью.
+type searchRow struct {
+	eventRow
+	Rank float64 `db:"rank"`
+}
+
+// SearchEvents ищет по колонке search_vector (GIN-индекс) и ранжирует результаты через ts_rank.
+func (s *Storage) SearchEvents(ctx context.Context, query storage.SearchQuery) (storage.SearchPage, error) {
+	terms, err := query.Terms()
+	if err != nil {
+		return storage.SearchPage{}, err
+	}
+	offset, err := query.Offset()
+	if err != nil {
+		return storage.SearchPage{}, err
+	}
+	limit := query.PageLimit()
+
+	var rows []searchRow
+	err = s.querier().SelectContext(ctx, &rows, `
+		SELECT `+eventColumns+`, ts_rank(search_vector, q) AS rank
+		FROM events, plainto_tsquery('simple', $2) AS q
+		WHERE `+listedForUser+` AND deleted_at IS NULL AND search_vector @@ q
+		ORDER BY rank DESC, start_time, id
+		LIMIT $3 OFFSET $4
+	`, query.UserID, strings.Join(terms, " "), limit+1, offset)
+	if err != nil {
+		return storage.SearchPage{}, fmt.Errorf("failed to search events: %w", err)
+	}
+
+	page := storage.SearchPage{Results: make([]storage.SearchResult, 0, len(rows))}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		page.NextCursor = storage.SearchCursor(offset + limit)
+	}
+	events := make([]storage.Event, 0, len(rows))
+	for _, r := range rows {
+		e, err := r.toEvent()
+		if err != nil {
+			return storage.SearchPage{}, fmt.Errorf("failed to search events: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := s.attachDetails(ctx, events); err != nil {
+		return storage.SearchPage{}, err
+	}
+	for i, e := range events {
+		page.Results = append(page.Results, storage.SearchResult{Event: e, Rank: rows[i].Rank})
+	}
+	return page, nil
 }
 
-func (s *Storage) listEventsBetween(ctx context.Context, start, end time.Time) ([]storage.Event, error) {
-	var events []storage.Event
+// escapeLike экранирует спецсимволы шаблона LIKE.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
 
+func (s *Storage) listEventsBetween(ctx context.Context, userID string, start, end time.Time) ([]storage.Event, error) {
+	var rows []eventRow
+
+	// Повторяющиеся события выбираются целиком и разворачиваются во вхождения на стороне приложения.
+	// Одиночное событие попадает в окно, если пересекается с ним (см. storage.Event.OccurrencesOverlapping).
 	query := `
-		SELECT id, title, start_time, end_time, description, user_id, notify_at, notified
+		SELECT ` + eventColumns + `
 		FROM events
-		WHERE start_time >= $1 AND start_time < $2
+		WHERE (user_id = $3 OR id IN (
+			SELECT event_id FROM event_attendees WHERE user_id = $3 AND status = 'accepted'
+		))
+		AND deleted_at IS NULL
+		AND start_time < $2
+		AND (rrule <> '' OR end_time > $1 OR start_time >= $1)
 		ORDER BY start_time
 	`
 
-	err := s.db.SelectContext(ctx, &events, query, start, end)
+	err := s.querier().SelectContext(ctx, &rows, query, start, end, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list events: %w", err)
 	}
 
-	if events == nil {
-		events = []storage.Event{}
+	series, err := toEvents(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
 	}
+	if err := s.attachDetails(ctx, series); err != nil {
+		return nil, err
+	}
+
+	events := []storage.Event{}
+	for _, e := range series {
+		events = append(events, e.OccurrencesOverlapping(start, end)...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].StartTime.Before(events[j].StartTime)
+	})
 
 	return events, nil
 }
 
-func (s *Storage) isTimeBusy(ctx context.Context, excludeID, userID string, start, end time.Time) (bool, error) {
+func (s *Storage) FindConflicts(ctx context.Context, candidate storage.Event) ([]string, error) {
+	if candidate.RRule != nil && candidate.RRule.Validate() != nil || !candidate.Transparency.Valid() {
+		return nil, storage.ErrInvalidEvent
+	}
+	candidate = storage.NormalizeAllDay(candidate)
+	if !candidate.OccupiesTime() {
+		return nil, nil
+	}
+	spanEnd := candidate.SeriesEnd(candidate.StartTime.Add(storage.ConflictHorizon))
+
 	query := `
-		SELECT EXISTS(
-			SELECT 1 FROM events
-			WHERE user_id = $1
-			AND id != $2
-			AND start_time < $4
-			AND end_time > $3
-		)
+		SELECT ` + eventColumns + `
+		FROM events
+		WHERE user_id = $1
+		AND id != $2
+		AND deleted_at IS NULL
+		AND start_time < $4
+		AND (end_time > $3 OR rrule <> '')
+		AND transparency = 'busy'
+		ORDER BY id
 	`
 
-	var busy bool
-	err := s.db.GetContext(ctx, &busy, query, userID, excludeID, start, end)
+	var rows []eventRow
+	err := s.querier().SelectContext(ctx, &rows, query, candidate.UserID, candidate.ID, candidate.StartTime, spanEnd)
 	if err != nil {
-		return false, err
+		return nil, fmt.Errorf("failed to find conflicting events: %w", err)
 	}
 
-	return busy, nil
+	events, err := toEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	for _, e := range events {
+		if storage.Overlaps(candidate, e) {
+			conflicts = append(conflicts, e.ID)
+		}
+	}
+	return conflicts, nil
 }
 
-func (s *Storage) GetEventsToNotify(ctx context.Context) ([]storage.Event, error) {
-	var events []storage.Event
+func (s *Storage) GetEventsToNotify(ctx context.Context) ([]storage.DueReminder, error) {
+	var rows []eventRow
 
+	// Для серии отбираются события с наступившим первым напоминанием, вхождения считает приложение.
 	query := `
-		SELECT id, title, start_time, end_time, description, user_id, notify_at, notified
+		SELECT ` + eventColumns + `
 		FROM events
-		WHERE notified = FALSE AND notify_at <= NOW()
+		WHERE deleted_at IS NULL AND EXISTS (
+			SELECT 1 FROM reminders r
+			WHERE r.event_id = events.id
+			AND events.start_time - r.offset_seconds * INTERVAL '1 second' <= NOW()
+			AND (events.rrule <> '' OR r.sent_for IS NULL)
+		)
 	`
 
-	err := s.db.SelectContext(ctx, &events, query)
+	err := s.querier().SelectContext(ctx, &rows, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get events to notify: %w", err)
 	}
 
-	if events == nil {
-		events = []storage.Event{}
+	candidates, err := toEvents(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events to notify: %w", err)
+	}
+	if err := s.attachDetails(ctx, candidates); err != nil {
+		return nil, err
 	}
 
-	return events, nil
+	now := time.Now()
+	due := []storage.DueReminder{}
+	for _, e := range candidates {
+		due = append(due, e.DueReminders(now)...)
+	}
+
+	return due, nil
 }
 
-func (s *Storage) MarkEventNotified(ctx context.Context, id string) error {
-	query := `UPDATE events SET notified = TRUE WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, id)
+func (s *Storage) MarkEventNotified(
+	ctx context.Context, id, reminderID string, occurrence time.Time, msgs ...storage.OutboxMessage,
+) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Условие на sent_for делает отметку захватом: из параллельных сканирований
+	// сообщение в outbox поставит только одно.
+	result, err := tx.ExecContext(ctx, `
+		UPDATE reminders SET sent_at = NOW(), sent_for = $3, claimed_by = NULL, claimed_until = NULL
+		WHERE id = $2 AND event_id = $1 AND (sent_for IS NULL OR sent_for < $3)
+	`, id, reminderID, occurrence)
 	if err != nil {
 		return fmt.Errorf("failed to mark event as notified: %w", err)
 	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		var exists bool
+		err := tx.GetContext(ctx, &exists,
+			"SELECT EXISTS (SELECT 1 FROM reminders WHERE id = $2 AND event_id = $1)", id, reminderID)
+		if err != nil {
+			return fmt.Errorf("failed to mark event as notified: %w", err)
+		}
+		if !exists {
+			return storage.ErrEventNotFound
+		}
+		return nil
+	}
+
+	for _, msg := range msgs {
+		// payload передается строкой: []byte драйвер отправил бы как bytea.
+		_, err = tx.ExecContext(ctx, "INSERT INTO outbox (id, payload) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING",
+			msg.ID, string(msg.Payload))
+		if err != nil {
+			return fmt.Errorf("failed to enqueue notification: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to mark event as notified: %w", err)
+	}
+	return nil
+}
+
+// outboxRow - представление строки таблицы outbox.
+type outboxRow struct {
+	ID        string    `db:"id"`
+	Payload   []byte    `db:"payload"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (s *Storage) PendingOutbox(ctx context.Context, limit int) ([]storage.OutboxMessage, error) {
+	var rows []outboxRow
+	err := s.querier().SelectContext(ctx, &rows,
+		"SELECT id, payload, created_at FROM outbox ORDER BY created_at, id LIMIT $1", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox messages: %w", err)
+	}
+	messages := make([]storage.OutboxMessage, 0, len(rows))
+	for _, r := range rows {
+		messages = append(messages, storage.OutboxMessage{ID: r.ID, Payload: r.Payload, CreatedAt: r.CreatedAt})
+	}
+	return messages, nil
+}
+
+func (s *Storage) DeleteOutbox(ctx context.Context, id string) error {
+	if _, err := s.querier().ExecContext(ctx, "DELETE FROM outbox WHERE id = $1", id); err != nil {
+		return fmt.Errorf("failed to delete outbox message: %w", err)
+	}
 	return nil
 }
 
 func (s *Storage) DeleteOldEvents(ctx context.Context, olderThan time.Time) error {
-	query := `DELETE FROM events WHERE start_time < $1`
-	_, err := s.db.ExecContext(ctx, query, olderThan)
+	query := `
+		UPDATE events SET deleted_at = NOW(), version = version + 1
+		WHERE start_time < $1 AND rrule = '' AND deleted_at IS NULL
+	`
+	_, err := s.querier().ExecContext(ctx, query, olderThan)
 	if err != nil {
 		return fmt.Errorf("failed to delete old events: %w", err)
 	}
+
+	// Серия удаляется только после окончания последнего вхождения.
+	var rows []eventRow
+	query = `SELECT ` + eventColumns + ` FROM events WHERE start_time < $1 AND rrule <> '' AND deleted_at IS NULL`
+	if err := s.querier().SelectContext(ctx, &rows, query, olderThan); err != nil {
+		return fmt.Errorf("failed to select old recurring events: %w", err)
+	}
+	series, err := toEvents(rows)
+	if err != nil {
+		return fmt.Errorf("failed to select old recurring events: %w", err)
+	}
+
+	var ids []string
+	for _, e := range series {
+		if e.SeriesEnd(olderThan).Before(olderThan) {
+			ids = append(ids, e.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err = s.querier().ExecContext(ctx,
+		`UPDATE events SET deleted_at = NOW(), version = version + 1 WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to delete old recurring events: %w", err)
+	}
+	return nil
+}
+
+// PurgeTrash удаляет строки событий; напоминания и участники удаляются каскадно.
+func (s *Storage) PurgeTrash(ctx context.Context, deletedBefore time.Time) error {
+	_, err := s.querier().ExecContext(ctx, `DELETE FROM events WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return fmt.Errorf("failed to purge trash: %w", err)
+	}
 	return nil
 }
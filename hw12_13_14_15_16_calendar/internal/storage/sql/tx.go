@@ -0,0 +1,91 @@
+package sqlstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stas-ik/otus-go-test/hw12_13_14_15_16_calendar/internal/storage"
+)
+
+// querier - общие методы *sqlx.DB и *sqlx.Tx, через которые выполняются запросы.
+type querier interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// querier возвращает транзакцию Atomic, если хранилище к ней привязано, иначе пул соединений.
+func (s *Storage) querier() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// txn - транзакция метода хранилища. Внутри Atomic это транзакция Atomic:
+// фиксирует или откатывает ее сам Atomic, поэтому Commit и Rollback ничего не делают.
+type txn struct {
+	*sqlx.Tx
+	nested bool
+}
+
+func (t txn) Commit() error {
+	if t.nested {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t txn) Rollback() error {
+	if t.nested {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
+
+// begin начинает транзакцию метода хранилища.
+func (s *Storage) begin(ctx context.Context) (txn, error) {
+	if s.tx != nil {
+		return txn{Tx: s.tx, nested: true}, nil
+	}
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return txn{}, err
+	}
+	return txn{Tx: tx}, nil
+}
+
+// LockEvents берет advisory-блокировку уровня транзакции с ключом hashtext от владельца;
+// ее снимает завершение транзакции Atomic. Вне Atomic блокировка снялась бы сразу.
+func (s *Storage) LockEvents(ctx context.Context, userID string) error {
+	if s.tx == nil {
+		return errors.New("LockEvents must be called inside Atomic")
+	}
+	if _, err := s.tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "events:"+userID); err != nil {
+		return fmt.Errorf("failed to lock events: %w", err)
+	}
+	return nil
+}
+
+// Atomic выполняет fn в одной транзакции: изменения, сделанные через tx, фиксируются,
+// только если fn не вернула ошибку. Вложенный вызов выполняется в транзакции внешнего.
+func (s *Storage) Atomic(ctx context.Context, fn func(tx storage.Storage) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(&Storage{dsn: s.dsn, db: s.db, tx: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
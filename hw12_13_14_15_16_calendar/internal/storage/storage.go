@@ -5,21 +5,102 @@ import (
 	"time"
 )
 
+// Storage - хранилище событий. Пользовательские методы работают только с событиями
+// пользователя userID; методы планировщика (уведомления, очистка) - со всеми событиями.
 type Storage interface {
-	CreateEvent(ctx context.Context, event Event) error
+	// CreateEvent сохраняет событие и возвращает его в сохраненном виде.
+	// Если ID не задан, хранилище генерирует его через NewID. Если событие с таким ID
+	// или событие владельца с таким UID уже есть, возвращает ErrAlreadyExists.
+	CreateEvent(ctx context.Context, event Event) (Event, error)
 
-	UpdateEvent(ctx context.Context, id string, event Event) error
+	// UpdateEvent и DeleteEvent возвращают ErrVersionConflict, если текущая версия события
+	// отличается от ожидаемой (event.Version или version); нулевая версия отключает проверку.
+	UpdateEvent(ctx context.Context, userID, id string, event Event) error
 
-	DeleteEvent(ctx context.Context, id string) error
+	// DeleteEvent переносит событие в корзину: оно пропадает из списков, поиска и проверок
+	// занятости, перестает напоминать о себе, но может быть восстановлено через RestoreEvent.
+	// Перенос в корзину и восстановление увеличивают версию события.
+	DeleteEvent(ctx context.Context, userID, id string, version int64) error
+	// ListTrash возвращает события пользователя из корзины, недавно удаленные - первыми.
+	ListTrash(ctx context.Context, userID string) ([]Event, error)
+	// RestoreEvent возвращает событие из корзины; если там его нет - ErrEventNotFound.
+	RestoreEvent(ctx context.Context, userID, id string) error
 
-	GetEventByID(ctx context.Context, id string) (*Event, error)
+	// FindConflicts возвращает ID событий владельца event.UserID, кроме самого event, которые
+	// занимают время и пересекаются с event (см. Overlaps). Пересечения не мешают сохранению
+	// событий: что с ними делать, решает приложение по политике пользователя (см. OverlapPolicy).
+	FindConflicts(ctx context.Context, event Event) ([]string, error)
 
-	ListEventsForDay(ctx context.Context, date time.Time) ([]Event, error)
+	// GetEventByID возвращает событие владельцу и приглашенным участникам.
+	GetEventByID(ctx context.Context, userID, id string) (*Event, error)
 
-	ListEventsForWeek(ctx context.Context, startDate time.Time) ([]Event, error)
-	ListEventsForMonth(ctx context.Context, startDate time.Time) ([]Event, error)
+	// RespondToInvitation сохраняет ответ участника userID на приглашение на событие id.
+	// Если пользователь не приглашен, возвращает ErrForbidden.
+	RespondToInvitation(ctx context.Context, userID, id string, status RSVPStatus) error
+	// SharesEvent сообщает, есть ли вне корзины событие, которое видят оба пользователя
+	// (см. Event.VisibleTo): один из них владелец, а другой приглашен, или оба приглашены.
+	SharesEvent(ctx context.Context, userID, otherID string) (bool, error)
 
-	GetEventsToNotify(ctx context.Context) ([]Event, error)
-	MarkEventNotified(ctx context.Context, id string) error
+	// ListEventsForDay, ListEventsForWeek и ListEventsForMonth считают границы суток, недели
+	// и месяца в часовом поясе переданной даты (см. DayBounds). Списки за интервал содержат
+	// вхождения, пересекающиеся с ним, в том числе начавшиеся раньше (многодневные события),
+	// и кроме своих событий - события, приглашение на которые пользователь принял.
+	ListEventsForDay(ctx context.Context, userID string, date time.Time) ([]Event, error)
+	ListEventsForWeek(ctx context.Context, userID string, startDate time.Time) ([]Event, error)
+	ListEventsForMonth(ctx context.Context, userID string, startDate time.Time) ([]Event, error)
+	ListEventsBetween(ctx context.Context, userID string, start, end time.Time) ([]Event, error)
+	// ListEvents возвращает страницу вхождений, упорядоченных по (StartTime, ID).
+	// Как и списки за интервал, включает события, приглашение на которые пользователь принял.
+	ListEvents(ctx context.Context, filter EventFilter) (EventPage, error)
+	// SearchEvents ищет события по словам заголовка и описания, более релевантные - первыми.
+	// Ищет среди своих событий пользователя и событий, приглашение на которые он принял.
+	SearchEvents(ctx context.Context, query SearchQuery) (SearchPage, error)
+
+	// GetEventsToNotify возвращает напоминания, которые пора отправить.
+	GetEventsToNotify(ctx context.Context) ([]DueReminder, error)
+	// ClaimEventsToNotify как GetEventsToNotify, но захватывает возвращенные напоминания
+	// за экземпляром owner на время lease: другие экземпляры их не получат, пока захват
+	// не истечет или напоминание не будет отмечено через MarkEventNotified.
+	ClaimEventsToNotify(ctx context.Context, owner string, lease time.Duration) ([]DueReminder, error)
+	// MarkEventNotified отмечает напоминание reminderID события id отправленным для вхождения,
+	// начинающегося в occurrence, и в той же транзакции ставит msgs (по одному на получателя) в outbox.
+	// Если напоминание об этом вхождении уже отмечено, ничего не делает.
+	MarkEventNotified(ctx context.Context, id, reminderID string, occurrence time.Time, msgs ...OutboxMessage) error
+	// PendingOutbox возвращает до limit неопубликованных сообщений в порядке постановки.
+	PendingOutbox(ctx context.Context, limit int) ([]OutboxMessage, error)
+	// ClaimOutbox как PendingOutbox, но захватывает возвращенные сообщения за owner на lease:
+	// пока захват действует, другие экземпляры их не получают.
+	ClaimOutbox(ctx context.Context, owner string, lease time.Duration, limit int) ([]OutboxMessage, error)
+	// DeleteOutbox удаляет опубликованное сообщение.
+	DeleteOutbox(ctx context.Context, id string) error
+	// DeleteOldEvents переносит в корзину события (серии - по последнему вхождению), начавшиеся раньше olderThan.
 	DeleteOldEvents(ctx context.Context, olderThan time.Time) error
+	// PurgeTrash окончательно удаляет события, перенесенные в корзину раньше deletedBefore.
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) error
+
+	// AddAuditEntry сохраняет запись журнала изменений события; если ID не задан,
+	// хранилище генерирует его через NewID.
+	AddAuditEntry(ctx context.Context, entry AuditEntry) error
+	// ListAuditEntries возвращает журнал изменений события id, начиная с самых ранних записей.
+	// Журнал не удаляется вместе с событием при очистке корзины.
+	ListAuditEntries(ctx context.Context, id string) ([]AuditEntry, error)
+
+	// GetUserSettings возвращает настройки пользователя; если они не сохранялись - нулевые.
+	GetUserSettings(ctx context.Context, userID string) (UserSettings, error)
+	// SaveUserSettings сохраняет настройки; для неизвестного пояса возвращает ErrInvalidTimeZone,
+	// для неверного первого дня недели - ErrInvalidWeekStart.
+	SaveUserSettings(ctx context.Context, settings UserSettings) error
+
+	// RunExclusive выполняет fn, если ни один экземпляр не выполняет задачу name в этот момент,
+	// и сообщает, была ли задача выполнена.
+	RunExclusive(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error)
+
+	// Atomic выполняет fn так, что изменения, сделанные через tx, сохраняются вместе:
+	// если fn вернула ошибку, они отменяются. Так изменение события и запись о нем в журнале
+	// не расходятся.
+	Atomic(ctx context.Context, fn func(tx Storage) error) error
+	// LockEvents внутри Atomic не дает другим вызовам Atomic, тоже заблокировавшим события
+	// владельца userID, выполняться до конца текущего: так проверка пересечений через
+	// FindConflicts и последующая запись не разделяются параллельным изменением.
+	LockEvents(ctx context.Context, userID string) error
 }
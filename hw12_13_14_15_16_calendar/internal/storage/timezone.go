@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// locations кэширует загруженные часовые пояса: time.LoadLocation каждый раз читает базу tzdata.
+var locations sync.Map
+
+// LoadLocation возвращает часовой пояс IANA по имени; пустое имя означает UTC.
+// Для неизвестного пояса возвращает ErrInvalidTimeZone.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTimeZone, name)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// Location возвращает часовой пояс события; для пустого или неизвестного - UTC.
+func (e Event) Location() *time.Location {
+	loc, err := LoadLocation(e.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// DayBounds возвращает границы суток, содержащих date, в часовом поясе date.
+// В дни перехода на летнее или зимнее время сутки длятся 23 или 25 часов.
+func DayBounds(date time.Time) (time.Time, time.Time) {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return start, start.AddDate(0, 0, 1)
+}
+
+// WeekBounds возвращает границы семи суток, начиная с дня startDate, в его часовом поясе.
+func WeekBounds(startDate time.Time) (time.Time, time.Time) {
+	start, _ := DayBounds(startDate)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// MonthBounds возвращает границы месяца, содержащего startDate, в его часовом поясе.
+func MonthBounds(startDate time.Time) (time.Time, time.Time) {
+	start := time.Date(startDate.Year(), startDate.Month(), 1, 0, 0, 0, 0, startDate.Location())
+	return start, start.AddDate(0, 1, 0)
+}
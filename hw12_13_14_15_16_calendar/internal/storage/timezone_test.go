@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadLocation(t *testing.T) {
+	if loc, err := LoadLocation(""); err != nil || loc != time.UTC {
+		t.Errorf("expected UTC for empty name, got %v, %v", loc, err)
+	}
+	if loc, err := LoadLocation("Europe/Moscow"); err != nil || loc.String() != "Europe/Moscow" {
+		t.Errorf("expected Europe/Moscow, got %v, %v", loc, err)
+	}
+	if _, err := LoadLocation("Mars/Olympus"); !errors.Is(err, ErrInvalidTimeZone) {
+		t.Errorf("expected ErrInvalidTimeZone, got %v", err)
+	}
+}
+
+func TestBounds_DST(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+
+	tests := []struct {
+		name   string
+		bounds func(time.Time) (time.Time, time.Time)
+		date   time.Time
+		start  time.Time
+		length time.Duration
+	}{
+		{"SpringForwardDay", DayBounds, time.Date(2026, 3, 8, 15, 0, 0, 0, ny),
+			time.Date(2026, 3, 8, 5, 0, 0, 0, time.UTC), 23 * time.Hour},
+		{"FallBackDay", DayBounds, time.Date(2026, 11, 1, 0, 30, 0, 0, ny),
+			time.Date(2026, 11, 1, 4, 0, 0, 0, time.UTC), 25 * time.Hour},
+		{"WeekWithSpringForward", WeekBounds, time.Date(2026, 3, 5, 0, 0, 0, 0, ny),
+			time.Date(2026, 3, 5, 5, 0, 0, 0, time.UTC), 7*24*time.Hour - time.Hour},
+		{"MonthWithSpringForward", MonthBounds, time.Date(2026, 3, 20, 0, 0, 0, 0, ny),
+			time.Date(2026, 3, 1, 5, 0, 0, 0, time.UTC), 31*24*time.Hour - time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := tt.bounds(tt.date)
+			if !start.Equal(tt.start) || end.Sub(start) != tt.length {
+				t.Errorf("expected [%v, +%v), got [%v, %v)", tt.start, tt.length, start, end)
+			}
+			if end.In(ny).Hour() != 0 {
+				t.Errorf("expected window to end at local midnight, got %v", end.In(ny))
+			}
+		})
+	}
+}
+
+func TestEvent_OccurrencesAcrossDST(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, ny)
+	rule, _ := ParseRRule("FREQ=WEEKLY;COUNT=2")
+	e := Event{StartTime: start.UTC(), EndTime: start.Add(time.Hour).UTC(), RRule: rule, TimeZone: "America/New_York"}
+
+	occ := e.Occurrences(start, start.AddDate(0, 1, 0))
+	if len(occ) != 2 {
+		t.Fatalf("expected 2 occurrences, got %d", len(occ))
+	}
+	// После перехода на летнее время событие остается в 9:00 по местному времени.
+	for _, o := range occ {
+		if local := o.StartTime.In(ny); local.Hour() != 9 || o.EndTime.Sub(o.StartTime) != time.Hour {
+			t.Errorf("expected occurrence at 09:00-10:00 local time, got %v - %v", local, o.EndTime.In(ny))
+		}
+	}
+	if got := occ[1].StartTime.Sub(occ[0].StartTime); got != 7*24*time.Hour-time.Hour {
+		t.Errorf("expected 167h between occurrences, got %v", got)
+	}
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sundayFirstRegions - регионы, где неделя начинается с воскресенья (по CLDR);
+// в остальных она, как в ISO 8601, начинается с понедельника.
+var sundayFirstRegions = map[string]bool{
+	"US": true, "CA": true, "MX": true, "BR": true, "JP": true, "KR": true,
+	"IL": true, "PH": true, "IN": true, "SA": true, "ZA": true,
+}
+
+// ParseWeekday разбирает название дня недели на английском без учета регистра ("monday", "Sun").
+// Для неизвестного названия возвращает ErrInvalidWeekStart.
+func ParseWeekday(s string) (time.Weekday, error) {
+	name := strings.ToLower(s)
+	if len(name) >= 3 {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.HasPrefix(strings.ToLower(d.String()), name) {
+				return d, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrInvalidWeekStart, s)
+}
+
+// WeekStartForLocale возвращает первый день недели для локали вида en-US или ru_RU.
+// Для локали без региона - понедельник.
+func WeekStartForLocale(locale string) time.Weekday {
+	_, region, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	if sundayFirstRegions[strings.ToUpper(region)] {
+		return time.Sunday
+	}
+	return time.Monday
+}
+
+// StartOfWeek возвращает начало недели, содержащей date, в часовом поясе date,
+// если неделя начинается с дня first.
+func StartOfWeek(date time.Time, first time.Weekday) time.Time {
+	day, _ := DayBounds(date)
+	offset := (int(day.Weekday()) - int(first) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// ParseISOWeek разбирает неделю ISO 8601 вида 2026-W42 и возвращает ее понедельник
+// в часовом поясе loc.
+func ParseISOWeek(s string, loc *time.Location) (time.Time, error) {
+	yearStr, weekStr, ok := strings.Cut(s, "-W")
+	year, yerr := strconv.Atoi(yearStr)
+	week, werr := strconv.Atoi(weekStr)
+	if !ok || len(yearStr) != 4 || len(weekStr) != 2 || yerr != nil || werr != nil {
+		return time.Time{}, fmt.Errorf("invalid ISO week %q, want YYYY-Www", s)
+	}
+
+	// 4 января всегда приходится на первую неделю года.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	monday := StartOfWeek(jan4, time.Monday).AddDate(0, 0, (week-1)*7)
+	if y, w := monday.ISOWeek(); y != year || w != week {
+		return time.Time{}, fmt.Errorf("invalid ISO week %q: year %d has no week %d", s, year, week)
+	}
+	return monday, nil
+}
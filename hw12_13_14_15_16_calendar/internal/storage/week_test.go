@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseWeekday(t *testing.T) {
+	for s, want := range map[string]time.Weekday{"monday": time.Monday, "Sun": time.Sunday, "SATURDAY": time.Saturday} {
+		if d, err := ParseWeekday(s); err != nil || d != want {
+			t.Errorf("ParseWeekday(%q): expected %v, got %v, %v", s, want, d, err)
+		}
+	}
+	for _, s := range []string{"", "s", "mo", "someday"} {
+		if _, err := ParseWeekday(s); !errors.Is(err, ErrInvalidWeekStart) {
+			t.Errorf("ParseWeekday(%q): expected ErrInvalidWeekStart, got %v", s, err)
+		}
+	}
+}
+
+func TestUserSettings_FirstDayOfWeek(t *testing.T) {
+	tests := []struct {
+		settings UserSettings
+		want     time.Weekday
+	}{
+		{UserSettings{}, time.Monday},
+		{UserSettings{Locale: "ru-RU"}, time.Monday},
+		{UserSettings{Locale: "en_US"}, time.Sunday},
+		{UserSettings{Locale: "en"}, time.Monday},
+		{UserSettings{Locale: "en-US", WeekStart: "monday"}, time.Monday},
+		{UserSettings{Locale: "ar-EG", WeekStart: "saturday"}, time.Saturday},
+	}
+	for _, tt := range tests {
+		if got := tt.settings.FirstDayOfWeek(); got != tt.want {
+			t.Errorf("%+v: expected %v, got %v", tt.settings, tt.want, got)
+		}
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	// Среда после перехода на летнее время 8 марта 2026.
+	date := time.Date(2026, 3, 11, 18, 0, 0, 0, ny)
+
+	if got := StartOfWeek(date, time.Monday); !got.Equal(time.Date(2026, 3, 9, 0, 0, 0, 0, ny)) {
+		t.Errorf("expected Monday 2026-03-09, got %v", got)
+	}
+	sunday := StartOfWeek(date, time.Sunday)
+	if !sunday.Equal(time.Date(2026, 3, 8, 0, 0, 0, 0, ny)) {
+		t.Errorf("expected Sunday 2026-03-08, got %v", sunday)
+	}
+	// Неделя с переходом на летнее время короче на час, но заканчивается в полночь.
+	if start, end := WeekBounds(sunday); end.Sub(start) != 7*24*time.Hour-time.Hour || end.In(ny).Hour() != 0 {
+		t.Errorf("expected a 167-hour week ending at midnight, got [%v, %v)", start, end)
+	}
+}
+
+func TestParseISOWeek(t *testing.T) {
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	tests := map[string]time.Time{
+		"2026-W42": time.Date(2026, 10, 12, 0, 0, 0, 0, moscow),
+		"2026-W01": time.Date(2025, 12, 29, 0, 0, 0, 0, moscow),
+		"2026-W53": time.Date(2026, 12, 28, 0, 0, 0, 0, moscow),
+		"2021-W01": time.Date(2021, 1, 4, 0, 0, 0, 0, moscow),
+	}
+	for s, want := range tests {
+		if got, err := ParseISOWeek(s, moscow); err != nil || !got.Equal(want) {
+			t.Errorf("ParseISOWeek(%q): expected %v, got %v, %v", s, want, got, err)
+		}
+	}
+	for _, s := range []string{"2027-W53", "2026-W00", "2026-W7", "26-W42", "2026-42", "2026-Wxx"} {
+		if _, err := ParseISOWeek(s, moscow); err == nil {
+			t.Errorf("ParseISOWeek(%q): expected error", s)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE events ADD COLUMN rrule TEXT NOT NULL DEFAULT '';
+ALTER TABLE events ADD COLUMN exdates TEXT NOT NULL DEFAULT '';
+ALTER TABLE events ADD COLUMN notified_until TIMESTAMP;
+
+CREATE INDEX idx_events_recurring ON events(user_id) WHERE rrule <> '';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_events_recurring;
+ALTER TABLE events DROP COLUMN notified_until;
+ALTER TABLE events DROP COLUMN exdates;
+ALTER TABLE events DROP COLUMN rrule;
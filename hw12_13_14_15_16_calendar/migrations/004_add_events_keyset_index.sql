@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX idx_events_user_start_id ON events(user_id, start_time, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_events_user_start_id;
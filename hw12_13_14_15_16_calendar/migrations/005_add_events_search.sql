@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE events ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', title), 'A') ||
+    setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_events_search ON events USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_events_search;
+ALTER TABLE events DROP COLUMN search_vector;
@@ -0,0 +1,42 @@
+-- +goose Up
+CREATE TABLE reminders (
+    id VARCHAR(255) PRIMARY KEY,
+    event_id VARCHAR(255) NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    offset_seconds BIGINT NOT NULL CHECK (offset_seconds >= 0),
+    channel VARCHAR(255) NOT NULL DEFAULT '',
+    sent_at TIMESTAMP,
+    -- начало вхождения, о котором напомнили последним
+    sent_for TIMESTAMP
+);
+
+CREATE INDEX idx_reminders_event_id ON reminders(event_id);
+
+-- Единственное напоминание события переносится под ID самого события.
+INSERT INTO reminders (id, event_id, offset_seconds, sent_for)
+SELECT id, id, GREATEST(EXTRACT(EPOCH FROM start_time - notify_at), 0)::BIGINT,
+    CASE WHEN rrule <> '' THEN notified_until WHEN COALESCE(notified, FALSE) THEN start_time END
+FROM events
+WHERE notify_at IS NOT NULL;
+
+ALTER TABLE events DROP COLUMN notify_at;
+ALTER TABLE events DROP COLUMN notified;
+ALTER TABLE events DROP COLUMN notified_until;
+
+-- +goose Down
+ALTER TABLE events ADD COLUMN notify_at TIMESTAMP;
+ALTER TABLE events ADD COLUMN notified BOOLEAN DEFAULT FALSE;
+ALTER TABLE events ADD COLUMN notified_until TIMESTAMP;
+
+-- Из нескольких напоминаний сохраняется самое раннее.
+UPDATE events e
+SET notify_at = e.start_time - r.offset_seconds * INTERVAL '1 second',
+    notified = (e.rrule = '' AND r.sent_for IS NOT NULL),
+    notified_until = CASE WHEN e.rrule <> '' THEN r.sent_for END
+FROM (
+    SELECT DISTINCT ON (event_id) event_id, offset_seconds, sent_for
+    FROM reminders
+    ORDER BY event_id, offset_seconds DESC
+) r
+WHERE r.event_id = e.id;
+
+DROP TABLE IF EXISTS reminders;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE outbox (
+    -- ключ идемпотентности уведомления
+    id VARCHAR(255) PRIMARY KEY,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_outbox_created_at ON outbox(created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox;
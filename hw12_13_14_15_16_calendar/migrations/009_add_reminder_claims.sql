@@ -0,0 +1,9 @@
+-- +goose Up
+-- Захват напоминания экземпляром планировщика: пока claimed_until не наступило,
+-- другие экземпляры его не отправляют.
+ALTER TABLE reminders ADD COLUMN claimed_by VARCHAR(255);
+ALTER TABLE reminders ADD COLUMN claimed_until TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE reminders DROP COLUMN IF EXISTS claimed_until;
+ALTER TABLE reminders DROP COLUMN IF EXISTS claimed_by;
@@ -0,0 +1,36 @@
+-- +goose Up
+-- Моменты времени хранятся с часовым поясом; прежние значения записывались в UTC.
+ALTER TABLE events
+    ALTER COLUMN start_time TYPE TIMESTAMPTZ USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMPTZ USING end_time AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE reminders
+    ALTER COLUMN sent_at TYPE TIMESTAMPTZ USING sent_at AT TIME ZONE 'UTC',
+    ALTER COLUMN sent_for TYPE TIMESTAMPTZ USING sent_for AT TIME ZONE 'UTC',
+    ALTER COLUMN claimed_until TYPE TIMESTAMPTZ USING claimed_until AT TIME ZONE 'UTC';
+ALTER TABLE outbox
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
+
+-- часовой пояс IANA, в котором задано событие; пустой - UTC
+ALTER TABLE events ADD COLUMN time_zone VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE TABLE user_settings (
+    user_id VARCHAR(255) PRIMARY KEY,
+    time_zone VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_settings;
+
+ALTER TABLE events DROP COLUMN IF EXISTS time_zone;
+
+ALTER TABLE outbox
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
+ALTER TABLE reminders
+    ALTER COLUMN sent_at TYPE TIMESTAMP USING sent_at AT TIME ZONE 'UTC',
+    ALTER COLUMN sent_for TYPE TIMESTAMP USING sent_for AT TIME ZONE 'UTC',
+    ALTER COLUMN claimed_until TYPE TIMESTAMP USING claimed_until AT TIME ZONE 'UTC';
+ALTER TABLE events
+    ALTER COLUMN start_time TYPE TIMESTAMP USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMP USING end_time AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'UTC';
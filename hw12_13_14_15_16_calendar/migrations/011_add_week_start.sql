@@ -0,0 +1,8 @@
+-- +goose Up
+-- Первый день недели пользователя: явно заданный week_start, иначе по региону locale.
+ALTER TABLE user_settings ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT '';
+ALTER TABLE user_settings ADD COLUMN week_start VARCHAR(16) NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE user_settings DROP COLUMN IF EXISTS week_start;
+ALTER TABLE user_settings DROP COLUMN IF EXISTS locale;
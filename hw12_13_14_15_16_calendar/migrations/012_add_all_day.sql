@@ -0,0 +1,6 @@
+-- +goose Up
+-- События на весь день: start_time и end_time выровнены по полуночи в time_zone.
+ALTER TABLE events ADD COLUMN all_day BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN IF EXISTS all_day;
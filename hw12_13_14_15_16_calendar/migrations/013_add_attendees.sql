@@ -0,0 +1,16 @@
+-- +goose Up
+-- Участники встреч. position сохраняет порядок, в котором владелец перечислил участников.
+CREATE TABLE event_attendees (
+    event_id VARCHAR(255) NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'required',
+    status VARCHAR(16) NOT NULL DEFAULT 'needs-action',
+    position BIGSERIAL,
+    PRIMARY KEY (event_id, user_id)
+);
+
+-- Списки участника выбирают принятые им приглашения.
+CREATE INDEX idx_event_attendees_user_status ON event_attendees(user_id, status);
+
+-- +goose Down
+DROP TABLE IF EXISTS event_attendees;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Прозрачность события: свободные (free) события не занимают время владельца.
+ALTER TABLE events ADD COLUMN transparency TEXT NOT NULL DEFAULT 'busy';
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN IF EXISTS transparency;
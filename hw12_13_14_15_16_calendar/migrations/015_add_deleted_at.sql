@@ -0,0 +1,9 @@
+-- +goose Up
+-- Корзина: удаленное событие хранится с отметкой deleted_at, пока его не удалит очистка.
+ALTER TABLE events ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_events_deleted_at ON events(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_events_deleted_at;
+ALTER TABLE events DROP COLUMN IF EXISTS deleted_at;
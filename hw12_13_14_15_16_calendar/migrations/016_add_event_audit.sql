@@ -0,0 +1,16 @@
+-- +goose Up
+-- Журнал изменений событий. changes - JSON-массив изменений полей {field, old, new};
+-- записи удаляются вместе с событием при очистке корзины.
+CREATE TABLE event_audit (
+    id VARCHAR(255) PRIMARY KEY,
+    event_id VARCHAR(255) NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    changed_at TIMESTAMP NOT NULL,
+    changes JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX idx_event_audit_event_id ON event_audit(event_id, changed_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS event_audit;
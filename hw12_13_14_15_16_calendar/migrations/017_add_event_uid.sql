@@ -0,0 +1,12 @@
+-- +goose Up
+-- UID события iCalendar: уникален в пределах календаря владельца и не совпадает с id,
+-- который назначает сервер. Для существующих событий UID - их id.
+ALTER TABLE events ADD COLUMN uid VARCHAR(255);
+UPDATE events SET uid = id;
+ALTER TABLE events ALTER COLUMN uid SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_events_user_uid ON events(user_id, uid);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_events_user_uid;
+ALTER TABLE events DROP COLUMN IF EXISTS uid;
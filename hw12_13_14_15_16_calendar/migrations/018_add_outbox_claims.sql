@@ -0,0 +1,9 @@
+-- +goose Up
+-- Захват сообщения outbox экземпляром планировщика: пока claimed_until не наступило,
+-- другие экземпляры его не публикуют.
+ALTER TABLE outbox ADD COLUMN claimed_by VARCHAR(255);
+ALTER TABLE outbox ADD COLUMN claimed_until TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE outbox DROP COLUMN IF EXISTS claimed_until;
+ALTER TABLE outbox DROP COLUMN IF EXISTS claimed_by;
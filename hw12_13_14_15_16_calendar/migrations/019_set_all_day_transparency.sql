@@ -0,0 +1,7 @@
+-- +goose Up
+-- Прозрачность теперь решает и для событий на весь день, а без нее они свободны.
+-- Сохраненные раньше события на весь день время не занимали: сохраняем это поведение.
+UPDATE events SET transparency = 'free' WHERE all_day;
+
+-- +goose Down
+SELECT 1;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Момент удаления хранится с часовым поясом, как и остальные моменты времени (см. 010);
+-- прежние значения записывались в UTC.
+ALTER TABLE events
+    ALTER COLUMN deleted_at TYPE TIMESTAMPTZ USING deleted_at AT TIME ZONE 'UTC';
+
+-- +goose Down
+ALTER TABLE events
+    ALTER COLUMN deleted_at TYPE TIMESTAMP USING deleted_at AT TIME ZONE 'UTC';
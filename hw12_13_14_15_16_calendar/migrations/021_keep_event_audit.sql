@@ -0,0 +1,14 @@
+-- +goose Up
+-- Журнал сохраняется и после очистки корзины, поэтому event_id больше не ссылается на events.
+-- Момент изменения хранится с часовым поясом (см. 010); прежние значения записывались в UTC.
+ALTER TABLE event_audit DROP CONSTRAINT IF EXISTS event_audit_event_id_fkey;
+ALTER TABLE event_audit
+    ALTER COLUMN changed_at TYPE TIMESTAMPTZ USING changed_at AT TIME ZONE 'UTC';
+
+-- +goose Down
+ALTER TABLE event_audit
+    ALTER COLUMN changed_at TYPE TIMESTAMP USING changed_at AT TIME ZONE 'UTC';
+-- Записи об окончательно удаленных событиях не восстановить вместе со ссылкой.
+DELETE FROM event_audit WHERE event_id NOT IN (SELECT id FROM events);
+ALTER TABLE event_audit
+    ADD CONSTRAINT event_audit_event_id_fkey FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE;